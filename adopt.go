@@ -0,0 +1,187 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/extract"
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+// cmdAdopt renames notes that have a Denote identifier in their front
+// matter but not in their file name into the Denote naming scheme.
+func cmdAdopt(args []string) error {
+	fs := flag.NewFlagSet("adopt", flag.ContinueOnError)
+	dryRun := fs.Bool("n", false, "print the renames without performing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		notes, err := extract.Foreign(dir)
+		if err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			notes, err = selectNotes(notes, fs.Args())
+			if err != nil {
+				return err
+			}
+		}
+		existing, err := metadata.ScanDir(dir)
+		if err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(existing))
+		for _, md := range existing {
+			taken[md.Identifier] = true
+		}
+
+		adopted := 0
+		for _, md := range notes {
+			newPath := extract.AdoptedPath(md)
+			if taken[md.Identifier] {
+				fmt.Fprintf(os.Stderr, "skipping %s: identifier %s is already in use\n", md.Path, md.Identifier)
+				continue
+			}
+			fmt.Printf("%s -> %s\n", md.Path, filepath.Base(newPath))
+			if *dryRun {
+				continue
+			}
+			if _, err := os.Stat(newPath); err == nil {
+				return fmt.Errorf("%s already exists", newPath)
+			}
+			if err := checkUnsaved(md.Path); err != nil {
+				return err
+			}
+			if err := util.Rename(md.Path, newPath); err != nil {
+				return err
+			}
+			taken[md.Identifier] = true
+			reloadNoteWindow(md.Path, newPath)
+			autoCommit(f, vcs.RenameMessage(md.Identifier, md.Path, newPath), md.Path, newPath)
+			runHook(hookRename, md.Identifier, md.Path, newPath)
+			adopted++
+		}
+		if adopted == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
+
+// cmdAdoptDir brings every plain file under a directory into the denote
+// directory as a note named by the Denote convention, adding tags to
+// each. Hidden entries and files already named by the convention are
+// skipped.
+func cmdAdoptDir(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: Denote adopt-dir <dir> [tag...]")
+	}
+	src, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	tags := args[1:]
+	if invalid := metadata.ValidateTags(tags); len(invalid) > 0 {
+		return fmt.Errorf("invalid tags: %s", strings.Join(invalid, ", "))
+	}
+	tags, err = checkTags(tags, nil, warnStderr)
+	if err != nil {
+		return err
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		existing, err := metadata.ScanDir(dir)
+		if err != nil {
+			return err
+		}
+		ids := importer.Identifiers{}
+		for _, md := range existing {
+			ids[md.Identifier] = true
+		}
+
+		var adopted, skipped, failed int
+		err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != src && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			if metadata.ParseFilename(path).Identifier != "" {
+				skipped++
+				return nil
+			}
+			newPath, err := importer.Adopt(path, dir, tags, defaultFileType(), ids)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				failed++
+				return nil
+			}
+			fmt.Printf("%s -> %s\n", path, newPath)
+			id := metadata.ParseFilename(newPath).Identifier
+			if util.Within(dir, path) {
+				autoCommit(f, vcs.RenameMessage(id, path, newPath), path, newPath)
+				runHook(hookRename, id, path, newPath)
+			} else {
+				autoCommit(f, vcs.CreateMessage(id, newPath), newPath)
+				runHook(hookNew, id, "", newPath)
+			}
+			adopted++
+			return nil
+		})
+		fmt.Printf("adopted %d, skipped %d already named, failed %d\n", adopted, skipped, failed)
+		if err != nil {
+			return err
+		}
+		if adopted == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
+
+// selectNotes returns the notes whose paths are named in paths.
+func selectNotes(notes metadata.Results, paths []string) (metadata.Results, error) {
+	byPath := make(map[string]*metadata.Metadata, len(notes))
+	for _, md := range notes {
+		byPath[md.Path] = md
+	}
+	var selected metadata.Results
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		md, ok := byPath[abs]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a note with a front matter identifier in the denote directory", p)
+		}
+		selected = append(selected, md)
+	}
+	return selected, nil
+}
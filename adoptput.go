@@ -0,0 +1,158 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/ignore"
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// adoptPutFile handles a text note Put in the denote directory without
+// a Denote identifier in its name, which the server does not index, as
+// config.AdoptOnPut says. In "ask" mode, each file is offered once; a
+// file is never adopted twice at once.
+func (w *putWatcher) adoptPutFile(path string) {
+	if config.AdoptOnPut != "ask" && config.AdoptOnPut != "on" {
+		return
+	}
+	dir, ok := unadopted(path)
+	if !ok {
+		return
+	}
+	switch config.AdoptOnPut {
+	case "on":
+		w.mu.Lock()
+		busy := w.adopting[path]
+		w.adopting[path] = true
+		w.mu.Unlock()
+		if busy {
+			return
+		}
+		err := adoptPut(dir, path)
+		w.mu.Lock()
+		delete(w.adopting, path)
+		w.mu.Unlock()
+		if err != nil {
+			notifyError("failed to adopt %s: %v", path, err)
+		}
+	case "ask":
+		w.mu.Lock()
+		offer := !w.offered[path]
+		w.offered[path] = true
+		w.mu.Unlock()
+		if offer {
+			acme.Err(dir+"/", fmt.Sprintf("%s is not indexed: it has no Denote identifier. To adopt it:\nDenote adopt-dir %s", path, path))
+		}
+	}
+}
+
+// unadopted reports whether path is a text note below the denote
+// directory, returned, whose name lacks a Denote identifier. Hidden and
+// ignored files are not.
+func unadopted(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".org", ".txt":
+	default:
+		return "", false
+	}
+	if metadata.ParseFilename(path).Identifier != "" {
+		return "", false
+	}
+	dir, err := denoteDir()
+	if err != nil || !util.Within(dir, path) {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", false
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(elem, ".") {
+			return "", false
+		}
+	}
+	if ignoreRules(dir).Ignored(rel, false) {
+		return "", false
+	}
+	return dir, true
+}
+
+// ignoreCache holds the ignore rules of a denote directory, read again
+// only when its ignore file changes.
+var ignoreCache struct {
+	sync.Mutex
+	dir     string
+	modTime time.Time // zero if there is no ignore file
+	rules   *ignore.Matcher
+	loaded  bool
+}
+
+// ignoreRules returns the ignore rules of dir, or nil if it has none or
+// they cannot be read.
+func ignoreRules(dir string) *ignore.Matcher {
+	var modTime time.Time
+	if info, err := os.Stat(filepath.Join(dir, ignore.FileName)); err == nil {
+		modTime = info.ModTime()
+	}
+	ignoreCache.Lock()
+	defer ignoreCache.Unlock()
+	c := &ignoreCache
+	if c.loaded && c.dir == dir && c.modTime.Equal(modTime) {
+		return c.rules
+	}
+	rules, err := ignore.Load(dir)
+	if err != nil {
+		logging.Warnf("failed to read %s: %v", filepath.Join(dir, ignore.FileName), err)
+	}
+	c.dir, c.modTime, c.rules, c.loaded = dir, modTime, rules, true
+	return rules
+}
+
+// adoptPut adopts the file at path in the denote directory dir as
+// Denote adopt-dir does, and registers the new note with the server.
+func adoptPut(dir, path string) error {
+	if err := checkUnsaved(path); err != nil {
+		return err
+	}
+	existing, err := metadata.ScanDir(dir)
+	if err != nil {
+		return err
+	}
+	ids := importer.Identifiers{}
+	for _, md := range existing {
+		ids[md.Identifier] = true
+	}
+	newPath, err := importer.Adopt(path, dir, nil, defaultFileType(), ids)
+	if err != nil {
+		return err
+	}
+	id := metadata.ParseFilename(newPath).Identifier
+	reloadNoteWindow(path, newPath)
+	acme.Err(dir+"/", fmt.Sprintf("adopted %s as %s", path, filepath.Base(newPath)))
+	return p9client.With9P(func(f *client.Fsys) error {
+		autoCommit(f, vcs.CreateMessage(id, newPath), newPath)
+		runHook(hookNew, id, "", newPath)
+		if err := reloadNote(f, dir, id); err != nil {
+			return err
+		}
+		windowsMu.Lock()
+		defer windowsMu.Unlock()
+		for _, iw := range windows {
+			iw.refreshInBackground()
+		}
+		return nil
+	})
+}
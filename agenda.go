@@ -0,0 +1,153 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/encoding/results"
+	"denote/pkg/metadata"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"9fans.net/go/acme"
+)
+
+const agendaWname = "+Agenda"
+
+// agendaDays is how far ahead the agenda looks by default.
+const agendaDays = 14
+
+// readDue returns rs with the Due field of its text notes set from
+// their due front matter field, which the index does not carry. Notes
+// with a due date are copies, since rs may share notes with the cached
+// index.
+func readDue(dir string, rs metadata.Results) (metadata.Results, error) {
+	paths, err := notePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, n := range rs {
+		path := paths[n.Identifier]
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".md" && ext != ".org" && ext != ".txt" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		fm, _, _ := frontmatter.Unmarshal(content, ext)
+		if fm.Due == "" {
+			continue
+		}
+		due, err := metadata.ParseDue(fm.Due, time.Local)
+		if err != nil {
+			logging.Debugf("%s: %v", path, err)
+			continue
+		}
+		md := *n
+		md.Due = due
+		rs[i] = &md
+	}
+	return rs, nil
+}
+
+// agenda returns the notes that are overdue or due within days, earliest
+// first.
+func agenda(days int) (metadata.Results, error) {
+	rs, err := search("due:")
+	if err != nil {
+		return nil, err
+	}
+	return metadata.Agenda(rs, time.Now(), days), nil
+}
+
+// formatAgenda prefixes the index line of every note with its due date
+// and how far off it is, so that the identifier can still be opened.
+func formatAgenda(rs metadata.Results, now time.Time) []byte {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var buf strings.Builder
+	for _, n := range rs {
+		date := n.Due.Format("2006-01-02")
+		if n.Due.Hour() != 0 || n.Due.Minute() != 0 {
+			date = n.Due.Format("2006-01-02 15:04")
+		}
+		due := time.Date(n.Due.Year(), n.Due.Month(), n.Due.Day(), 0, 0, 0, 0, now.Location())
+		var when string
+		switch days := int(due.Sub(today).Hours() / 24); {
+		case days < 0:
+			when = "overdue"
+		case days == 0:
+			when = "today"
+		default:
+			when = fmt.Sprintf("in %dd", days)
+		}
+		fmt.Fprintf(&buf, "%-16s %-8s %s", date, when, results.Marshal(metadata.Results{n}))
+	}
+	return []byte(buf.String())
+}
+
+// cmdAgenda prints the notes that are overdue or due within -days days,
+// earliest first.
+func cmdAgenda(args []string) error {
+	fs := flag.NewFlagSet("agenda", flag.ContinueOnError)
+	days := fs.Int("days", agendaDays, "list notes due within `n` days")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: Denote agenda [-days n]")
+	}
+	rs, err := agenda(*days)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(formatAgenda(rs, time.Now()))
+	return nil
+}
+
+// openAgendaWindow shows the agenda in a +Agenda window. Get refreshes
+// it; right-clicking an identifier opens the note.
+func openAgendaWindow() {
+	w := acme.Show(wname + agendaWname)
+	if w != nil {
+		refreshAgenda(w)
+		return
+	}
+	w, err := acme.New()
+	if err != nil {
+		logging.Errorf("failed to open agenda window: %v", err)
+		return
+	}
+	w.Name(wname + agendaWname)
+	w.Write("tag", []byte("Get"))
+	refreshAgenda(w)
+
+	go func() {
+		defer w.CloseFiles()
+		for e := range w.EventChan() {
+			if (e.C2 == 'x' || e.C2 == 'X') && string(e.Text) == "Get" {
+				refreshAgenda(w)
+				continue
+			}
+			w.WriteEvent(e)
+		}
+	}()
+}
+
+func refreshAgenda(w *acme.Win) {
+	rs, err := agenda(agendaDays)
+	if err != nil {
+		notifyError("failed to read agenda: %v", err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", formatAgenda(rs, time.Now()))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
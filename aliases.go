@@ -0,0 +1,48 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/metadata"
+	"fmt"
+	"sort"
+)
+
+// resolveAlias returns the identifier that replaced id if id is an alias
+// recorded by redate, and id otherwise.
+func resolveAlias(id string) string {
+	dir, err := denoteDir()
+	if err != nil {
+		return id
+	}
+	aliases, err := metadata.LoadAliases(dir)
+	if err != nil {
+		logging.Warnf("failed to load aliases: %v", err)
+		return id
+	}
+	return aliases.Resolve(id)
+}
+
+// cmdAliases lists the identifiers notes no longer have, each with the
+// identifier denote: links to it resolve to.
+func cmdAliases(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote aliases")
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	aliases, err := metadata.LoadAliases(dir)
+	if err != nil {
+		return err
+	}
+	olds := make([]string, 0, len(aliases))
+	for old := range aliases {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		fmt.Printf("denote:%s -> denote:%s\n", old, aliases.Resolve(old))
+	}
+	return nil
+}
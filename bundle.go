@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/bundle"
+	"denote/pkg/config"
+	"denote/pkg/metadata"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+const bundleUsage = "usage: Denote bundle [-o file.tgz|file.zip] [-crypt keep|skip|decrypt] [-encrypt recipient] <filter...>"
+
+// cmdBundle writes the notes matching a Look query, the notes they link
+// to, and their attachments to an archive. The format follows the
+// output name: .zip for zip, otherwise gzip-compressed tar. Options may
+// come before or after the query.
+//
+// Encrypted notes (config.CryptExt) are kept as they are unless -crypt
+// says to skip them or, after confirmation, decrypt them with gpg.
+// -encrypt encrypts every file leaving the bundle in plaintext to
+// recipient instead.
+func cmdBundle(ctx context.Context, args []string) error {
+	opts := map[string]string{"-o": "bundle.tgz", "-crypt": "keep", "-encrypt": ""}
+	var query []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if _, ok := opts[name]; !ok {
+			query = append(query, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return fmt.Errorf(bundleUsage)
+			}
+			i++
+			value = args[i]
+		}
+		opts[name] = value
+	}
+	out, mode, recipient := opts["-o"], opts["-crypt"], opts["-encrypt"]
+	if len(query) == 0 || (mode != "keep" && mode != "skip" && mode != "decrypt") {
+		return fmt.Errorf(bundleUsage)
+	}
+
+	var dir string
+	if err := p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		dir, err = readDenoteDir(f)
+		return err
+	}); err != nil {
+		return err
+	}
+	rs, err := search(quoteArgs(query))
+	if err != nil {
+		return err
+	}
+	notes, err := metadata.ScanDirContext(ctx, dir)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, n := range rs {
+		ids = append(ids, n.Identifier)
+	}
+	b, err := bundle.Collect(dir, ids, notes)
+	if err != nil {
+		return err
+	}
+	if err := cryptBundle(b, mode, recipient); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(strings.ToLower(out), ".zip") {
+		err = b.WriteZip(f)
+	} else {
+		err = b.WriteTar(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d files\n", out, len(b.Entries))
+	return nil
+}
+
+// cryptBundle prepares the encrypted and plaintext files of b for
+// export: encrypted files are kept, dropped (mode "skip"), or decrypted
+// (mode "decrypt"), and if recipient is set every file that is not
+// already encrypted, including decrypted ones, is encrypted to it.
+func cryptBundle(b *bundle.Bundle, mode, recipient string) error {
+	var entries []bundle.Entry
+	var encrypted int
+	for _, e := range b.Entries {
+		if !strings.HasSuffix(e.Path, config.CryptExt) {
+			if recipient != "" {
+				e.Name += config.CryptExt
+			}
+			entries = append(entries, e)
+			continue
+		}
+		switch mode {
+		case "skip":
+			continue
+		case "decrypt":
+			if recipient == "" {
+				e.Name = strings.TrimSuffix(e.Name, config.CryptExt)
+			}
+		}
+		encrypted++
+		entries = append(entries, e)
+	}
+	b.Entries = entries
+
+	if mode == "decrypt" && encrypted > 0 {
+		fmt.Fprintf(os.Stderr, "decrypt %d encrypted notes into the bundle? [y/N] ", encrypted)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("bundle cancelled")
+		}
+	}
+	if mode != "decrypt" && recipient == "" {
+		return nil
+	}
+	b.Convert = func(e bundle.Entry, content []byte) ([]byte, error) {
+		if strings.HasSuffix(e.Path, config.CryptExt) {
+			if mode != "decrypt" {
+				return content, nil
+			}
+			var err error
+			if content, err = gpg(content, "--decrypt"); err != nil {
+				return nil, err
+			}
+		}
+		if recipient == "" {
+			return content, nil
+		}
+		return gpg(content, "--encrypt", "--recipient", recipient)
+	}
+	return nil
+}
+
+// gpg runs gpg with args, feeding it content and returning its output.
+func gpg(content []byte, args ...string) ([]byte, error) {
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("gpg", append([]string{"--quiet", "--yes"}, args...)...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gpg: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out.Bytes(), nil
+}
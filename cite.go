@@ -0,0 +1,103 @@
+package main
+
+import (
+	"denote/pkg/bibtex"
+	"denote/pkg/config"
+	"denote/pkg/importer"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"9fans.net/go/acme"
+)
+
+// cmdCite searches the bibliography. A single match is inserted as a
+// citation at dot in the calling acme window ($winid), or printed when
+// run outside acme; with -note a reference note is created for it
+// instead. Several matches are listed so the query can be refined.
+func cmdCite(args []string) error {
+	fs := flag.NewFlagSet("cite", flag.ContinueOnError)
+	file := fs.String("file", config.BibFile, "BibTeX file to search")
+	note := fs.Bool("note", false, "create a reference note for the entry")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: Denote cite [-note] [-file refs.bib] <key|query>")
+	}
+	if *file == "" {
+		return fmt.Errorf("no bibliography: set BibFile or pass -file")
+	}
+
+	entries, err := bibtex.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	matches := bibtex.Search(entries, strings.Join(fs.Args(), " "))
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no entry matches %q", strings.Join(fs.Args(), " "))
+	case 1:
+	default:
+		for _, e := range matches {
+			fmt.Printf("%s | %s | %s | %s\n", e.Key, e.Author(), e.Year(), e.Title())
+		}
+		return nil
+	}
+
+	e := matches[0]
+	if *note {
+		path, err := createNote(referenceNote(e))
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	}
+	return insertCitation(e.Key)
+}
+
+// referenceNote describes a bibliography entry as a note.
+func referenceNote(e bibtex.Entry) importer.Note {
+	title := e.Title()
+	if title == "" {
+		title = e.Key
+	}
+	var body strings.Builder
+	if a := e.Author(); a != "" {
+		fmt.Fprintf(&body, "Author: %s  \n", a)
+	}
+	if y := e.Year(); y != "" {
+		fmt.Fprintf(&body, "Year: %s  \n", y)
+	}
+	fmt.Fprintf(&body, "Key: %s\n", e.Key)
+	return importer.Note{Date: time.Now(), Title: title, Tags: config.BibTags, Body: body.String(), Bib: e.Key}
+}
+
+// insertCitation replaces the selection in the calling acme window with a
+// citation of key: [cite:@key] in org notes, [@key] otherwise.
+func insertCitation(key string) error {
+	winid, err := strconv.Atoi(os.Getenv("winid"))
+	if err != nil {
+		fmt.Println(key)
+		return nil
+	}
+	w, err := acme.Open(winid, nil)
+	if err != nil {
+		return err
+	}
+	defer w.CloseFiles()
+
+	cite := "[@" + key + "]"
+	if tag, err := w.ReadAll("tag"); err == nil {
+		if fields := strings.Fields(string(tag)); len(fields) > 0 && strings.HasSuffix(fields[0], ".org") {
+			cite = "[cite:@" + key + "]"
+		}
+	}
+	w.Ctl("addr=dot")
+	_, err = w.Write("data", []byte(cite))
+	return err
+}
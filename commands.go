@@ -0,0 +1,501 @@
+package main
+
+import (
+	"context"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/encoding/results"
+	"denote/pkg/extract"
+	"denote/pkg/metadata"
+	"denote/pkg/templates"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// command is a Denote subcommand invoked as `Denote <name> [args...]`.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"adopt":                   {"adopt [-n] [file...]", cmdAdopt},
+	"aliases":                 {"aliases", cmdAliases},
+	"adopt-dir":               {"adopt-dir <dir> [tag...]", cmdAdoptDir},
+	"diff":                    {"diff <identifier> [rev]", cmdDiff},
+	"grep":                    {"grep <regexp> [filter...]", cmdGrep},
+	"history":                 {"history <identifier>", cmdHistory},
+	"spell":                   {"spell", cmdSpell},
+	"snapshot":                {"snapshot [-r reason] <identifier>", cmdSnapshot},
+	"snapshots":               {"snapshots <identifier>", cmdSnapshots},
+	"suggest":                 {"suggest <identifier>", cmdSuggest},
+	"journal":                 {"journal streak|import [-format jrnl|dayone] [-type md-yaml] [-encrypt] <file>", cmdJournal},
+	"locks":                   {"locks", cmdLocks},
+	"log":                     {"log [-n lines]", cmdLog},
+	"audit-encryption":        {"audit-encryption", cmdAuditEncryption},
+	"bundle":                  {"bundle [-o file.tgz|file.zip] [-crypt keep|skip|decrypt] [-encrypt recipient] <filter...>", cancellable(cmdBundle)},
+	"cite":                    {"cite [-note] [-file refs.bib] <key|query>", cmdCite},
+	"commit":                  {"commit [message]", cmdCommit},
+	"complete":                {"complete tags|titles [prefix]", cmdComplete},
+	"config":                  {"config [key]", cmdConfig},
+	"agenda":                  {"agenda [-days n]", cmdAgenda},
+	"conflicts":               {"conflicts", cmdConflicts},
+	"convert":                 {"convert [-n] <identifier> -to org|md-yaml|md-toml|txt", cmdConvert},
+	"decrypt":                 {"decrypt <identifier>", cmdDecrypt},
+	"duplicates":              {"duplicates", cmdDuplicates},
+	"encrypt":                 {"encrypt [-r recipient] <identifier>", cmdEncrypt},
+	"feed":                    {"feed [-tag tag] [-title t] [-link url] [-author name] [-n 20] [-out file] [filter...]", cmdFeed},
+	"migrate-frontmatter":     {"migrate-frontmatter [-n] -from <type> -to <type> [filters]", cancellable(cmdMigrateFrontMatter)},
+	"new":                     {"new [-date YYYYMMDD[Thhmmss]] [@template] <title> [tag...]", cmdNew},
+	"orphans":                 {"orphans [-days n] [-x tag,...]", cmdOrphans},
+	"preview":                 {"preview [-html|-web] [-name file] [identifier|file]", cmdPreview},
+	"redate":                  {"redate <identifier> <YYYYMMDD[Thhmmss]>", cmdRedate},
+	"reload":                  {"reload [identifier...]", cmdReload},
+	"rename-batch":            {"rename-batch [-n] <file>", cancellable(cmdRenameBatch)},
+	"rename-from-frontmatter": {"rename-from-frontmatter [-n] <file|dir>...", cancellable(cmdRenameFromFrontMatter)},
+	"restore":                 {"restore <identifier> <snapshot>", cmdRestore},
+	"set":                     {"set <identifier> field=value...", cmdSet},
+	"signature":               {"signature -next-sibling|-child <identifier>", cmdSignature},
+	"title":                   {"title <file>", cmdTitle},
+	"trash":                   {"trash list|restore <identifier>...|purge [-days n]", cmdTrash},
+	"tree":                    {"tree", cmdTree},
+	"views":                   {"views <dir>", cmdViews},
+	"search":                  {"search [-lines] [sort:id|title|tags|relevance[,asc]] [filter...]", cmdSearch},
+	"serve":                   {"serve [addr]", cmdServe},
+	"import-mail":             {"import-mail [-tags a,b] [-type md-yaml] [-from re] [-subject re] [-since YYYYMMDD] <maildir|mbox>", cmdImportMail},
+}
+
+// cancellable adapts a command that takes a context to the command
+// table. The context is cancelled on an interrupt (^C) or SIGTERM, so
+// that a bulk command can stop between notes, leaving none half done,
+// and report how far it got.
+func cancellable(run func(ctx context.Context, args []string) error) func([]string) error {
+	return func(args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return run(ctx, args)
+	}
+}
+
+// runCommand dispatches args[0] to the matching subcommand.
+func runCommand(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage()
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	return cmd.run(args[1:])
+}
+
+func printUsage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage: Denote [-v] [-dir path] [-service name] [denote:<identifier>]")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "       Denote %s\n", commands[name].usage)
+	}
+}
+
+// cmdConfig prints the value of a config key, or every key=value pair in
+// config file syntax, after the config file and environment are applied.
+func cmdConfig(args []string) error {
+	switch len(args) {
+	case 0:
+		fmt.Printf("# %s\n", config.Path())
+		for _, key := range config.Keys() {
+			v, _ := config.Get(key)
+			fmt.Printf("%s=%s\n", key, v)
+		}
+		return nil
+	case 1:
+		v, ok := config.Get(args[0])
+		if !ok {
+			return fmt.Errorf("unknown config key: %s", args[0])
+		}
+		fmt.Println(v)
+		return nil
+	}
+	return fmt.Errorf("usage: Denote config [key]")
+}
+
+// cmdSearch prints the notes matching a Look query. Each argument is one
+// filter, so shell-quoted arguments may contain spaces. With -lines it
+// prints the matching lines of a body: search instead.
+func cmdSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	lines := fs.Bool("lines", false, "list the matching lines of body: searches")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *lines {
+		ms, err := searchMatches(quoteArgs(fs.Args()))
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(results.MarshalMatches(ms))
+		return nil
+	}
+	rs, err := search(quoteArgs(fs.Args()))
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(results.Marshal(rs))
+	return nil
+}
+
+// cmdComplete prints the tags or titles of every note that start with
+// prefix, one per line, for editors and scripts that offer completion.
+func cmdComplete(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: Denote complete tags|titles [prefix]")
+	}
+	var prefix string
+	if len(args) == 2 {
+		prefix = args[1]
+	}
+	rs, err := search("")
+	if err != nil {
+		return err
+	}
+	var matches []string
+	switch args[0] {
+	case "tags":
+		matches = completeTags(rs, prefix)
+	case "titles":
+		matches = metadata.CompleteTitles(rs, prefix)
+	default:
+		return fmt.Errorf("usage: Denote complete tags|titles [prefix]")
+	}
+	for _, m := range matches {
+		fmt.Println(m)
+	}
+	return nil
+}
+
+// quoteArgs joins shell arguments into a Look query, quoting those that
+// contain spaces so each stays one filter.
+func quoteArgs(args []string) string {
+	var quoted []string
+	for _, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// cmdTitle prints the title stored inside a PDF, HTML, or EPUB file, or
+// the display title of a text note (see config.TitleFallback).
+func cmdTitle(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote title <file>")
+	}
+	switch ext := filepath.Ext(args[0]); ext {
+	case ".md", ".org", ".txt":
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(extract.TextTitle(content, ext, extract.Fallback(config.TitleFallback)))
+		return nil
+	}
+	if !extract.Supported(args[0]) {
+		return fmt.Errorf("no title extractor for %s", filepath.Ext(args[0]))
+	}
+	title, err := extract.Title(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(title)
+	return nil
+}
+
+// cmdNew creates a note as New in the /Denote/ window does, e.g.
+// "Denote new 'a title' tag1 tag2", applying the tag vocabulary and
+// subdirectory routes. With -date the note is backdated, and a first
+// argument @name applies the template name.
+func cmdNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	date := fs.String("date", "", "create the note at `YYYYMMDD[Thhmmss]` instead of now")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	var tmpl string
+	if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		tmpl, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: Denote new [-date YYYYMMDD[Thhmmss]] [@template] <title> [tag...]")
+	}
+	input := "'" + strings.ReplaceAll(args[0], "'", "''") + "' " + strings.Join(args[1:], ",")
+	if tmpl != "" {
+		input = tmpl + " " + input
+	}
+	if *date != "" {
+		input = *date + " " + input
+	}
+	return writeNew(strings.TrimSpace(input), warnStderr)
+}
+
+// writeNew writes input, 'title' tag1,tag2, to the server's new file
+// after checking its tags and routing it to a subdirectory. Input may
+// start with a date, YYYYMMDD or YYYYMMDDThhmmss, to create the note at
+// that time rather than now; the identifier asked for is the first free
+// one from that time on. It may then name a template in config.TemplateDir,
+// as in @meeting 'Weekly sync' team, whose subdirectory and tags apply to
+// the note and whose body is added to the new note's window.
+func writeNew(input string, warn func(format string, args ...any)) error {
+	date, rest, _ := strings.Cut(input, " ")
+	if !metadata.IsIdentifier(date) && !isDay(date) {
+		date, rest = "", input
+	}
+	rest = strings.TrimSpace(rest)
+	var tmpl *templates.Template
+	if name, after, ok := strings.Cut(rest, " "); ok && strings.HasPrefix(name, "@") {
+		t, err := templates.Load(config.TemplateDir, name[1:])
+		if err != nil {
+			return err
+		}
+		tmpl, rest = t, applyTemplate(t, strings.TrimSpace(after))
+	}
+	rest, err := checkNewInput(rest, warn)
+	if err != nil {
+		return err
+	}
+	warnUnencrypted(rest, warn)
+	return p9client.With9P(func(f *client.Fsys) error {
+		if date != "" {
+			id, err := freeIdentifierAt(f, date)
+			if err != nil {
+				return err
+			}
+			date = id
+		}
+		var open map[int]bool
+		if tmpl != nil {
+			if open, err = windowIDs(); err != nil {
+				return err
+			}
+		}
+		if err := newNoteAt(f, date, rest, warn); err != nil {
+			return err
+		}
+		if tmpl != nil {
+			return fillNewWindow(tmpl, open, rest)
+		}
+		return nil
+	})
+}
+
+// newNoteAt has the server create the note input, 'title' tags, and
+// open a window on it. A note with identifier id is created with the ctl
+// command "new <id> 'title' tags"; servers without that command are
+// sent the plain input, after a warning, and create the note now.
+func newNoteAt(f *client.Fsys, id, input string, warn func(format string, args ...any)) error {
+	if id != "" {
+		if p9client.WriteFile(f, "ctl", "new "+id+" "+input) == nil {
+			return nil
+		}
+		warn("the server cannot create notes at a given date; creating the note now")
+	}
+	return p9client.WriteFile(f, "new", input)
+}
+
+// applyTemplate returns the New input 'title' tags with the subdirectory
+// and tags of t. A title naming its own subdirectory keeps it.
+func applyTemplate(t *templates.Template, input string) string {
+	i := strings.LastIndex(input, "'")
+	if i <= 0 || !strings.HasPrefix(input, "'") {
+		return input
+	}
+	title, tags := input[:i+1], strings.TrimSpace(input[i+1:])
+	if t.Dir != "" && !strings.Contains(title, "/:") {
+		title = "'" + t.Dir + "/:" + title[1:]
+	}
+	if len(t.Tags) > 0 {
+		tags = strings.Trim(tags+","+strings.Join(t.Tags, ","), ",")
+	}
+	return strings.TrimSpace(title + " " + tags)
+}
+
+// windowIDs returns the IDs of the open acme windows.
+func windowIDs() (map[int]bool, error) {
+	wins, err := acme.Windows()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int]bool, len(wins))
+	for _, wi := range wins {
+		ids[wi.ID] = true
+	}
+	return ids, nil
+}
+
+// fillNewWindow appends the body of t to the window the server opened for
+// the new note created from input: the first window, of those not in
+// open, named after a note with input's title. The note's identifier is
+// taken from the window name, since the server picks it.
+func fillNewWindow(t *templates.Template, open map[int]bool, input string) error {
+	i := strings.LastIndex(input, "'")
+	title, _ := metadata.Unquote(input[:i+1])
+	if _, after, ok := strings.Cut(title, "/:"); ok {
+		title = after
+	}
+	// The title as it reads back from a file name.
+	want := metadata.ParseFilename(metadata.BuildFilename(&metadata.FrontMatter{
+		Identifier: metadata.GenerateIdentifier(),
+		Title:      title,
+	}, "")).Title
+	// The server opens the window asynchronously.
+	for try := 0; try < 20; try++ {
+		wins, err := acme.Windows()
+		if err != nil {
+			return err
+		}
+		for _, wi := range wins {
+			n := metadata.ParseFilename(wi.Name)
+			if open[wi.ID] || n.Identifier == "" || n.Title != want {
+				continue
+			}
+			date, _ := time.ParseInLocation("20060102T150405", n.Identifier, time.Local)
+			body, err := t.Execute(templates.Vars{
+				Identifier: n.Identifier,
+				Title:      title,
+				Tags:       strings.Split(strings.TrimSpace(input[i+1:]), ","),
+				Date:       date,
+			})
+			if err != nil {
+				return err
+			}
+			w, err := acme.Open(wi.ID, nil)
+			if err != nil {
+				return err
+			}
+			defer w.CloseFiles()
+			if err := w.Addr("$"); err != nil {
+				return err
+			}
+			_, err = w.Write("data", []byte(body))
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("no window for new note %q", title)
+}
+
+// isDay reports whether s is a date in the form YYYYMMDD.
+func isDay(s string) bool {
+	_, err := time.ParseInLocation("20060102", s, time.Local)
+	return err == nil && len(s) == len("20060102")
+}
+
+// freeIdentifierAt returns the first identifier from date on that no note
+// in the denote directory uses, nor any alias. A date without a time of
+// day takes the current one.
+func freeIdentifierAt(f *client.Fsys, date string) (string, error) {
+	t, err := parseRedate(date, metadata.GenerateIdentifier())
+	if err != nil {
+		return "", err
+	}
+	dir, err := readDenoteDir(f)
+	if err != nil {
+		return "", err
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(notes))
+	for _, n := range notes {
+		taken[n.Identifier] = true
+	}
+	aliases, err := metadata.LoadAliases(dir)
+	if err != nil {
+		return "", err
+	}
+	for old := range aliases {
+		taken[old] = true
+	}
+	return metadata.FreeIdentifier(t, taken), nil
+}
+
+// cmdHistory prints the git log of a single note.
+func cmdHistory(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote history <identifier>")
+	}
+	identifier := strings.TrimPrefix(args[0], "denote:")
+	return p9client.With9P(func(f *client.Fsys) error {
+		repo, err := openRepo(f)
+		if err != nil {
+			return err
+		}
+		path, err := p9client.ReadFile(f, "n/"+identifier+"/path")
+		if err != nil {
+			return fmt.Errorf("failed to read path for %s: %w", identifier, err)
+		}
+		out, err := repo.Log(path)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	})
+}
+
+// cmdCommit commits all pending changes in the denote directory.
+func cmdCommit(args []string) error {
+	msg := strings.Join(args, " ")
+	if msg == "" {
+		msg = "commit " + metadata.GenerateIdentifier()
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		repo, err := openRepo(f)
+		if err != nil {
+			return err
+		}
+		return repo.Commit(msg)
+	})
+}
+
+// openRepo opens the git repository containing the current denote directory.
+func openRepo(f *client.Fsys) (*vcs.Repo, error) {
+	dir, err := readDenoteDir(f)
+	if err != nil {
+		return nil, err
+	}
+	return vcs.Open(dir)
+}
+
+// autoCommit records a change in git when config.GitAutoCommit is enabled.
+// Failures are logged rather than returned so that versioning never blocks
+// a metadata operation that already succeeded.
+func autoCommit(f *client.Fsys, msg string, paths ...string) {
+	if !config.GitAutoCommit {
+		return
+	}
+	repo, err := openRepo(f)
+	if err != nil {
+		notifyError("git auto-commit disabled: %v", err)
+		return
+	}
+	if err := repo.Commit(msg, paths...); err != nil {
+		notifyError("git auto-commit failed: %v", err)
+	}
+}
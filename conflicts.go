@@ -0,0 +1,148 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/conflict"
+	"denote/pkg/metadata"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const conflictsWname = "+Conflicts"
+
+// mergeAnnotation introduces the content of a merged conflicting copy.
+const mergeAnnotation = "MERGED CONFLICT:"
+
+// findConflicts lists conflicting copies in the current denote directory.
+func findConflicts() ([]conflict.Conflict, error) {
+	dir, err := denoteDir()
+	if err != nil {
+		return nil, err
+	}
+	return conflict.Find(dir)
+}
+
+func formatConflicts(cs []conflict.Conflict) []byte {
+	var buf strings.Builder
+	for _, c := range cs {
+		fmt.Fprintf(&buf, "%s -> %s\n", c.Path, filepath.Base(c.Original))
+	}
+	return []byte(buf.String())
+}
+
+// cmdConflicts prints conflicting copies, one per line.
+func cmdConflicts(args []string) error {
+	cs, err := findConflicts()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(formatConflicts(cs)))
+	return nil
+}
+
+// openConflictsWindow shows conflicting copies in a +Conflicts window whose
+// tag commands resolve the chorded line: Delete discards the copy, Take
+// replaces the original with it, and Merge appends it to the original.
+func openConflictsWindow() {
+	w := acme.Show(wname + conflictsWname)
+	if w != nil {
+		refreshConflicts(w)
+		return
+	}
+	w, err := acme.New()
+	if err != nil {
+		logging.Errorf("failed to open conflicts window: %v", err)
+		return
+	}
+	w.Name(wname + conflictsWname)
+	w.Write("tag", []byte("Delete Take Merge Get"))
+	refreshConflicts(w)
+
+	go func() {
+		defer w.CloseFiles()
+		for e := range w.EventChan() {
+			if e.C2 != 'x' && e.C2 != 'X' {
+				w.WriteEvent(e)
+				continue
+			}
+			cmd := string(e.Text)
+			switch cmd {
+			case "Delete", "Take", "Merge":
+				if err := resolveConflict(cmd, string(e.Arg)); err != nil {
+					notifyError("failed to resolve conflict: %v", err)
+				}
+				refreshConflicts(w)
+			case "Get":
+				refreshConflicts(w)
+			default:
+				w.WriteEvent(e)
+			}
+		}
+	}()
+}
+
+func refreshConflicts(w *acme.Win) {
+	cs, err := findConflicts()
+	if err != nil {
+		logging.Errorf("failed to find conflicts: %v", err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", formatConflicts(cs))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// resolveConflict applies cmd to the conflicting copy named by line, which
+// is either a bare path or a line from the +Conflicts window.
+func resolveConflict(cmd, line string) error {
+	path, _, _ := strings.Cut(strings.TrimSpace(line), " -> ")
+	if path == "" {
+		return fmt.Errorf("%s: no conflicting copy selected", cmd)
+	}
+	orig, ok := conflict.Original(filepath.Base(path))
+	if !ok {
+		return fmt.Errorf("%s: not a conflicting copy: %s", cmd, path)
+	}
+	c := conflict.Conflict{Path: path, Original: filepath.Join(filepath.Dir(path), orig)}
+	for _, p := range []string{c.Path, c.Original} {
+		if err := checkUnsaved(p); err != nil {
+			return fmt.Errorf("%s: %w", cmd, err)
+		}
+	}
+	if cmd != "Delete" {
+		snapshotNote(metadata.ParseFilename(c.Original).Identifier, c.Original, strings.ToLower(cmd))
+	}
+	var err error
+	switch cmd {
+	case "Delete":
+		err = c.Delete()
+	case "Take":
+		err = c.Take()
+	default:
+		err = c.Merge(mergeAnnotation)
+	}
+	if err != nil {
+		return err
+	}
+	if cmd != "Delete" {
+		reloadNoteWindow(c.Original, c.Original)
+	}
+
+	// The copy shares its identifier with the original, so the index has
+	// to be rebuilt to drop the duplicate entry.
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		return reloadIndex(f, dir)
+	})
+}
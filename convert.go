@@ -0,0 +1,126 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"denote/pkg/render"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+const convertUsage = "usage: Denote convert [-n] <identifier> -to org|md-yaml|md-toml|txt"
+
+// cmdConvert converts a note to another file type, e.g. "Denote convert
+// 20240101T120000 -to org": its front matter and markup are rewritten
+// and the file gets the extension of the new type. Constructs the
+// converter leaves as written are reported as path:line warnings, so that
+// they can be fixed by hand.
+func cmdConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := fs.String("to", "", "convert to file `type` org, md-yaml, md-toml, or txt")
+	dryRun := fs.Bool("n", false, "print the converted note without changing it")
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if id == "" && fs.NArg() == 1 {
+		id = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		return fmt.Errorf(convertUsage)
+	}
+	toType := metadata.FileType(*to)
+	if id == "" || metadata.GetExtension(toType) == "" {
+		return fmt.Errorf(convertUsage)
+	}
+	id = resolveAlias(strings.TrimPrefix(id, "denote:"))
+	path := resolveNotePath(id)
+	if path == "" {
+		return fmt.Errorf("note not found: %s", id)
+	}
+	if config.CryptExt != "" && strings.HasSuffix(path, config.CryptExt) {
+		return fmt.Errorf("cannot convert encrypted note %s", id)
+	}
+	ext := filepath.Ext(path)
+	if e := strings.ToLower(ext); e != ".md" && e != ".org" && e != ".txt" {
+		return fmt.Errorf("%s is not a text note", id)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, fromType, _ := frontmatter.Unmarshal(content, ext)
+	if fromType == "" {
+		fromType = metadata.FileTypeMdYaml // markdown without front matter
+	}
+	if fromType == toType {
+		return fmt.Errorf("%s is already %s", id, toType)
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	body := util.StripFrontMatter(text, fromType)
+	head := text[:len(text)-len(body)]
+	if head != "" {
+		if head, err = frontmatter.Convert(head, fromType, toType); err != nil {
+			return fmt.Errorf("cannot convert the front matter of %s: %w", id, err)
+		}
+	}
+	body, warnings := render.Convert(body, fromType, toType)
+	newPath := strings.TrimSuffix(path, ext) + metadata.GetExtension(toType)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s\n", newPath, strings.Count(head, "\n")+w.Line, w.Text)
+	}
+	if *dryRun {
+		fmt.Print(head + body)
+		return nil
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		if newPath != path {
+			if _, err := os.Stat(newPath); err == nil {
+				return fmt.Errorf("%s already exists", newPath)
+			}
+		}
+		warnLocked(dir, id, path)
+		if err := checkUnsaved(path); err != nil {
+			return err
+		}
+		snapshotNote(id, path, "convert")
+		if newPath != path {
+			if err := util.Rename(path, newPath); err != nil {
+				return err
+			}
+		}
+		if err := util.WriteFile(newPath, []byte(head+body)); err != nil {
+			if newPath != path {
+				return fmt.Errorf("renamed to %s, but failed to convert it: %w", newPath, err)
+			}
+			return err
+		}
+		reloadNoteWindow(path, newPath)
+		if newPath == path {
+			autoCommit(f, vcs.UpdateMessage(id, path), path)
+			runHook(hookUpdate, id, "", path)
+		} else {
+			fmt.Printf("%s -> %s\n", path, filepath.Base(newPath))
+			autoCommit(f, vcs.RenameMessage(id, path, newPath), path, newPath)
+			runHook(hookRename, id, path, newPath)
+		}
+		return reloadIndex(f, dir)
+	})
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bytes"
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// encTag is the pseudo-tag marking encrypted notes in the index. It is
+// not written back to notes by Put.
+const encTag = "enc"
+
+// encCache holds the encrypted notes found by the last scan, which is
+// reused while the index sequence number stays the same or, for servers
+// without one, while no directory of the scan has changed.
+var encCache struct {
+	sync.Mutex
+	dir, seq string
+	dirs     map[string]time.Time // modification times, without seq
+	enc      map[string]bool
+}
+
+// encryptedNotes returns the identifiers of the notes in dir whose file
+// ends in config.CryptExt. The index does not carry file names, so the
+// directory is scanned for them, unless the index, at seq, has not
+// changed since the last scan. Without a sequence number, the last scan
+// is reused until a file is added, removed, or renamed in one of the
+// directories, which changes its modification time. A failed scan is
+// logged and finds nothing.
+func encryptedNotes(dir, seq string) map[string]bool {
+	if config.CryptExt == "" {
+		return nil
+	}
+	encCache.Lock()
+	defer encCache.Unlock()
+	if encCache.enc != nil && encCache.dir == dir {
+		if seq != "" && encCache.seq == seq || seq == "" && unchangedDirs(encCache.dirs) {
+			return encCache.enc
+		}
+	}
+	var dirs map[string]time.Time
+	if seq == "" {
+		// Before the scan, so that changes during it are seen next time.
+		dirs = dirModTimes(dir)
+	}
+	opts := metadata.DefaultScanOptions
+	opts.Include = []string{"*" + config.CryptExt}
+	notes, err := opts.ScanDir(dir)
+	if err != nil {
+		logging.Warnf("failed to find encrypted notes in %s: %v", dir, err)
+		return nil
+	}
+	enc := make(map[string]bool, len(notes))
+	for _, n := range notes {
+		enc[n.Identifier] = true
+	}
+	encCache.dir, encCache.seq, encCache.dirs, encCache.enc = dir, seq, dirs, enc
+	return enc
+}
+
+// dirModTimes returns the modification times of dir and the directories
+// below it, or nil if one cannot be read.
+func dirModTimes(dir string) map[string]time.Time {
+	dirs := make(map[string]time.Time)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dirs[path] = info.ModTime()
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	return dirs
+}
+
+// unchangedDirs reports whether every directory in dirs, as returned by
+// dirModTimes, still has its modification time.
+func unchangedDirs(dirs map[string]time.Time) bool {
+	if dirs == nil {
+		return false
+	}
+	for path, t := range dirs {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// markEncrypted returns rs with encTag added to the tags of the notes in
+// enc. Marked notes are copies, since rs may share notes with the cached
+// index.
+func markEncrypted(rs metadata.Results, enc map[string]bool) metadata.Results {
+	for i, n := range rs {
+		if enc[n.Identifier] && !slices.Contains(n.Tags, encTag) {
+			marked := *n
+			marked.Tags = append(slices.Clip(n.Tags), encTag)
+			rs[i] = &marked
+		}
+	}
+	return rs
+}
+
+// unmarkEncrypted removes encTag from tags written back to a note whose
+// keywords, old, do not have it.
+func unmarkEncrypted(tags, old []string) []string {
+	if slices.Contains(old, encTag) {
+		return tags
+	}
+	return slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == encTag })
+}
+
+// cmdEncrypt encrypts a plaintext note in place, adding config.CryptExt
+// to its file name.
+func cmdEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	recipient := fs.String("r", "", "encrypt to `recipient` instead of the one crypt-recipients selects")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: Denote encrypt [-r recipient] <identifier>")
+	}
+	return convertNote(fs.Arg(0), true, *recipient)
+}
+
+// cmdDecrypt decrypts an encrypted note in place, removing
+// config.CryptExt from its file name.
+func cmdDecrypt(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote decrypt <identifier>")
+	}
+	return convertNote(args[0], false, "")
+}
+
+// convertNote encrypts or decrypts the note with identifier id. Only the
+// extension of its file name changes, so the identifier and links to the
+// note stay as they are. A clean acme window on the note is closed when it
+// is encrypted, so that no plaintext is left on screen, and shows the
+// plaintext file when it is decrypted.
+func convertNote(id string, encrypt bool, recipient string) error {
+	id = strings.TrimPrefix(id, "denote:")
+	if !metadata.IsIdentifier(id) {
+		return fmt.Errorf("invalid identifier: %s", id)
+	}
+	if config.CryptExt == "" {
+		return fmt.Errorf("crypt-ext is not set")
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		path := notePath(f, dir, id)
+		if path == "" {
+			return fmt.Errorf("note not found: %s", id)
+		}
+		isEncrypted := strings.HasSuffix(path, config.CryptExt)
+		var newPath string
+		switch {
+		case encrypt && isEncrypted:
+			return fmt.Errorf("%s is already encrypted", path)
+		case !encrypt && !isEncrypted:
+			return fmt.Errorf("%s is not encrypted", path)
+		case encrypt:
+			newPath = path + config.CryptExt
+		default:
+			newPath = strings.TrimSuffix(path, config.CryptExt)
+		}
+		warnLocked(dir, id, path)
+		if err := checkUnsaved(path); err != nil {
+			return err
+		}
+		if encrypt && recipient == "" {
+			if recipient, err = recipientFor(dir, path); err != nil {
+				return err
+			}
+		}
+
+		if err := convertFile(path, newPath, encrypt, recipient); err != nil {
+			return err
+		}
+
+		if encrypt {
+			closeNoteWindow(path)
+		} else {
+			reloadNoteWindow(path, newPath)
+		}
+		if err := reloadIndex(f, dir); err != nil {
+			logging.Warnf("failed to reload index: %v", err)
+		}
+		autoCommit(f, vcs.RenameMessage(id, path, newPath), path, newPath)
+		runHook(hookRename, id, path, newPath)
+		fmt.Println(newPath)
+		return nil
+	})
+}
+
+// convertFile encrypts or decrypts the file at path into a new file at
+// newPath and removes path.
+func convertFile(path, newPath string, encrypt bool, recipient string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if content, err = crypt(content, encrypt, recipient); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(content); err != nil {
+		out.Close()
+		os.Remove(newPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(newPath)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// recipientFor returns the recipient config.CryptRecipients selects for
+// the note at path in the denote directory dir, or "" for the default.
+func recipientFor(dir, path string) (string, error) {
+	rules, err := metadata.ParseCryptRecipients(config.CryptRecipients)
+	if err != nil {
+		return "", err
+	}
+	return metadata.RecipientFor(rules, dir, path), nil
+}
+
+// cryptRules returns the rules of config.EncryptPolicy.
+func cryptRules() ([]metadata.CryptRule, error) {
+	return metadata.ParseCryptRules(config.EncryptPolicy)
+}
+
+// encryptNew returns the function importer.WriteEncrypted uses to
+// encrypt the notes Denote writes in dir that config.EncryptPolicy
+// requires to be encrypted, or every note if force is set, so that
+// their plaintext never reaches the disk.
+func encryptNew(dir string, force bool) importer.EncryptFunc {
+	return func(path string, content []byte) (string, []byte, error) {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || config.CryptExt == "" || strings.HasSuffix(path, config.CryptExt) {
+			return path, content, nil
+		}
+		if !force {
+			rules, err := cryptRules()
+			if err != nil {
+				return "", nil, err
+			}
+			if _, ok := metadata.RequiredBy(rules, rel, metadata.ParseFilename(path).Tags); !ok {
+				return path, content, nil
+			}
+		}
+		recipient, err := recipientFor(dir, path)
+		if err != nil {
+			return "", nil, err
+		}
+		sealed, err := crypt(content, true, recipient)
+		clear(content)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s must be encrypted, but encrypting it failed: %w", path, err)
+		}
+		return path + config.CryptExt, sealed, nil
+	}
+}
+
+// warnUnencrypted warns if config.EncryptPolicy requires the note New
+// creates from input, 'dir/:title' tags, to be encrypted. The server
+// opens such notes as plaintext windows, to be saved with CryptPut.
+func warnUnencrypted(input string, warn func(format string, args ...any)) {
+	rules, err := cryptRules()
+	if err != nil {
+		warn("%v", err)
+		return
+	}
+	i := strings.LastIndex(input, "'")
+	if i <= 0 {
+		return
+	}
+	rel := "note"
+	if sub, _, ok := strings.Cut(input[:i], "/:"); ok {
+		rel = filepath.Join(strings.TrimPrefix(sub, "'"), rel)
+	}
+	tags := strings.Split(strings.TrimSpace(input[i+1:]), ",")
+	if r, ok := metadata.RequiredBy(rules, rel, tags); ok {
+		warn("the new note must be encrypted (%s): add %s to its name and save it with CryptPut", r, config.CryptExt)
+	}
+}
+
+// cmdAuditEncryption lists the notes that config.EncryptPolicy requires to
+// be encrypted but are not, with the rule requiring it.
+func cmdAuditEncryption(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote audit-encryption")
+	}
+	rules, err := cryptRules()
+	if err != nil {
+		return err
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return err
+	}
+	violations := 0
+	for _, n := range notes {
+		if config.CryptExt != "" && strings.HasSuffix(n.Path, config.CryptExt) {
+			continue
+		}
+		rel, err := filepath.Rel(dir, n.Path)
+		if err != nil {
+			continue
+		}
+		if r, ok := metadata.RequiredBy(rules, rel, n.Tags); ok {
+			fmt.Printf("%s | %s | %s\n", n.Identifier, r, n.Path)
+			violations++
+		}
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d notes are not encrypted as the policy requires", violations)
+	}
+	return nil
+}
+
+// crypt encrypts or decrypts content with the tool config.CryptExt names:
+// age for .age, otherwise gpg. age decrypts with the identity file in
+// $AGE_IDENTITY.
+func crypt(content []byte, encrypt bool, recipient string) ([]byte, error) {
+	if config.CryptExt != ".age" {
+		switch {
+		case !encrypt:
+			return gpg(content, "--decrypt")
+		case recipient != "":
+			return gpg(content, "--encrypt", "--recipient", recipient)
+		default:
+			return gpg(content, "--encrypt", "--default-recipient-self")
+		}
+	}
+	var args []string
+	switch {
+	case !encrypt:
+		identity := os.Getenv("AGE_IDENTITY")
+		if identity == "" {
+			return nil, fmt.Errorf("set $AGE_IDENTITY to the age identity file")
+		}
+		args = []string{"--decrypt", "--identity", identity}
+	case recipient != "":
+		args = []string{"--encrypt", "--recipient", recipient}
+	default:
+		return nil, fmt.Errorf("age needs a recipient: Denote encrypt -r <recipient>")
+	}
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("age: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// closeNoteWindow deletes the clean acme window showing path, if any.
+func closeNoteWindow(path string) {
+	info, open := noteWindow(path)
+	if !open {
+		return
+	}
+	w, err := acme.Open(info.ID, nil)
+	if err != nil {
+		logging.Warnf("failed to open window %d: %v", info.ID, err)
+		return
+	}
+	defer w.CloseFiles()
+	if err := w.Ctl("del"); err != nil {
+		logging.Warnf("failed to close window %d: %v", info.ID, err)
+	}
+}
+
+// openNote opens the note with identifier id in acme: encrypted notes
+// with openEncrypted, others through the plumber. For an encrypted note
+// it returns when its window is deleted. Anything but an identifier, such
+// as a denote: link naming a path, is refused.
+func openNote(id string) error {
+	if !metadata.IsIdentifier(id) {
+		return fmt.Errorf("invalid identifier %q", id)
+	}
+	lockNote(id)
+	recordOpen(id)
+	if path := resolveNotePath(id); config.CryptExt != "" && strings.HasSuffix(path, config.CryptExt) {
+		return openEncrypted(path)
+	}
+	return exec.Command("plumb", "denote:"+id).Run()
+}
+
+// openEncrypted shows the encrypted note at path decrypted in an acme
+// window named path. The plaintext goes from the decryption tool's
+// output to the window and, on Put, from the window to the encryption
+// tool, and is never written to disk: Put in the window is handled here
+// and writes only the encrypted file. Acme's Putall skips the window, as
+// it does every window whose events a program reads. Buffers holding
+// plaintext are cleared once used. A rename of the note by another
+// command renames the window, and Put then writes the new file; once the
+// note is decrypted on disk, the window is left to acme. openEncrypted
+// returns when the window is deleted.
+func openEncrypted(path string) error {
+	if w, open := noteWindow(path); open {
+		if w, err := acme.Open(w.ID, nil); err == nil {
+			w.Ctl("show")
+			w.CloseFiles()
+		}
+		return nil
+	}
+	w, err := acme.New()
+	if err != nil {
+		return err
+	}
+	defer w.CloseFiles()
+	w.Name(path)
+	if err := loadDecrypted(w, path); err != nil {
+		w.Ctl("delete")
+		return err
+	}
+	for e := range w.EventChan() {
+		if e.C2 == 'x' || e.C2 == 'X' {
+			switch cmd := string(e.Text); cmd {
+			case "Put", "Get":
+				// Renames and decryption by other commands rename the
+				// window, so its name is the note's path.
+				path = windowName(w.ID(), path)
+				if config.CryptExt == "" || !strings.HasSuffix(path, config.CryptExt) {
+					// Decrypted on disk since: hand the window back to acme.
+					w.WriteEvent(e)
+					return nil
+				}
+				if cmd == "Put" {
+					if err := putEncrypted(w, path); err != nil {
+						notifyError("failed to save %s: %v", path, err)
+					} else {
+						commitPut(metadata.ParseFilename(path).Identifier, path)
+					}
+				} else if err := loadDecrypted(w, path); err != nil {
+					notifyError("failed to load %s: %v", path, err)
+				}
+				continue
+			}
+		}
+		w.WriteEvent(e)
+	}
+	return nil
+}
+
+// windowName returns the name of the acme window with the given id, or
+// name if it cannot be found.
+func windowName(id int, name string) string {
+	wins, err := acme.Windows()
+	if err != nil {
+		return name
+	}
+	for _, w := range wins {
+		if w.ID == id {
+			return w.Name
+		}
+	}
+	return name
+}
+
+// loadDecrypted replaces the body of w with the decrypted note at path.
+func loadDecrypted(w *acme.Win, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plain, err := crypt(content, false, "")
+	if err != nil {
+		return err
+	}
+	defer clear(plain)
+	w.Addr(",")
+	if _, err := w.Write("data", plain); err != nil {
+		return err
+	}
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	return w.Ctl("show")
+}
+
+// putEncrypted encrypts the body of w and writes it to path.
+func putEncrypted(w *acme.Win, path string) error {
+	plain, err := w.ReadAll("body")
+	if err != nil {
+		return err
+	}
+	defer clear(plain)
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	recipient, err := recipientFor(dir, path)
+	if err != nil {
+		return err
+	}
+	content, err := crypt(plain, true, recipient)
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFile(path, content); err != nil {
+		return err
+	}
+	return w.Ctl("clean")
+}
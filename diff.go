@@ -0,0 +1,185 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/diff"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const diffWname = "+Diff"
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 2
+
+// noteDiff returns a word-level diff of the note with identifier id from
+// the git history of the denote directory. With a revision, the note as
+// of rev is compared with its file. Without one, the file is compared
+// with its last committed version or, if it has no uncommitted changes,
+// as with autocommit, the last committed version with the one before.
+func noteDiff(id, rev string) (string, error) {
+	var path string
+	var repo *vcs.Repo
+	err := p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		if repo, err = openRepo(f); err != nil {
+			return err
+		}
+		if path, err = p9client.ReadFile(f, "n/"+id+"/path"); err != nil {
+			return fmt.Errorf("note not found: %s", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if config.CryptExt != "" && strings.HasSuffix(path, config.CryptExt) {
+		return "", fmt.Errorf("cannot diff encrypted note %s", id)
+	}
+	revs, err := repo.Revisions(path)
+	if err != nil {
+		return "", err
+	}
+	if len(revs) == 0 {
+		return "", fmt.Errorf("%s has no committed versions", id)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var oldRev vcs.Revision
+	newText, newLabel := string(content), "working copy"
+	switch {
+	case rev != "":
+		hash, err := repo.Resolve(rev)
+		if err != nil {
+			return "", err
+		}
+		// The note may have had another name at rev.
+		oldRev = vcs.Revision{Hash: hash, Path: revs[0].Path}
+		for _, r := range revs {
+			if r.Hash == hash {
+				oldRev = r
+				break
+			}
+		}
+	default:
+		last, err := repo.Show(revs[0].Hash, revs[0].Path)
+		if err != nil {
+			return "", err
+		}
+		oldRev = revs[0]
+		if last == newText {
+			if len(revs) < 2 {
+				return "", fmt.Errorf("%s has no earlier version", id)
+			}
+			oldRev = revs[1]
+			newText, newLabel = last, shortHash(revs[0].Hash)
+		}
+	}
+	oldText, err := repo.Show(oldRev.Hash, oldRev.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s..%s\n", id, shortHash(oldRev.Hash), newLabel)
+	hunks := diff.Words(oldText, newText, diffContext)
+	if len(hunks) == 0 {
+		b.WriteString("no changes\n")
+	}
+	for _, h := range hunks {
+		// Lines of the working copy are addressed so that right-clicking
+		// the address opens the note there.
+		if newLabel == "working copy" {
+			fmt.Fprintf(&b, "\n%s:%d\n", path, h.Line)
+		} else {
+			fmt.Fprintf(&b, "\nline %d\n", h.Line)
+		}
+		b.WriteString(h.Text)
+	}
+	return b.String(), nil
+}
+
+func shortHash(hash string) string {
+	return hash[:min(10, len(hash))]
+}
+
+// cmdDiff prints the word-level diff of a note against its previous
+// version, or against rev.
+func cmdDiff(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: Denote diff <identifier> [rev]")
+	}
+	var rev string
+	if len(args) == 2 {
+		rev = args[1]
+	}
+	out, err := noteDiff(resolveAlias(strings.TrimPrefix(args[0], "denote:")), rev)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// diffShown is the note and revision the +Diff window shows.
+var diffShown struct {
+	sync.Mutex
+	id, rev string
+}
+
+// openDiffWindow shows the diff of the note with identifier id in the
+// +Diff window. Get shows it again, e.g. after another sync.
+func openDiffWindow(id, rev string) {
+	diffShown.Lock()
+	diffShown.id, diffShown.rev = id, rev
+	diffShown.Unlock()
+	w := acme.Show(wname + diffWname)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open diff window: %v", err)
+			return
+		}
+		w.Name(wname + diffWname)
+		w.Write("tag", []byte("Get"))
+		go func() {
+			defer w.CloseFiles()
+			for e := range w.EventChan() {
+				if (e.C2 == 'x' || e.C2 == 'X') && string(e.Text) == "Get" {
+					refreshDiff(w)
+					continue
+				}
+				w.WriteEvent(e)
+			}
+		}()
+	}
+	refreshDiff(w)
+}
+
+func refreshDiff(w *acme.Win) {
+	diffShown.Lock()
+	id, rev := diffShown.id, diffShown.rev
+	diffShown.Unlock()
+	out, err := noteDiff(id, rev)
+	if err != nil {
+		notifyError("failed to diff %s: %v", id, err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", []byte(out))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"9fans.net/go/plan9/client"
+)
+
+// The 9P server is the single source of truth for the denote directory:
+// every command reads it from the server's dir file rather than resolving
+// it locally, so Denote, the extensions, and Dsilo always agree.
+
+// readDenoteDir returns the server's current denote directory.
+func readDenoteDir(f *client.Fsys) (string, error) {
+	dir, err := p9client.ReadFile(f, "dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to read denote directory: %w", err)
+	}
+	return dir, nil
+}
+
+// denoteDir returns the directory currently served by the denote server.
+func denoteDir() (string, error) {
+	var dir string
+	err := p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		dir, err = readDenoteDir(f)
+		return err
+	})
+	return dir, err
+}
+
+// serverRunning reports whether a denote server can be reached. A server
+// that accepts connections but cannot attach or serve its dir file is
+// reported as an error rather than as not running, so that a second
+// server is not started next to it and does not remove its socket. Only
+// a missing or stale socket counts as not running.
+func serverRunning() (bool, error) {
+	reached := false
+	err := p9client.With9P(func(f *client.Fsys) error {
+		reached = true
+		_, err := readDenoteDir(f)
+		return err
+	})
+	if err == nil {
+		return true, nil
+	}
+	if reached || p9client.Probe() == p9client.SocketLive {
+		return true, fmt.Errorf("denote server is running but unhealthy: %w", err)
+	}
+	return false, nil
+}
+
+// connect makes sure denotesrv is running, starting it in the directory
+// resolved by config.DenoteDir if needed. If dirFlag is set and the server
+// is already running elsewhere, it is switched to dirFlag as with Dsilo.
+func connect(dirFlag string) error {
+	dir, err := config.DenoteDir(dirFlag)
+	if err != nil {
+		return err
+	}
+
+	running, err := serverRunning()
+	if err != nil {
+		return err
+	}
+	if !running {
+		cmd := exec.Command("denotesrv", "start")
+		cmd.Env = append(os.Environ(), "DENOTE_DIR="+dir)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to start denotesrv: %w", err)
+		}
+		for i := 0; ; i++ {
+			if running, _ := serverRunning(); running {
+				break
+			}
+			if i == 9 {
+				return fmt.Errorf("denotesrv failed to start")
+			}
+		}
+	}
+
+	if dirFlag == "" {
+		return nil
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		current, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		if current == dir {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"denote/pkg/metadata"
+	"fmt"
+	"strings"
+
+	"9fans.net/go/acme"
+)
+
+// findDuplicates lists notes in the denote directory that share an
+// identifier, e.g. copies in two subdirectories. The index keeps only
+// one of them, so the others cannot be opened by identifier.
+func findDuplicates() (string, []metadata.Results, error) {
+	dir, err := denoteDir()
+	if err != nil {
+		return "", nil, err
+	}
+	rs, err := metadata.ScanDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, metadata.Duplicates(rs), nil
+}
+
+func formatDuplicates(dups []metadata.Results) string {
+	var b strings.Builder
+	for _, group := range dups {
+		fmt.Fprintf(&b, "%s:\n", group[0].Identifier)
+		for _, md := range group {
+			fmt.Fprintf(&b, "\t%s\n", md.Path)
+		}
+	}
+	return b.String()
+}
+
+// cmdDuplicates prints every identifier used by more than one file.
+func cmdDuplicates(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote duplicates")
+	}
+	_, dups, err := findDuplicates()
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatDuplicates(dups))
+	return nil
+}
+
+// warnDuplicates reports duplicate identifiers in the denote directory's
+// +Errors window.
+func warnDuplicates() {
+	dir, dups, err := findDuplicates()
+	if err != nil || len(dups) == 0 {
+		return
+	}
+	acme.Err(dir+"/", fmt.Sprintf("warning: %d duplicate identifiers; only one note each is indexed\n%s",
+		len(dups), strings.TrimSuffix(formatDuplicates(dups), "\n")))
+}
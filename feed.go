@@ -0,0 +1,172 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/feed"
+	"denote/pkg/metadata"
+	"denote/pkg/render"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// buildFeed renders the newest limit notes matching query as an Atom
+// feed. When f.Link is set, notes are linked as <link><identifier>.html.
+func buildFeed(query string, limit int, f feed.Feed) ([]byte, error) {
+	var entries []feed.Entry
+	err := p9client.With9P(func(fs *client.Fsys) error {
+		indexMu.Lock()
+		rs, err := readFiltered(fs, query)
+		indexMu.Unlock()
+		if err != nil {
+			return err
+		}
+		metadata.Sort(rs, metadata.SortById, metadata.SortOrderDesc)
+
+		for _, r := range rs {
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+			path, err := p9client.ReadFile(fs, "n/"+r.Identifier+"/path")
+			if err != nil {
+				return fmt.Errorf("failed to read path for %s: %w", r.Identifier, err)
+			}
+			e, ok, err := feedEntry(r, path, f.Link)
+			if err != nil {
+				return err
+			}
+			if ok {
+				entries = append(entries, e)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feed.Atom(f, entries)
+}
+
+// readFiltered reads the index entries matching query and clears the
+// filter again. The caller holds indexMu.
+func readFiltered(fs *client.Fsys, query string) (metadata.Results, error) {
+	if err := setFilter(fs, query); err != nil {
+		return nil, err
+	}
+	defer setFilter(fs, "")
+	return readIndex(fs)
+}
+
+// feedEntry reads and renders a single note. Notes that are not plain
+// text (encrypted notes, PDFs, ...) are skipped.
+func feedEntry(r *metadata.Metadata, path, link string) (feed.Entry, bool, error) {
+	ext := filepath.Ext(path)
+	if ext != ".md" && ext != ".org" && ext != ".txt" {
+		return feed.Entry{}, false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return feed.Entry{}, false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return feed.Entry{}, false, err
+	}
+	_, fileType, _ := frontmatter.Unmarshal(content, ext)
+
+	var resolve render.Resolver
+	e := feed.Entry{
+		Identifier: r.Identifier,
+		Title:      r.Title,
+		Tags:       r.Tags,
+		Updated:    info.ModTime(),
+	}
+	if link != "" {
+		base := strings.TrimSuffix(link, "/") + "/"
+		resolve = func(id string) string { return base + id + ".html" }
+		e.Link = resolve(r.Identifier)
+	}
+	if e.Published, err = time.ParseInLocation("20060102T150405", r.Identifier, time.Local); err != nil {
+		e.Published = e.Updated
+	}
+	e.HTML = render.HTML(string(content), fileType, resolve)
+	return e, true, nil
+}
+
+// cmdFeed writes an Atom feed of the notes matching a filter.
+func cmdFeed(args []string) error {
+	fs := flag.NewFlagSet("feed", flag.ContinueOnError)
+	tag := fs.String("tag", "", "only include notes with this tag")
+	title := fs.String("title", "Notes", "feed title")
+	link := fs.String("link", "", "base URL of the published notes")
+	author := fs.String("author", os.Getenv("USER"), "feed author")
+	limit := fs.Int("n", 20, "maximum number of entries (0 for all)")
+	out := fs.String("out", "", "output file (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filters := fs.Args()
+	if *tag != "" {
+		filters = append(filters, "tag:"+*tag)
+	}
+	data, err := buildFeed(strings.Join(filters, " "), *limit, feed.Feed{Title: *title, Link: *link, Author: *author})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0644)
+}
+
+// serveFeed serves GET /feed?q=<filter>&n=<limit>&title=<title>. Feeds
+// may include any note, so the endpoint exists only while
+// config.CaptureToken is set, and requests must carry the token in the
+// X-Denote-Token header or, for feed readers, the token parameter.
+func serveFeed(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	token := config.CaptureToken
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if q.Get("token") != token && r.Header.Get("X-Denote-Token") != token {
+		http.Error(w, "invalid token", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 20
+	if n := q.Get("n"); n != "" {
+		var err error
+		if limit, err = strconv.Atoi(n); err != nil {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+	}
+	title := q.Get("title")
+	if title == "" {
+		title = "Notes"
+	}
+	data, err := buildFeed(q.Get("q"), limit, feed.Feed{Title: title, Link: q.Get("link"), Author: os.Getenv("USER")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(data)
+}
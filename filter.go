@@ -0,0 +1,142 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"9fans.net/go/acme"
+)
+
+const filterWname = "+filter"
+
+// filterFields are the query prefixes offered as hints in the +filter window.
+var filterFields = []string{"title:", "tag:", "!tag:", "date:", "body:", "due:", "overdue:", "sort:id", "sort:title", "sort:tags", "sort:relevance", "sort:frequency", "sort:lastopened"}
+
+// filterTarget is the index window the +filter window applies its query to.
+var filterTarget atomic.Pointer[indexWindow]
+
+// openFilterWindow opens a +filter prompt for iw. The first line holds
+// the query, starting from iw's current one; Put runs it in iw. Below it
+// are the field names and known tags: middle-click one to add it to the
+// query.
+func openFilterWindow(iw *indexWindow) {
+	filterTarget.Store(iw)
+	w := acme.Show(wname + filterWname)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open filter window: %v", err)
+			return
+		}
+		w.Name(wname + filterWname)
+		w.Write("tag", []byte("Put"))
+		go func() {
+			defer w.CloseFiles()
+			for e := range w.EventChan() {
+				switch e.C2 {
+				case 'x', 'X':
+					text := string(e.Text)
+					if text == "Put" {
+						applyFilter(w)
+					} else if isFilterHint(text) {
+						appendFilter(w, text)
+					} else {
+						w.WriteEvent(e)
+					}
+				default:
+					w.WriteEvent(e)
+				}
+			}
+		}()
+	}
+	iw.mu.Lock()
+	query := iw.query
+	iw.mu.Unlock()
+	refreshFilter(w, query)
+}
+
+// refreshFilter writes query and the completion hints to the +filter window.
+func refreshFilter(w *acme.Win, query string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", query)
+	fmt.Fprintf(&b, "# fields: %s\n", strings.Join(filterFields, " "))
+	if tags, err := knownTags(); err != nil {
+		logging.Warnf("failed to read tags: %v", err)
+	} else if len(tags) > 0 {
+		fmt.Fprintf(&b, "# tags: tag:%s\n", strings.Join(tags, " tag:"))
+	}
+	w.Addr(",")
+	w.Write("data", []byte(b.String()))
+	w.Ctl("clean")
+	w.Addr("#%d", len([]rune(query)))
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// knownTags returns every tag in the index and the configured known
+// tags, sorted.
+func knownTags() ([]string, error) {
+	rs, err := search("")
+	if err != nil {
+		return nil, err
+	}
+	return completeTags(rs, ""), nil
+}
+
+// filterQuery returns the query in the +filter window body: every line
+// that is not a hint, joined by spaces.
+func filterQuery(body []byte) string {
+	var parts []string
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func isFilterHint(text string) bool {
+	return slices.Contains(filterFields, text) || strings.HasPrefix(text, "tag:")
+}
+
+// appendFilter adds hint to the end of the query line and leaves dot
+// after it, ready for a field's value to be typed.
+func appendFilter(w *acme.Win, hint string) {
+	body, err := w.ReadAll("body")
+	if err != nil {
+		logging.Errorf("failed to read filter window: %v", err)
+		return
+	}
+	query := strings.TrimSpace(strings.SplitN(string(body), "\n", 2)[0])
+	if query != "" {
+		query += " "
+	}
+	w.Addr("1")
+	w.Write("data", []byte(query+hint+"\n"))
+	end := len([]rune(query + hint))
+	w.Addr("#%d", end)
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// applyFilter runs the +filter window's query in the target index window.
+func applyFilter(w *acme.Win) {
+	iw := filterTarget.Load()
+	if iw == nil {
+		return
+	}
+	body, err := w.ReadAll("body")
+	if err != nil {
+		logging.Errorf("failed to read filter window: %v", err)
+		return
+	}
+	query := filterQuery(body)
+	iw.search(query)
+	history.add(query)
+	iw.save()
+	w.Ctl("clean")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha1"
+	"denote/internal/logging"
+	"denote/pkg/fulltext"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"encoding/hex"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fulltextCache returns where the full-text index of dir is kept between
+// runs, one file per denote directory.
+func fulltextCache(dir string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(dir))
+	return filepath.Join(cache, "acme-denote", "fulltext-"+hex.EncodeToString(sum[:8])+".gob"), nil
+}
+
+// searchBodies returns the identifiers of the notes in dir whose bodies
+// contain every word of query, scored between 0 and 1 relative to the
+// best hit. The cached index is brought up to date first.
+func searchBodies(dir, query string) (map[string]float64, error) {
+	ix, err := loadFulltext(dir)
+	if err != nil {
+		return nil, err
+	}
+	hits := ix.Search(query)
+	scores := make(map[string]float64, len(hits))
+	for _, h := range hits {
+		if id := metadata.ParseFilename(h.Path).Identifier; id != "" {
+			scores[id] = h.Score / hits[0].Score
+		}
+	}
+	return scores, nil
+}
+
+// fulltextState is what this process knows of the note files since it
+// last brought the full-text index up to date.
+var fulltextState struct {
+	sync.Mutex
+	dirs map[string]map[string]time.Time // by denote directory, as of the update
+	put  map[string]bool                 // notes Put since
+}
+
+// fulltextPut records that the note at path was saved, so that the next
+// search re-reads it.
+func fulltextPut(path string) {
+	fulltextState.Lock()
+	defer fulltextState.Unlock()
+	if fulltextState.put == nil {
+		fulltextState.put = make(map[string]bool)
+	}
+	fulltextState.put[path] = true
+}
+
+// loadFulltext returns the full-text index of dir, brought up to date
+// with the note files. The directory is rescanned only the first time in
+// this process and when one of its directories has changed since, as
+// adding, removing, or renaming a note does. Otherwise only the notes Put
+// since are re-read. The cache is written back only if that changed it.
+// Notes that cannot be read are logged and skipped.
+func loadFulltext(dir string) (*fulltext.Index, error) {
+	cache, err := fulltextCache(dir)
+	if err != nil {
+		return nil, err
+	}
+	fulltextState.Lock()
+	defer fulltextState.Unlock()
+	ix, err := fulltext.Load(cache)
+	rebuilt := err != nil
+	if rebuilt {
+		// A corrupt or outdated cache is rebuilt from scratch.
+		ix = fulltext.New()
+	}
+	var changed bool
+	var skipped []error
+	if dirs := fulltextState.dirs[dir]; !rebuilt && unchangedDirs(dirs) {
+		for path := range fulltextState.put {
+			if !util.Within(dir, path) {
+				continue
+			}
+			c, err := ix.UpdateFile(path)
+			if err != nil {
+				skipped = append(skipped, err)
+			}
+			changed = changed || c
+		}
+	} else {
+		// Before the scan, so that changes during it are seen next time.
+		dirs := dirModTimes(dir)
+		if changed, skipped, err = ix.Update(dir); err != nil {
+			return nil, err
+		}
+		if fulltextState.dirs == nil {
+			fulltextState.dirs = make(map[string]map[string]time.Time)
+		}
+		fulltextState.dirs[dir] = dirs
+	}
+	maps.DeleteFunc(fulltextState.put, func(path string, _ bool) bool { return util.Within(dir, path) })
+	for _, err := range skipped {
+		logging.Warnf("full-text index: skipping %v", err)
+	}
+	if changed || rebuilt {
+		if err := ix.Save(cache); err != nil {
+			return nil, err
+		}
+	}
+	return ix, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/config"
+	"slices"
+	"strings"
+	"sync"
+
+	"9fans.net/go/acme"
+)
+
+const historyWname = "+History"
+
+// queryHistory remembers recent Look queries, most recent first.
+type queryHistory struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+var history queryHistory
+
+// add records query as the most recent one. An earlier copy of the same
+// query is dropped so each query appears once.
+func (h *queryHistory) add(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queries = slices.DeleteFunc(h.queries, func(q string) bool { return q == query })
+	h.queries = slices.Insert(h.queries, 0, query)
+	if len(h.queries) > config.HistorySize {
+		h.queries = h.queries[:config.HistorySize]
+	}
+}
+
+// last returns the most recent query, or "" if there is none.
+func (h *queryHistory) last() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.queries) == 0 {
+		return ""
+	}
+	return h.queries[0]
+}
+
+func (h *queryHistory) list() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.queries)
+}
+
+// openHistoryWindow lists previous queries in a +History window, newest
+// first. Select a line (editing it if needed) and chord it with Look in
+// the /Denote/ window to run it again.
+func openHistoryWindow() {
+	w := acme.Show(wname + historyWname)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open history window: %v", err)
+			return
+		}
+		w.Name(wname + historyWname)
+		w.Write("tag", []byte("Get"))
+		go func() {
+			defer w.CloseFiles()
+			for e := range w.EventChan() {
+				if (e.C2 == 'x' || e.C2 == 'X') && string(e.Text) == "Get" {
+					refreshHistory(w)
+					continue
+				}
+				w.WriteEvent(e)
+			}
+		}()
+	}
+	refreshHistory(w)
+}
+
+func refreshHistory(w *acme.Win) {
+	var b strings.Builder
+	for _, q := range history.list() {
+		b.WriteString(q)
+		b.WriteString("\n")
+	}
+	w.Addr(",")
+	w.Write("data", []byte(b.String()))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"denote/internal/vcs"
+	"denote/pkg/metadata"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+// Note lifecycle events. Each names the hook script run after it.
+const (
+	hookNew    = "on-new"
+	hookRename = "on-rename"
+	hookDelete = "on-delete"
+	hookUpdate = "on-update"
+)
+
+func hookDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acme-denote", "hooks"), nil
+}
+
+// runHook runs the user's executable hook script for event, if there is
+// one. The script gets the note in DENOTE_ID and DENOTE_PATH (the new
+// path after a rename, the removed path after a delete) and, for
+// renames, DENOTE_OLD_PATH and DENOTE_NEW_PATH. A failing hook is
+// logged; the operation it follows has already succeeded.
+func runHook(event, identifier, oldPath, newPath string) {
+	dir, err := hookDir()
+	if err != nil {
+		return
+	}
+	script := filepath.Join(dir, event)
+	if fi, err := os.Stat(script); err != nil || fi.IsDir() || fi.Mode()&0111 == 0 {
+		return
+	}
+	path := newPath
+	if path == "" {
+		path = oldPath
+	}
+	cmd := exec.Command(script)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Env = append(os.Environ(),
+		"DENOTE_EVENT="+event,
+		"DENOTE_ID="+identifier,
+		"DENOTE_PATH="+path,
+		"DENOTE_OLD_PATH="+oldPath,
+		"DENOTE_NEW_PATH="+newPath,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		notifyError("%s hook failed: %v: %s", event, err, strings.TrimSpace(string(out)))
+	}
+}
+
+// runNewHook commits a note Denote wrote to path, if config.GitAutoCommit
+// is set, and runs the on-new hook for it.
+func runNewHook(f *client.Fsys, path string) {
+	id := metadata.ParseFilename(path).Identifier
+	autoCommit(f, vcs.CreateMessage(id, path), path)
+	runHook(hookNew, id, "", path)
+}
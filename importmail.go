@@ -0,0 +1,128 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// cmdImportMail converts messages from a maildir or mbox into notes. Their
+// tags pass the tag vocabulary and they are placed in the subdirectory
+// config.Routes gives for them, as with New.
+func cmdImportMail(args []string) error {
+	fs := flag.NewFlagSet("import-mail", flag.ContinueOnError)
+	tags := fs.String("tags", strings.Join(config.MailImportTags, ","), "comma-separated tags for imported notes")
+	ftype := fs.String("type", config.DefaultFileType, "file type: org, md-yaml, md-toml, or txt")
+	from := fs.String("from", "", "only import messages whose sender matches this regexp")
+	subject := fs.String("subject", "", "only import messages whose subject matches this regexp")
+	since := fs.String("since", "", "only import messages dated on or after YYYYMMDD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: Denote import-mail [-tags a,b] [-type md-yaml] [-from re] [-subject re] [-since YYYYMMDD] <maildir|mbox>")
+	}
+
+	fileType := metadata.FileType(*ftype)
+	if metadata.GetExtension(fileType) == "" {
+		return fmt.Errorf("unknown file type: %s", *ftype)
+	}
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+	if invalid := metadata.ValidateTags(tagList); len(invalid) > 0 {
+		return fmt.Errorf("invalid tags: %s", strings.Join(invalid, ", "))
+	}
+	tagList, err := checkTags(tagList, nil, warnStderr)
+	if err != nil {
+		return err
+	}
+	routes, err := metadata.ParseRoutes(config.Routes)
+	if err != nil {
+		return err
+	}
+	fromRe, err := regexp.Compile("(?i)" + *from)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	subjectRe, err := regexp.Compile("(?i)" + *subject)
+	if err != nil {
+		return fmt.Errorf("invalid -subject: %w", err)
+	}
+	var sinceTime time.Time
+	if *since != "" {
+		if sinceTime, err = time.ParseInLocation("20060102", *since, time.Local); err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+
+	msgs, unreadable, err := importer.ReadMail(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, err := range unreadable {
+		fmt.Fprintf(os.Stderr, "skipping %v\n", err)
+	}
+
+	ids, err := existingIdentifiers()
+	if err != nil {
+		return err
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		target := dir
+		if sub := metadata.RouteDir(routes, tagList); sub != "" {
+			target = filepath.Join(dir, sub)
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		}
+
+		imported, skipped := 0, 0
+		for _, m := range msgs {
+			if !fromRe.MatchString(m.From) || !subjectRe.MatchString(m.Subject) || m.Date.Before(sinceTime) {
+				skipped++
+				continue
+			}
+			path, err := importer.WriteEncrypted(target, m.Note(tagList), fileType, ids, encryptNew(dir, false))
+			if err != nil {
+				return err
+			}
+			fmt.Println(path)
+			runNewHook(f, path)
+			imported++
+		}
+		fmt.Printf("imported %d messages, skipped %d, unreadable %d\n", imported, skipped, len(unreadable))
+		if imported == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
+
+// existingIdentifiers returns the identifiers of every note in the index.
+func existingIdentifiers() (importer.Identifiers, error) {
+	rs, err := readUnfilteredIndex()
+	if err != nil {
+		return nil, err
+	}
+	ids := importer.Identifiers{}
+	for _, r := range rs {
+		ids[r.Identifier] = true
+	}
+	return ids, nil
+}
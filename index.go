@@ -0,0 +1,538 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/encoding/results"
+	"denote/pkg/lock"
+	"denote/pkg/metadata"
+	"denote/pkg/metrics"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// indexWindow is an acme window listing notes from the index. Every
+// index window keeps its own query and handles its own commands; the
+// main /Denote/ window additionally has its view saved across restarts.
+type indexWindow struct {
+	win   *acme.Win
+	name  string
+	query string
+	main  bool
+
+	pending removal // Remove awaiting confirmation
+
+	mu sync.Mutex // serializes writes to win
+}
+
+var (
+	windowsMu sync.Mutex
+	windows   []*indexWindow
+
+	// indexMu serializes setting the filter and reading the index, since
+	// the filter is server state shared by every window.
+	indexMu sync.Mutex
+)
+
+// search runs searchText and shows the results, moving dot to the top.
+func (iw *indexWindow) search(searchText string) {
+	rs, err := search(searchText)
+	if err != nil {
+		logging.Errorf("search error: %v", err)
+		return
+	}
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	iw.query = strings.TrimSpace(searchText)
+	iw.show(rs)
+	iw.win.Addr("#0")
+	iw.win.Ctl("dot=addr")
+	iw.win.Ctl("show")
+}
+
+// show writes rs to the window and describes the view in its tag. The
+// caller holds iw.mu.
+func (iw *indexWindow) show(rs metadata.Results) {
+	refreshWindow(iw.win, rs, config.SectionHeaders && iw.sortBy() == metadata.SortByTitle)
+	iw.win.Ctl("cleartag")
+	iw.win.Write("tag", []byte(" Look "+iw.tagText()+"  "+viewStatus(len(rs), iw.query)))
+}
+
+// sortBy returns the field iw's query sorts by.
+func (iw *indexWindow) sortBy() metadata.SortBy {
+	sortBy, _ := parseSort(config.DefaultSort)
+	for _, arg := range parseArgs(iw.query) {
+		if spec, ok := strings.CutPrefix(arg, "sort:"); ok {
+			sortBy, _ = parseSort(spec)
+		}
+	}
+	return sortBy
+}
+
+// jump moves dot to the first note of section, a letter or #: its
+// header if the window has section headers, else the first note whose
+// title is in it.
+func (iw *indexWindow) jump(section string) {
+	section = strings.ToUpper(strings.TrimSpace(section))
+	if section == "" {
+		notifyError("usage: Jump <letter>")
+		return
+	}
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	body, err := iw.win.ReadAll("body")
+	if err != nil {
+		logging.Errorf("failed to read window body: %v", err)
+		return
+	}
+	q := 0
+	for _, line := range strings.SplitAfter(string(body), "\n") {
+		text := strings.TrimSpace(line)
+		if text == results.SectionHeader(section) {
+			break
+		}
+		if rs, err := results.Unmarshal([]byte(text)); err == nil && len(rs) == 1 && results.Section(rs[0].Title) == section {
+			break
+		}
+		q += utf8.RuneCountInString(line)
+	}
+	if q == utf8.RuneCount(body) {
+		notifyError("no notes under %s", section)
+		return
+	}
+	iw.win.Addr("#%d,#%d", q, q)
+	iw.win.Ctl("dot=addr")
+	iw.win.Ctl("show")
+}
+
+// reset shows the full index.
+func (iw *indexWindow) reset() {
+	iw.search("")
+}
+
+// resetOrRefresh shows the full index in the main window after a change
+// and re-runs the query of a query window, which exists to show it.
+func (iw *indexWindow) resetOrRefresh() {
+	if iw.main {
+		iw.reset()
+	} else {
+		iw.refresh()
+	}
+}
+
+// refresh re-runs the window's query, keeping dot where it was.
+func (iw *indexWindow) refresh() {
+	rs, err := search(iw.query)
+	if err != nil {
+		logging.Errorf("error refreshing %s: %v", iw.name, err)
+		return
+	}
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	iw.win.Ctl("addr=dot")
+	q0, q1, _ := iw.win.ReadAddr()
+	iw.show(rs)
+	if err := iw.win.Addr("#%d,#%d", q0, q1); err != nil {
+		iw.win.Addr("#0")
+	}
+	iw.win.Ctl("dot=addr")
+	iw.win.Ctl("show")
+}
+
+// refreshOthers re-runs the query of every index window except iw, so
+// that all windows reflect a change made in one of them.
+func (iw *indexWindow) refreshOthers() {
+	windowsMu.Lock()
+	defer windowsMu.Unlock()
+	for _, other := range windows {
+		if other != iw {
+			other.refreshInBackground()
+		}
+	}
+}
+
+// refreshInBackground re-runs the window's query in a goroutine of its
+// own, which holds the config read lock while it runs.
+func (iw *indexWindow) refreshInBackground() {
+	go func() {
+		config.RLock()
+		defer config.RUnlock()
+		iw.refresh()
+	}()
+}
+
+// removal is a Remove of the note with identifier id at a time.
+type removal struct {
+	id string
+	at time.Time
+}
+
+// confirmRemove reports whether a Remove of the note with identifier id
+// should go ahead. With config.ConfirmDelete set, the first Remove only
+// asks for confirmation in the +Errors window, and a second one of the
+// same note within that many seconds deletes it, so a mis-chord alone
+// never deletes a note.
+func (iw *indexWindow) confirmRemove(id string) bool {
+	if config.ConfirmDelete <= 0 {
+		return true
+	}
+	now := time.Now()
+	if iw.pending.id == id && now.Sub(iw.pending.at) <= time.Duration(config.ConfirmDelete)*time.Second {
+		iw.pending = removal{}
+		return true
+	}
+	iw.pending = removal{id, now}
+	if dir, err := errorsDir(); err == nil {
+		acme.Err(dir+"/", fmt.Sprintf("Denote: Remove %s again within %ds to delete it", id, config.ConfirmDelete))
+	}
+	return false
+}
+
+var queryWindows atomic.Int32
+
+// openQueryWindow shows the results of query in a new index window named
+// /Denote/query/<n>, leaving the /Denote/ window as it is. The new window
+// handles Put, Remove, and the other index commands on its own.
+func openQueryWindow(query string) {
+	w, err := acme.New()
+	if err != nil {
+		logging.Errorf("failed to open query window: %v", err)
+		return
+	}
+	name := fmt.Sprintf("%squery/%d", wname, queryWindows.Add(1))
+	w.Name(name)
+	iw := &indexWindow{win: w, name: name}
+	iw.search(query)
+	w.Ctl("clean")
+	go func() {
+		defer w.CloseFiles()
+		iw.loop()
+	}()
+}
+
+// loop handles the window's events until it is deleted.
+func (iw *indexWindow) loop() {
+	windowsMu.Lock()
+	windows = append(windows, iw)
+	windowsMu.Unlock()
+	defer func() {
+		windowsMu.Lock()
+		windows = slices.DeleteFunc(windows, func(o *indexWindow) bool { return o == iw })
+		windowsMu.Unlock()
+	}()
+
+	w := iw.win
+	for e := range w.EventChan() {
+		// Get in the /Denote/ window reloads the configuration, which
+		// must not change while another window's command reads it.
+		config.RLock()
+		logging.Debugf("%s: event %c%c %q %q", iw.name, e.C1, e.C2, e.Text, e.Arg)
+		metrics.Add("denote_window_events_total", 1, "type", string(e.C2))
+		switch e.C2 {
+		case 'x', 'X':
+			switch string(e.Text) {
+			case "New":
+				input := strings.TrimSpace(string(e.Arg))
+				if input == "" {
+					break
+				}
+				if err := writeNew(input, notifyError); err != nil {
+					notifyError("failed to create note: %v", err)
+				}
+				iw.resetOrRefresh()
+				iw.refreshOthers()
+			case "Remove":
+				input := strings.TrimSpace(string(e.Arg))
+				if input == "" || !iw.confirmRemove(input) {
+					break
+				}
+				if err := p9client.With9P(func(f *client.Fsys) error {
+					dir, err := readDenoteDir(f)
+					if err != nil {
+						return err
+					}
+					path := notePath(f, dir, input)
+					warnLocked(dir, input, path)
+					if err := checkUnsaved(path); err != nil {
+						return err
+					}
+					if err := deleteNote(f, input, path); err != nil {
+						return err
+					}
+					lock.Release(dir, input, lockHolder())
+					autoCommit(f, vcs.DeleteMessage(input, path), path)
+					runHook(hookDelete, input, path, "")
+					return nil
+				}); err != nil {
+					notifyError("failed to delete file: %v", err)
+				}
+				iw.refresh()
+				iw.refreshOthers()
+			case "Look":
+				iw.search(string(e.Arg))
+				history.add(string(e.Arg))
+				iw.save()
+			case "Search":
+				query := strings.TrimSpace(string(e.Arg))
+				if query == "" {
+					query = iw.query
+				}
+				openMatchesWindow(wname+matchesWname, func() ([]results.Match, error) { return searchMatches(query) })
+			case "Jump":
+				iw.jump(string(e.Arg))
+			case "Grep":
+				iw.grep(string(e.Arg))
+			case "Look+":
+				openQueryWindow(string(e.Arg))
+				history.add(string(e.Arg))
+			case "ID", "TITLE", "TAGS":
+				iw.search(toggleSort(iw.query, metadata.SortBy(strings.ToLower(string(e.Text)))))
+				iw.save()
+			case "Again":
+				iw.search(history.last())
+				iw.save()
+			case "Del":
+				iw.save()
+				w.WriteEvent(e)
+			case "Filter":
+				openFilterWindow(iw)
+			case "History":
+				openHistoryWindow()
+			case "Set":
+				err := p9client.With9P(func(f *client.Fsys) error {
+					return p9client.WriteFile(f, "ctl", "set "+strings.TrimSpace(string(e.Arg)))
+				})
+				if err != nil {
+					notifyError("failed to set option: %v", err)
+				}
+			case "Commit":
+				if err := cmdCommit(strings.Fields(string(e.Arg))); err != nil {
+					notifyError("failed to commit: %v", err)
+				}
+			case "Tree":
+				openTreeWindow()
+			case "Conflicts":
+				openConflictsWindow()
+			case "Agenda":
+				openAgendaWindow()
+			case "Diff":
+				// Chorded with an identifier and optionally a revision.
+				var rev string
+				arg := string(e.Arg)
+				if f := strings.Fields(arg); len(f) == 2 && metadata.IsIdentifier(f[0]) {
+					arg, rev = f[0], f[1]
+				}
+				ids, err := iw.selectedIdentifiers(arg)
+				if err != nil || len(ids) == 0 {
+					notifyError("usage: Diff <identifier> [rev]")
+					break
+				}
+				openDiffWindow(resolveAlias(ids[0]), rev)
+			case "Suggest":
+				ids, err := iw.selectedIdentifiers(string(e.Arg))
+				if err != nil || len(ids) == 0 {
+					notifyError("usage: Suggest <identifier>")
+					break
+				}
+				for _, id := range ids {
+					openSuggestWindow(resolveAlias(id), func() {
+						iw.refresh()
+						iw.refreshOthers()
+					})
+				}
+			case "Journal", "Journal streak":
+				// The subcommand is swept along with Journal or chorded.
+				if args := strings.Fields(string(e.Text) + " " + string(e.Arg)); len(args) != 2 || args[1] != "streak" {
+					notifyError("usage: Journal streak")
+					break
+				}
+				openStreakWindow()
+			case "Get":
+				if arg := strings.TrimSpace(string(e.Arg)); arg != "" {
+					// Chorded with identifiers: reload only those notes.
+					ids, err := iw.selectedIdentifiers(arg)
+					if err != nil || len(ids) == 0 {
+						notifyError("usage: Get [identifier...]")
+						break
+					}
+					err = p9client.With9P(func(f *client.Fsys) error {
+						dir, err := readDenoteDir(f)
+						if err != nil {
+							return err
+						}
+						for _, id := range ids {
+							if err := reloadNote(f, dir, resolveAlias(id)); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+					if err != nil {
+						notifyError("failed to reload %s: %v", arg, err)
+					}
+					iw.refresh()
+					iw.refreshOthers()
+					break
+				}
+				config.RUnlock()
+				loadConfig()
+				config.RLock()
+				report, err := syncAll()
+				if err != nil {
+					notifyError("failed to sync: %v", err)
+				}
+				iw.resetOrRefresh()
+				iw.refreshOthers()
+				warnDuplicates()
+				if report != nil && !report.Empty() {
+					openChangesWindow(report)
+				}
+			case "Put":
+				body, err := w.ReadAll("body")
+				if err != nil {
+					logging.Errorf("failed to read window body: %v", err)
+					break
+				}
+				entries, err := results.UnmarshalStrict(body)
+				if err != nil {
+					notifyError("failed to parse window: %v", err)
+					break
+				}
+				if err := p9client.With9P(func(f *client.Fsys) error {
+					dir, err := readDenoteDir(f)
+					if err != nil {
+						return err
+					}
+					for _, e := range entries {
+						old, _ := p9client.ReadFields(f, e.Identifier, "path", "title", "keywords")
+						oldPath := old["path"]
+						oldTags := strings.Split(old["keywords"], ",")
+						tags, err := checkTags(unmarkEncrypted(e.Tags, oldTags), oldTags, notifyError)
+						if err != nil {
+							notifyError("%s: %v", e.Identifier, err)
+							continue
+						}
+						keywords := strings.Join(tags, ",")
+						if old != nil && old["title"] == e.Title && old["keywords"] == keywords {
+							continue
+						}
+						warnLocked(dir, e.Identifier, oldPath)
+						if err := checkUnsaved(oldPath); err != nil {
+							notifyError("%s: %v", e.Identifier, err)
+							continue
+						}
+						snapshotNote(e.Identifier, oldPath, "update")
+						fields := map[string]string{"title": e.Title, "keywords": keywords}
+						if _, err := p9client.WriteFields(f, e.Identifier, old, fields); err != nil {
+							return err
+						}
+						newPath, err := p9client.ReadFile(f, "n/"+e.Identifier+"/path")
+						switch {
+						case err != nil:
+						case newPath != oldPath:
+							reloadNoteWindow(oldPath, newPath)
+							autoCommit(f, vcs.RenameMessage(e.Identifier, oldPath, newPath), oldPath, newPath)
+							runHook(hookRename, e.Identifier, oldPath, newPath)
+						default:
+							reloadNoteWindow(oldPath, newPath)
+							runHook(hookUpdate, e.Identifier, "", newPath)
+						}
+					}
+					return nil
+				}); err != nil {
+					notifyError("failed to apply changes: %v", err)
+				}
+				iw.refreshOthers()
+			default:
+				// Grep and Jump may also be swept along with their argument.
+				if pattern, ok := strings.CutPrefix(string(e.Text), "Grep "); ok {
+					iw.grep(pattern)
+					break
+				}
+				if section, ok := strings.CutPrefix(string(e.Text), "Jump "); ok {
+					iw.jump(section)
+					break
+				}
+				if c, ok := findTagCommand(string(e.Text)); ok {
+					iw.runTagCommand(c, string(e.Arg))
+					break
+				}
+				w.WriteEvent(e)
+			}
+		case 'l', 'L':
+			if !plumbIdentifier(string(e.Text)) {
+				w.WriteEvent(e)
+			}
+		default:
+			w.WriteEvent(e)
+		}
+		config.RUnlock()
+	}
+}
+
+// grep shows the lines matching pattern in the notes of iw's current
+// view in the +Grep window.
+func (iw *indexWindow) grep(pattern string) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		notifyError("usage: Grep <regexp>")
+		return
+	}
+	query := iw.query
+	openMatchesWindow(wname+grepWname, func() ([]results.Match, error) { return grepNotes(pattern, query) })
+}
+
+// deleteSearchDepth bounds the search for a note the server has no path
+// for, so that a stale entry does not walk an arbitrarily deep tree.
+const deleteSearchDepth = 8
+
+// notePath returns the path of the note with identifier id: the one the
+// server records, or else the note's file found below dir. It returns ""
+// if there is neither.
+func notePath(f *client.Fsys, dir, id string) string {
+	if path, err := p9client.ReadFile(f, filepath.Join("n", id, "path")); err == nil && path != "" {
+		return path
+	}
+	opts := metadata.DefaultScanOptions
+	if opts.MaxDepth == 0 || opts.MaxDepth > deleteSearchDepth {
+		opts.MaxDepth = deleteSearchDepth
+	}
+	if md, ok := opts.FindNote(dir, id); ok {
+		return md.Path
+	}
+	return ""
+}
+
+// deleteNote moves the note with identifier id to the trash: it keeps a
+// copy of the file at path, has the server delete the note, and checks
+// that the file is gone, reporting a file that survived rather than
+// assuming the server removed it.
+func deleteNote(f *client.Fsys, id, path string) error {
+	if path == "" {
+		return fmt.Errorf("%s: no such note, nothing deleted", id)
+	}
+	undo, err := trashNote(id, path)
+	if err != nil {
+		return err
+	}
+	if err := p9client.WriteFile(f, filepath.Join("n", id, "ctl"), "d"); err != nil {
+		undo()
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s: %s was not deleted", id, path)
+	}
+	return nil
+}
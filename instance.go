@@ -0,0 +1,71 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"9fans.net/go/acme"
+)
+
+// pidFile records the process that drives the /Denote/ window, so that
+// running Denote again shows that window instead of attaching a second
+// event loop to it. Each service has its own.
+func pidFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acme-denote", p9client.ServiceFile("denote")+".pid"), nil
+}
+
+// runningInstance returns the pid of another live Denote process that
+// owns an open /Denote/ window.
+func runningInstance() (int, bool) {
+	path, err := pidFile()
+	if err != nil {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid == os.Getpid() {
+		return 0, false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil || p.Signal(syscall.Signal(0)) != nil {
+		return 0, false
+	}
+	wins, err := acme.Windows()
+	if err != nil {
+		return 0, false
+	}
+	for _, w := range wins {
+		if w.Name == wname {
+			return pid, true
+		}
+	}
+	return 0, false
+}
+
+// claimInstance records this process as the owner of the /Denote/
+// window. The returned function releases the claim.
+func claimInstance() (func(), error) {
+	path, err := pidFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+		return nil, err
+	}
+	return func() { os.Remove(path) }, nil
+}
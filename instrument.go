@@ -0,0 +1,12 @@
+package main
+
+import "denote/pkg/metrics"
+
+func init() {
+	metrics.Register("denote_searches_total", metrics.Counter, "Index searches run, including window refreshes.")
+	metrics.Register("denote_search_seconds", metrics.Summary, "Time to filter, read, and sort the index.")
+	metrics.Register("denote_notes_indexed", metrics.Gauge, "Notes in the index when it was last read unfiltered.")
+	metrics.Register("denote_window_events_total", metrics.Counter, "Acme events handled by index windows, by type.")
+	metrics.Register("denote_index_reloads_total", metrics.Counter, "Requests to the server to reload the index from disk.")
+	metrics.Register("denote_index_reload_seconds", metrics.Summary, "Time the server took to reload the index.")
+}
@@ -0,0 +1,149 @@
+// Package logging provides leveled logging for Denote on top of the
+// standard log package. Messages go to standard error and, once Open has
+// been called, to a log file that is rotated when it grows too large.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a message.
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level named s (debug, info, warn, or error).
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level: %s", s)
+}
+
+// MaxSize is the size in bytes at which the log file is rotated. The
+// previous file is kept with a .1 suffix.
+var MaxSize int64 = 1 << 20
+
+var (
+	mu    sync.Mutex
+	level = Info
+)
+
+// SetLevel discards messages below l.
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+func enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l >= level
+}
+
+// Open appends log output, including that of the standard logger, to the
+// file at path in addition to standard error.
+func Open(path string) error {
+	w, err := openRotating(path)
+	if err != nil {
+		return err
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, w))
+	return nil
+}
+
+func logf(l Level, format string, args ...any) {
+	if !enabled(l) {
+		return
+	}
+	log.Output(3, l.String()+": "+fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a message useful only when diagnosing a problem.
+func Debugf(format string, args ...any) { logf(Debug, format, args...) }
+
+// Infof logs a routine message.
+func Infof(format string, args ...any) { logf(Info, format, args...) }
+
+// Warnf logs a problem Denote worked around.
+func Warnf(format string, args ...any) { logf(Warn, format, args...) }
+
+// Errorf logs a failed operation.
+func Errorf(format string, args ...any) { logf(Error, format, args...) }
+
+// rotatingFile is a log file that is renamed to path.1 and started
+// afresh when it reaches MaxSize.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func openRotating(path string) (*rotatingFile, error) {
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, fi.Size()
+	if r.size >= MaxSize {
+		return r.rotate()
+	}
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size+int64(len(p)) > MaxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
@@ -3,22 +3,32 @@
 package client
 
 import (
+	"denote/pkg/metrics"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"9fans.net/go/plan9"
 	"9fans.net/go/plan9/client"
 )
 
+func init() {
+	metrics.Register("denote_9p_requests_total", metrics.Counter, "9P file reads and writes by operation and top-level file.")
+	metrics.Register("denote_9p_request_seconds", metrics.Summary, "Time spent in 9P file reads and writes.")
+}
+
+// record counts a read or write of path.
+func record(op, path string, start time.Time) {
+	file, _, _ := strings.Cut(path, "/")
+	metrics.Add("denote_9p_requests_total", 1, "op", op, "file", file)
+	metrics.Since("denote_9p_request_seconds", start, "op", op)
+}
+
 // With9P establishes a connection to the denote 9P server and executes fn.
 func With9P(fn func(*client.Fsys) error) error {
-	ns := client.Namespace()
-	if ns == "" {
-		return fmt.Errorf("failed to get namespace")
-	}
-
-	conn, err := client.DialService("denote")
+	conn, err := dial()
 	if err != nil {
 		return fmt.Errorf("failed to connect to denote service: %w", err)
 	}
@@ -35,6 +45,7 @@ func With9P(fn func(*client.Fsys) error) error {
 
 // WriteFile writes data to a 9P file at the given path.
 func WriteFile(f *client.Fsys, path string, data string) error {
+	defer record("write", path, time.Now())
 	fid, err := f.Open(path, plan9.OWRITE)
 	if err != nil {
 		return err
@@ -46,6 +57,7 @@ func WriteFile(f *client.Fsys, path string, data string) error {
 }
 
 func ReadFile(f *client.Fsys, path string) (string, error) {
+	defer record("read", path, time.Now())
 	fid, err := f.Open(path, plan9.OREAD)
 	if err != nil {
 		return "", err
@@ -67,6 +79,39 @@ func ReadFile(f *client.Fsys, path string) (string, error) {
 	return strings.TrimSpace(string(content)), nil
 }
 
+// maxReadTries bounds how often ReadStable reads a file that keeps
+// changing.
+const maxReadTries = 3
+
+// ReadStable reads path as ReadFile does and returns it with the content
+// of seq, a file the server changes whenever path changes. A read during
+// which seq changed is repeated, so that the content is a single version
+// even from a server that does not snapshot files per open; after
+// maxReadTries such reads ReadStable fails. If the server has no seq
+// file, path is read once and the seq returned is "".
+func ReadStable(f *client.Fsys, path, seq string) (string, string, error) {
+	before, err := ReadFile(f, seq)
+	if err != nil {
+		content, err := ReadFile(f, path)
+		return content, "", err
+	}
+	for try := 0; try < maxReadTries; try++ {
+		content, err := ReadFile(f, path)
+		if err != nil {
+			return "", "", err
+		}
+		after, err := ReadFile(f, seq)
+		if err != nil {
+			return "", "", err
+		}
+		if after == before {
+			return content, after, nil
+		}
+		before = after
+	}
+	return "", "", fmt.Errorf("%s kept changing while it was read", path)
+}
+
 func ReadFields(f *client.Fsys, identifier string, fields ...string) (map[string]string, error) {
 	result := make(map[string]string)
 	for _, field := range fields {
@@ -79,3 +124,39 @@ func ReadFields(f *client.Fsys, identifier string, fields ...string) (map[string
 	return result, nil
 }
 
+// WriteFields writes fields to the note with identifier, skipping those
+// whose value equals the one in old, e.g. as returned by ReadFields. Every
+// write makes the server update and possibly rename the note, so a burst
+// of edits is coalesced into as few writes as possible: several changed
+// fields are sent as one "set" command to the note's ctl file, applied by
+// the server in a single update. Servers without the command get one
+// write per field, in name order. It reports whether anything was written.
+func WriteFields(f *client.Fsys, identifier string, old, fields map[string]string) (bool, error) {
+	var changed []string
+	for field, val := range fields {
+		if cur, ok := old[field]; !ok || cur != val {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) > 1 && WriteFile(f, "n/"+identifier+"/ctl", SetCommand(changed, fields)) == nil {
+		return true, nil
+	}
+	for _, field := range changed {
+		if err := WriteFile(f, "n/"+identifier+"/"+field, fields[field]); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", field, err)
+		}
+	}
+	return len(changed) > 0, nil
+}
+
+// SetCommand returns the note ctl command setting the named fields to
+// their values in fields, e.g. "set keywords='a,b' title='x'". Values
+// are quoted, with any single quote in them doubled.
+func SetCommand(names []string, fields map[string]string) string {
+	cmd := "set"
+	for _, name := range names {
+		cmd += " " + name + "='" + strings.ReplaceAll(fields[name], "'", "''") + "'"
+	}
+	return cmd
+}
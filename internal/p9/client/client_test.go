@@ -0,0 +1,443 @@
+package client
+
+import (
+	"denote/internal/p9/p9test"
+	"denote/pkg/metadata"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"9fans.net/go/plan9/client"
+)
+
+// fakeDenote models the denote service's files over a denote directory:
+// dir, index (filtered by ctl "filter"), new, and n/<id>/{path,title,
+// keywords,ctl}. Metadata writes rename the note on disk.
+type fakeDenote struct {
+	mu     sync.Mutex
+	dir    string
+	filter string
+	srv    *p9test.Server
+}
+
+func startFake(t *testing.T) *fakeDenote {
+	d := &fakeDenote{dir: t.TempDir()}
+	d.srv = p9test.Start(t, map[string]p9test.File{
+		"dir":   {Read: func() ([]byte, error) { return []byte(d.dir), nil }},
+		"index": {Read: d.index},
+		"ctl":   {Write: d.ctl},
+		"new":   {Write: d.create},
+	})
+	return d
+}
+
+func (d *fakeDenote) notes() metadata.Results {
+	rs, _ := metadata.ScanDir(d.dir)
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Identifier < rs[j].Identifier })
+	return rs
+}
+
+func (d *fakeDenote) index() ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var b strings.Builder
+	for _, n := range d.notes() {
+		if strings.Contains(n.Title+" "+strings.Join(n.Tags, " "), d.filter) {
+			fmt.Fprintf(&b, "%s | %s | %s\n", n.Identifier, n.Title, strings.Join(n.Tags, ","))
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func (d *fakeDenote) ctl(data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cmd, arg, _ := strings.Cut(string(data), " ")
+	switch cmd {
+	case "filter":
+		d.filter = arg
+	case "cd":
+		d.dir = arg
+	default:
+		return fmt.Errorf("unknown ctl command: %s", cmd)
+	}
+	return nil
+}
+
+func (d *fakeDenote) create(data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 'title words' tag...
+	title, tags, _ := strings.Cut(strings.TrimPrefix(string(data), "'"), "'")
+	id := fmt.Sprintf("20240101T1200%02d", len(d.notes()))
+	fm := metadata.NewFrontMatter(title, "", strings.Fields(tags), id)
+	path := filepath.Join(d.dir, metadata.BuildFilename(fm, ".md"))
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return err
+	}
+	d.addNote(id)
+	return nil
+}
+
+// addNote serves the n/<id> files of the note with identifier id.
+func (d *fakeDenote) addNote(id string) {
+	find := func() *metadata.Metadata {
+		for _, n := range d.notes() {
+			if n.Identifier == id {
+				return n
+			}
+		}
+		return nil
+	}
+	rename := func(update func(fm *metadata.FrontMatter)) error {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		n := find()
+		if n == nil {
+			return fmt.Errorf("note %s not found", id)
+		}
+		fm := metadata.NewFrontMatter(n.Title, n.Signature, n.Tags, id)
+		update(fm)
+		return os.Rename(n.Path, filepath.Join(filepath.Dir(n.Path), metadata.BuildFilename(fm, filepath.Ext(n.Path))))
+	}
+	field := func(get func(n *metadata.Metadata) string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if n := find(); n != nil {
+				return []byte(get(n)), nil
+			}
+			return nil, fmt.Errorf("note %s not found", id)
+		}
+	}
+	d.srv.Set("n/"+id+"/path", p9test.File{Read: field(func(n *metadata.Metadata) string { return n.Path })})
+	d.srv.Set("n/"+id+"/title", p9test.File{
+		Read:  field(func(n *metadata.Metadata) string { return n.Title }),
+		Write: func(data []byte) error { return rename(func(fm *metadata.FrontMatter) { fm.Title = string(data) }) },
+	})
+	d.srv.Set("n/"+id+"/keywords", p9test.File{
+		Read: field(func(n *metadata.Metadata) string { return strings.Join(n.Tags, ",") }),
+		Write: func(data []byte) error {
+			return rename(func(fm *metadata.FrontMatter) { fm.Tags = strings.Split(string(data), ",") })
+		},
+	})
+	d.srv.Set("n/"+id+"/ctl", p9test.File{Write: func(data []byte) error {
+		if string(data) != "d" {
+			return fmt.Errorf("unknown note ctl command: %s", data)
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		n := find()
+		if n == nil {
+			return fmt.Errorf("note %s not found", id)
+		}
+		for _, f := range []string{"path", "title", "keywords", "ctl"} {
+			d.srv.Remove("n/" + id + "/" + f)
+		}
+		return os.Remove(n.Path)
+	}})
+}
+
+func TestLifecycle(t *testing.T) {
+	d := startFake(t)
+
+	// create
+	err := With9P(func(f *client.Fsys) error {
+		if err := WriteFile(f, "new", "'first note' work"); err != nil {
+			return err
+		}
+		return WriteFile(f, "new", "'second note' home")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(d.dir, "20240101T120000--first-note__work.md")); err != nil {
+		t.Errorf("first note not created: %v", err)
+	}
+
+	// filter
+	var index string
+	err = With9P(func(f *client.Fsys) error {
+		if err := WriteFile(f, "ctl", "filter home"); err != nil {
+			return err
+		}
+		index, err = ReadFile(f, "index")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "20240101T120001 | second note | home"; index != want {
+		t.Errorf("filtered index = %q, want %q", index, want)
+	}
+
+	// rename
+	var fields map[string]string
+	err = With9P(func(f *client.Fsys) error {
+		if err := WriteFile(f, "n/20240101T120000/title", "renamed note"); err != nil {
+			return err
+		}
+		if err := WriteFile(f, "n/20240101T120000/keywords", "work,done"); err != nil {
+			return err
+		}
+		fields, err = ReadFields(f, "20240101T120000", "path", "title", "keywords")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPath := filepath.Join(d.dir, "20240101T120000--renamed-note__work_done.md")
+	if fields["path"] != wantPath || fields["title"] != "renamed note" || fields["keywords"] != "work,done" {
+		t.Errorf("fields after rename = %v", fields)
+	}
+	if _, err := os.Stat(wantPath); err != nil {
+		t.Errorf("renamed note missing: %v", err)
+	}
+
+	// delete
+	err = With9P(func(f *client.Fsys) error {
+		return WriteFile(f, "n/20240101T120001/ctl", "d")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs := d.notes(); len(rs) != 1 || rs[0].Identifier != "20240101T120000" {
+		t.Errorf("notes after delete = %v", rs)
+	}
+	err = With9P(func(f *client.Fsys) error {
+		_, err := ReadFile(f, "n/20240101T120001/path")
+		return err
+	})
+	if err == nil {
+		t.Error("reading a deleted note succeeded")
+	}
+}
+
+func TestWith9PNoServer(t *testing.T) {
+	t.Setenv("NAMESPACE", t.TempDir())
+	t.Setenv("DENOTE_ADDR", "127.0.0.1:1")
+	if err := With9P(func(f *client.Fsys) error { return nil }); err == nil {
+		t.Error("With9P() without a server succeeded")
+	}
+}
+
+func TestWriteFields(t *testing.T) {
+	var writes []string
+	record := func(name string) func(data []byte) error {
+		return func(data []byte) error {
+			writes = append(writes, name+"="+string(data))
+			return nil
+		}
+	}
+	files := map[string]p9test.File{
+		"n/1/title":     {Write: record("title")},
+		"n/1/keywords":  {Write: record("keywords")},
+		"n/1/signature": {Write: record("signature")},
+		"n/1/ctl":       {Write: func([]byte) error { return fmt.Errorf("unknown note ctl command") }},
+	}
+	srv := p9test.Start(t, files)
+
+	old := map[string]string{"title": "note", "keywords": "a,b", "signature": ""}
+	write := func(fields map[string]string) bool {
+		t.Helper()
+		writes = nil
+		var wrote bool
+		err := With9P(func(f *client.Fsys) error {
+			var err error
+			wrote, err = WriteFields(f, "1", old, fields)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wrote
+	}
+
+	// A server without "set" gets one write per changed field.
+	if !write(map[string]string{"title": "it's new", "keywords": "a,b", "signature": "x"}) {
+		t.Error("WriteFields() reported no writes")
+	}
+	if got, want := strings.Join(writes, " "), "signature=x title=it's new"; got != want {
+		t.Errorf("WriteFields() wrote %q, want %q", got, want)
+	}
+
+	// A server with "set" gets a single ctl write.
+	srv.Set("n/1/ctl", p9test.File{Write: record("ctl")})
+	write(map[string]string{"title": "it's new", "keywords": "a,b", "signature": "x"})
+	if got, want := strings.Join(writes, " "), "ctl=set signature='x' title='it''s new'"; got != want {
+		t.Errorf("WriteFields() wrote %q, want %q", got, want)
+	}
+
+	// A single changed field is written directly.
+	write(map[string]string{"title": "new"})
+	if got, want := strings.Join(writes, " "), "title=new"; got != want {
+		t.Errorf("WriteFields() wrote %q, want %q", got, want)
+	}
+
+	if write(old) || len(writes) != 0 {
+		t.Errorf("WriteFields(unchanged) wrote %q", writes)
+	}
+}
+
+// TestReadFileSnapshot checks that ReadFile returns one consistent
+// version of a file that is larger than a single 9P read and changes
+// while it is read, as long as the server snapshots content per open.
+func TestReadFileSnapshot(t *testing.T) {
+	var mu sync.Mutex
+	version := 0
+	content := func(v int) []byte {
+		var b strings.Builder
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(&b, "20240101T%06d | version %d | tag\n", i, v)
+		}
+		return []byte(b.String())
+	}
+	p9test.Start(t, map[string]p9test.File{
+		"index": {Read: func() ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			version++
+			return content(version), nil
+		}},
+	})
+
+	var first, second string
+	err := With9P(func(f *client.Fsys) error {
+		var err error
+		if first, err = ReadFile(f, "index"); err != nil {
+			return err
+		}
+		second, err = ReadFile(f, "index")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSpace(string(content(1))); first != want {
+		t.Errorf("first read mixes versions")
+	}
+	if want := strings.TrimSpace(string(content(2))); second != want {
+		t.Errorf("second read is not the next version")
+	}
+}
+
+func TestReadStable(t *testing.T) {
+	var mu sync.Mutex
+	version, reads := 0, 0
+	changes := 2 // index reads that race with a change
+	s := p9test.Start(t, map[string]p9test.File{
+		"index": {Read: func() ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if reads++; reads <= changes {
+				version++
+			}
+			return []byte(fmt.Sprintf("version %d", version)), nil
+		}},
+		"seq": {Read: func() ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return []byte(fmt.Sprint(version)), nil
+		}},
+	})
+	read := func() (string, string, error) {
+		var content, seq string
+		err := With9P(func(f *client.Fsys) error {
+			var err error
+			content, seq, err = ReadStable(f, "index", "seq")
+			return err
+		})
+		return content, seq, err
+	}
+
+	if content, seq, err := read(); err != nil || content != "version 2" || seq != "2" {
+		t.Errorf("ReadStable() = %q, %q, %v; want version 2 once seq stopped changing", content, seq, err)
+	}
+
+	mu.Lock()
+	reads, changes = 0, maxReadTries
+	mu.Unlock()
+	if _, _, err := read(); err == nil {
+		t.Error("ReadStable() of a file that keeps changing succeeded")
+	}
+
+	s.Remove("seq")
+	mu.Lock()
+	reads, changes = 0, 0
+	mu.Unlock()
+	if content, seq, err := read(); err != nil || content != "version 5" || seq != "" {
+		t.Errorf("ReadStable() without seq = %q, %q, %v; want version 5", content, seq, err)
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			var index strings.Builder
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(&index, "20240101T%06d | note %d about golang | work,meeting\n", i, i)
+			}
+			data := []byte(index.String())
+			p9test.Start(b, map[string]p9test.File{
+				"index": {Read: func() ([]byte, error) { return data, nil }},
+			})
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			err := With9P(func(f *client.Fsys) error {
+				for i := 0; i < b.N; i++ {
+					if _, err := ReadFile(f, "index"); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // no AddrFile
+	t.Setenv("NAMESPACE", t.TempDir())
+	t.Setenv("DENOTE_ADDR", "")
+	if got := Probe(); got != SocketAbsent {
+		t.Errorf("Probe() without a server = %v, want absent", got)
+	}
+
+	srv := p9test.Start(t, map[string]p9test.File{})
+	if got := Probe(); got != SocketLive {
+		t.Errorf("Probe() with a server = %v, want live", got)
+	}
+	srv.Close()
+	if got := Probe(); got != SocketStale {
+		t.Errorf("Probe() after the server exited = %v, want stale", got)
+	}
+}
+
+func TestService(t *testing.T) {
+	for name, want := range map[string]bool{"denote": true, "denote.work": true, "": false, "..": false, "a/b": false, "a b": false} {
+		if got := ValidService(name); got != want {
+			t.Errorf("ValidService(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func(s string) { Service = s }(Service)
+	Service = DefaultService
+	if got := filepath.Base(AddrFile()); got != "addr" {
+		t.Errorf("AddrFile() for %s = %s, want addr", Service, got)
+	}
+	Service = "denote.work"
+	if got := filepath.Base(AddrFile()); got != "addr.denote.work" {
+		t.Errorf("AddrFile() for %s = %s, want addr.denote.work", Service, got)
+	}
+	if got := ServiceFile("state"); got != "state.denote.work" {
+		t.Errorf("ServiceFile(state) for %s = %s, want state.denote.work", Service, got)
+	}
+}
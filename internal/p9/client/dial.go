@@ -0,0 +1,139 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// DefaultService is the name the denote server is posted under unless
+// another is chosen.
+const DefaultService = "denote"
+
+// Service is the name of the denote service to connect to, e.g.
+// denote.work to keep a work silo in its own server. It defaults to
+// $DENOTE_SERVICE, or DefaultService.
+var Service = defaultService()
+
+func defaultService() string {
+	if s := os.Getenv("DENOTE_SERVICE"); s != "" {
+		return s
+	}
+	return DefaultService
+}
+
+// ValidService reports whether name can be used as a service name: it is
+// posted as a file in the namespace directory.
+func ValidService(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\ \t\n")
+}
+
+// AddrFile returns the file in which a server listening on TCP records
+// its address, for systems without a plan9port namespace. Services other
+// than DefaultService have their own file, addr.<service>.
+func AddrFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "acme-denote", ServiceFile("addr"))
+}
+
+// ServiceFile returns name for DefaultService and name.<service> for
+// other services, naming files each service has its own of.
+func ServiceFile(name string) string {
+	if Service != DefaultService {
+		name += "." + Service
+	}
+	return name
+}
+
+// tcpAddr returns the server's TCP address from $DENOTE_ADDR or AddrFile,
+// or "" if neither is set.
+func tcpAddr() string {
+	if addr := os.Getenv("DENOTE_ADDR"); addr != "" {
+		return addr
+	}
+	data, err := os.ReadFile(AddrFile())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// dial connects to the denote service through the plan9port namespace.
+// If there is no namespace or no server is posted in it, dial falls back
+// to TCP on the address in $DENOTE_ADDR or AddrFile, so the client also
+// works outside a full plan9port environment (e.g. on Windows).
+func dial() (*client.Conn, error) {
+	var nsErr error
+	if ns := client.Namespace(); ns != "" {
+		conn, err := client.DialService(Service)
+		if err == nil {
+			return conn, nil
+		}
+		nsErr = err
+	} else {
+		nsErr = fmt.Errorf("no plan9 namespace")
+	}
+
+	addr := tcpAddr()
+	if addr == "" {
+		return nil, nsErr
+	}
+	conn, err := client.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%v; tcp %s: %w", nsErr, addr, err)
+	}
+	return conn, nil
+}
+
+// SocketState describes what is at the denote service's address.
+type SocketState int
+
+const (
+	SocketAbsent SocketState = iota // no socket and no recorded address
+	SocketStale                     // a socket or address nothing listens on
+	SocketLive                      // something accepts connections
+)
+
+func (s SocketState) String() string {
+	switch s {
+	case SocketLive:
+		return "live"
+	case SocketStale:
+		return "stale"
+	}
+	return "absent"
+}
+
+// Probe reports whether anything accepts connections at the denote
+// service's namespace socket or TCP address, without speaking 9P. A
+// server that accepts connections but fails to attach is live: starting
+// another server would remove its socket from under it.
+func Probe() SocketState {
+	state := SocketAbsent
+	if ns := client.Namespace(); ns != "" {
+		sock := filepath.Join(ns, Service)
+		if _, err := os.Stat(sock); err == nil {
+			if c, err := net.DialTimeout("unix", sock, time.Second); err == nil {
+				c.Close()
+				return SocketLive
+			}
+			state = SocketStale
+		}
+	}
+	if addr := tcpAddr(); addr != "" {
+		if c, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			c.Close()
+			return SocketLive
+		}
+		state = SocketStale
+	}
+	return state
+}
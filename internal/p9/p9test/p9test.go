@@ -0,0 +1,289 @@
+// Package p9test provides an in-process 9P file server for tests that
+// drive the denote client against a real 9P connection. The server
+// serves a tree of synthetic files whose contents come from callbacks,
+// so a test can model the denote service's files (dir, index, ctl, new,
+// n/<identifier>/...) over a temporary denote directory.
+package p9test
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"9fans.net/go/plan9"
+)
+
+// File is a synthetic file. Read is called when the file is opened for
+// reading and its result is served for that open; Write is called with
+// the data of every write. A nil callback makes the file unreadable or
+// unwritable.
+type File struct {
+	Read  func() ([]byte, error)
+	Write func(data []byte) error
+}
+
+// Server is a 9P server listening on a loopback TCP address.
+type Server struct {
+	Addr string
+
+	l     net.Listener
+	mu    sync.Mutex
+	files map[string]File   // by slash-separated path below the root
+	qids  map[string]uint64 // qid paths, stable per file path
+}
+
+// New starts a server for files.
+func New(files map[string]File) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{Addr: l.Addr().String(), l: l, files: map[string]File{}, qids: map[string]uint64{}}
+	for name, f := range files {
+		s.Set(name, f)
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Start starts a server for files and points the denote client at it for
+// the rest of the test: $DENOTE_ADDR is its address and $NAMESPACE an
+// empty directory, so no plan9port service is found first.
+func Start(t testing.TB, files map[string]File) *Server {
+	t.Helper()
+	s, err := New(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	t.Setenv("NAMESPACE", t.TempDir())
+	t.Setenv("DENOTE_ADDR", s.Addr)
+	return s
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.l.Close()
+}
+
+// Set adds or replaces the file at name.
+func (s *Server) Set(name string, f File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[strings.Trim(name, "/")] = f
+}
+
+// Remove removes the file at name.
+func (s *Server) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, strings.Trim(name, "/"))
+}
+
+// lookup returns the qid of name and its file, if it is not a directory.
+func (s *Server) lookup(name string) (plan9.Qid, *File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.qids[name]
+	if !ok {
+		id = uint64(len(s.qids) + 1)
+	}
+	if f, found := s.files[name]; found {
+		s.qids[name] = id
+		return plan9.Qid{Path: id}, &f, true
+	}
+	for n := range s.files {
+		if name == "" || strings.HasPrefix(n, name+"/") {
+			s.qids[name] = id
+			return plan9.Qid{Path: id, Type: plan9.QTDIR}, nil, true
+		}
+	}
+	return plan9.Qid{}, nil, false
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(c)
+	}
+}
+
+// fid is the state of a client fid.
+type fid struct {
+	name    string
+	file    *File
+	content []byte // snapshot served to reads
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+	fids := map[uint32]*fid{}
+	for {
+		tx, err := plan9.ReadFcall(c)
+		if err != nil {
+			return
+		}
+		rx := s.handle(tx, fids)
+		rx.Tag = tx.Tag
+		if err := plan9.WriteFcall(c, rx); err != nil {
+			return
+		}
+	}
+}
+
+func rerror(format string, args ...any) *plan9.Fcall {
+	return &plan9.Fcall{Type: plan9.Rerror, Ename: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) handle(tx *plan9.Fcall, fids map[uint32]*fid) *plan9.Fcall {
+	switch tx.Type {
+	case plan9.Tversion:
+		return &plan9.Fcall{Type: plan9.Rversion, Msize: min(tx.Msize, 8192+plan9.IOHDRSIZE), Version: "9P2000"}
+	case plan9.Tauth:
+		return rerror("authentication not required")
+	case plan9.Tattach:
+		qid, _, _ := s.lookup("")
+		fids[tx.Fid] = &fid{}
+		return &plan9.Fcall{Type: plan9.Rattach, Qid: qid}
+	case plan9.Tflush:
+		return &plan9.Fcall{Type: plan9.Rflush}
+	}
+
+	f, ok := fids[tx.Fid]
+	if !ok {
+		return rerror("unknown fid %d", tx.Fid)
+	}
+	switch tx.Type {
+	case plan9.Twalk:
+		name := f.name
+		var qids []plan9.Qid
+		for _, elem := range tx.Wname {
+			name = strings.TrimPrefix(path.Join(name, elem), "/")
+			if name == ".." || name == "." {
+				name = ""
+			}
+			qid, _, ok := s.lookup(name)
+			if !ok {
+				break
+			}
+			qids = append(qids, qid)
+		}
+		if len(qids) == 0 && len(tx.Wname) > 0 {
+			return rerror("file not found: %s", path.Join(f.name, path.Join(tx.Wname...)))
+		}
+		if len(qids) == len(tx.Wname) {
+			fids[tx.Newfid] = &fid{name: name}
+		}
+		return &plan9.Fcall{Type: plan9.Rwalk, Wqid: qids}
+
+	case plan9.Topen:
+		qid, file, ok := s.lookup(f.name)
+		if !ok {
+			return rerror("file not found: %s", f.name)
+		}
+		mode := tx.Mode &^ plan9.OTRUNC
+		if file != nil {
+			if mode != plan9.OWRITE {
+				if file.Read == nil {
+					return rerror("permission denied: %s", f.name)
+				}
+				content, err := file.Read()
+				if err != nil {
+					return rerror("%v", err)
+				}
+				f.content = content
+			}
+			if mode != plan9.OREAD && file.Write == nil {
+				return rerror("permission denied: %s", f.name)
+			}
+		} else if mode != plan9.OREAD {
+			return rerror("is a directory: %s", f.name)
+		} else {
+			f.content = s.dirContent(f.name)
+		}
+		f.file = file
+		return &plan9.Fcall{Type: plan9.Ropen, Qid: qid}
+
+	case plan9.Tread:
+		var data []byte
+		if tx.Offset < uint64(len(f.content)) {
+			data = f.content[tx.Offset:]
+			if uint64(len(data)) > uint64(tx.Count) {
+				data = data[:tx.Count]
+			}
+		}
+		return &plan9.Fcall{Type: plan9.Rread, Data: data}
+
+	case plan9.Twrite:
+		if f.file == nil || f.file.Write == nil {
+			return rerror("permission denied: %s", f.name)
+		}
+		if err := f.file.Write(tx.Data); err != nil {
+			return rerror("%v", err)
+		}
+		return &plan9.Fcall{Type: plan9.Rwrite, Count: uint32(len(tx.Data))}
+
+	case plan9.Tclunk:
+		delete(fids, tx.Fid)
+		return &plan9.Fcall{Type: plan9.Rclunk}
+
+	case plan9.Tstat:
+		qid, _, ok := s.lookup(f.name)
+		if !ok {
+			return rerror("file not found: %s", f.name)
+		}
+		d := plan9.Dir{Name: path.Base("/" + f.name), Qid: qid, Mode: 0666, Uid: "denote", Gid: "denote", Muid: "denote"}
+		if qid.Type&plan9.QTDIR != 0 {
+			d.Mode = plan9.DMDIR | 0777
+		}
+		b, err := d.Bytes()
+		if err != nil {
+			return rerror("%v", err)
+		}
+		return &plan9.Fcall{Type: plan9.Rstat, Stat: b}
+	}
+	return rerror("unsupported request %d", tx.Type)
+}
+
+// dirContent returns the packed directory entries below name.
+func (s *Server) dirContent(name string) []byte {
+	s.mu.Lock()
+	seen := map[string]bool{}
+	for n := range s.files {
+		rest, ok := strings.CutPrefix(n, name+"/")
+		if name == "" {
+			rest, ok = n, true
+		}
+		if ok {
+			elem, _, _ := strings.Cut(rest, "/")
+			seen[elem] = true
+		}
+	}
+	s.mu.Unlock()
+	var names []string
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var b []byte
+	for _, n := range names {
+		child := strings.TrimPrefix(name+"/"+n, "/")
+		qid, _, _ := s.lookup(child)
+		d := plan9.Dir{Name: n, Qid: qid, Mode: 0666, Uid: "denote", Gid: "denote", Muid: "denote"}
+		if qid.Type&plan9.QTDIR != 0 {
+			d.Mode = plan9.DMDIR | 0777
+		}
+		if db, err := d.Bytes(); err == nil {
+			b = append(b, db...)
+		}
+	}
+	return b
+}
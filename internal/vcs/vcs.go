@@ -0,0 +1,146 @@
+// Package vcs provides an optional git backend for versioning notes.
+// It shells out to git(1) and contains no denote business logic beyond
+// formatting structured commit messages.
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Repo is a git working tree containing a denote directory.
+type Repo struct {
+	Dir string
+}
+
+// Open returns the Repo for dir, or an error if dir is not inside a git
+// working tree.
+func Open(dir string) (*Repo, error) {
+	r := &Repo{Dir: dir}
+	out, err := r.git("rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
+	if strings.TrimSpace(out) != "true" {
+		return nil, fmt.Errorf("%s is not a git working tree", dir)
+	}
+	return r, nil
+}
+
+// Commit stages paths (including removals) and commits them with msg.
+// If no paths are given, all changes in the denote directory are staged.
+// Only paths are committed: other changes staged by the user are left
+// staged. Committing with nothing staged is not an error.
+func (r *Repo) Commit(msg string, paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	args := append([]string{"add", "-A", "--"}, paths...)
+	if _, err := r.git(args...); err != nil {
+		return err
+	}
+	args = append([]string{"diff", "--cached", "--quiet", "--"}, paths...)
+	if _, err := r.git(args...); err == nil {
+		return nil
+	}
+	args = append([]string{"commit", "-q", "-m", msg, "--"}, paths...)
+	_, err := r.git(args...)
+	return err
+}
+
+// Tracked reports whether git tracks the file at path.
+func (r *Repo) Tracked(path string) (bool, error) {
+	out, err := r.git("ls-files", "--", path)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// Log returns the git log of a single note, following renames.
+func (r *Repo) Log(path string) (string, error) {
+	return r.git("log", "--follow", "--date=iso", "--stat", "--", path)
+}
+
+// Revision is a commit that changed a note.
+type Revision struct {
+	Hash string
+	Path string // the note's path in the commit, relative to the top level
+}
+
+// Revisions returns the commits that changed the note at path, newest
+// first, following renames.
+func (r *Repo) Revisions(path string) ([]Revision, error) {
+	out, err := r.git("log", "--follow", "--format=commit %H", "--name-only", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var revs []Revision
+	for _, line := range strings.Split(out, "\n") {
+		if hash, ok := strings.CutPrefix(line, "commit "); ok {
+			revs = append(revs, Revision{Hash: hash})
+		} else if line != "" && len(revs) > 0 && revs[len(revs)-1].Path == "" {
+			revs[len(revs)-1].Path = line
+		}
+	}
+	return revs, nil
+}
+
+// Resolve returns the commit hash rev names.
+func (r *Repo) Resolve(rev string) (string, error) {
+	out, err := r.git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Show returns the content of the file at path, relative to the top
+// level, in commit rev.
+func (r *Repo) Show(rev, path string) (string, error) {
+	return r.git("show", rev+":"+path)
+}
+
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.Dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// CreateMessage formats the commit message for a newly created note.
+func CreateMessage(identifier, path string) string {
+	return fmt.Sprintf("create %s: %s", identifier, filepath.Base(path))
+}
+
+// RenameMessage formats the commit message for a renamed note.
+func RenameMessage(identifier, oldPath, newPath string) string {
+	return fmt.Sprintf("rename %s: %s -> %s", identifier, filepath.Base(oldPath), filepath.Base(newPath))
+}
+
+// DeleteMessage formats the commit message for a deleted note.
+func DeleteMessage(identifier, path string) string {
+	return fmt.Sprintf("delete %s: %s", identifier, filepath.Base(path))
+}
+
+// UpdateMessage formats the commit message for a note whose content or
+// front matter changed without a rename.
+func UpdateMessage(identifier, path string) string {
+	return fmt.Sprintf("update %s: %s", identifier, filepath.Base(path))
+}
+
+// RestoreMessage formats the commit message for a note brought back from
+// the trash.
+func RestoreMessage(identifier, path string) string {
+	return fmt.Sprintf("restore %s: %s", identifier, filepath.Base(path))
+}
@@ -0,0 +1,75 @@
+package vcs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitOnlyPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	unrelated := write("unrelated.txt")
+	note := write("20240101T120000--note.md")
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.git("add", "--", unrelated); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Commit("create note", note); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err := r.git("show", "--name-only", "--format=", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(committed); len(got) != 1 || got[0] != filepath.Base(note) {
+		t.Errorf("committed %v, want only %s", got, filepath.Base(note))
+	}
+	staged, err := r.git("diff", "--cached", "--name-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(staged) != "unrelated.txt" {
+		t.Errorf("staged = %q, want unrelated.txt still staged", staged)
+	}
+
+	draft := write("20240101T130000--draft.md")
+	for path, want := range map[string]bool{note: true, draft: false} {
+		if got, err := r.Tracked(path); err != nil || got != want {
+			t.Errorf("Tracked(%s) = %v, %v, want %v", filepath.Base(path), got, err, want)
+		}
+	}
+
+	// Nothing left to commit for the note.
+	if err := r.Commit("update note", note); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := r.git("rev-list", "--count", "HEAD"); strings.TrimSpace(out) != "1" {
+		t.Errorf("commits = %s, want 1", strings.TrimSpace(out))
+	}
+}
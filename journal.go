@@ -0,0 +1,246 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/importer"
+	"denote/pkg/journal"
+	"denote/pkg/metadata"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const streakWname = "+Streak"
+
+// journalTag marks journal entries, as Djournal creates them.
+const journalTag = "journal"
+
+// cmdJournal runs a journal subcommand.
+func cmdJournal(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: Denote journal streak|import")
+	}
+	switch args[0] {
+	case "import":
+		return cmdJournalImport(args[1:])
+	case "streak":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: Denote journal streak")
+		}
+		streak, err := readStreak()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(formatStreak(streak, time.Now())))
+		return nil
+	default:
+		return fmt.Errorf("unknown journal command: %s", args[0])
+	}
+}
+
+// readStreak computes the streak of the journal from the identifiers of
+// the notes tagged journal.
+func readStreak() (journal.Streak, error) {
+	rs, err := search("tag:" + journalTag)
+	if err != nil {
+		return journal.Streak{}, err
+	}
+	var times []time.Time
+	for _, n := range rs {
+		if !slices.Contains(n.Tags, journalTag) {
+			continue // tag: matches anywhere in a tag
+		}
+		if t, err := time.ParseInLocation("20060102T150405", n.Identifier, time.Local); err == nil {
+			times = append(times, t)
+		}
+	}
+	return journal.Stats(times, time.Now()), nil
+}
+
+// formatStreak describes streak, listing the missing days of the month
+// one per line so that they can be right-clicked to backfill them.
+func formatStreak(streak journal.Streak, now time.Time) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "current streak: %d days\n", streak.Current)
+	fmt.Fprintf(&buf, "longest streak: %d days\n", streak.Longest)
+	fmt.Fprintf(&buf, "%s: %d of %d days\n", now.Format("January 2006"), streak.Written, streak.Days)
+	if len(streak.Missing) > 0 {
+		fmt.Fprintf(&buf, "\nmissing:\n")
+		for _, d := range streak.Missing {
+			fmt.Fprintf(&buf, "%s\n", d.Format("2006-01-02 Mon"))
+		}
+	}
+	return []byte(buf.String())
+}
+
+// openStreakWindow shows the journal streak in a +Streak window.
+// Right-clicking a missing day opens a journal entry for it.
+func openStreakWindow() {
+	w := acme.Show(wname + streakWname)
+	if w != nil {
+		refreshStreak(w)
+		return
+	}
+	w, err := acme.New()
+	if err != nil {
+		logging.Errorf("failed to open streak window: %v", err)
+		return
+	}
+	w.Name(wname + streakWname)
+	w.Write("tag", []byte("Get"))
+	refreshStreak(w)
+
+	go func() {
+		defer w.CloseFiles()
+		for e := range w.EventChan() {
+			switch e.C2 {
+			case 'x', 'X':
+				if string(e.Text) == "Get" {
+					refreshStreak(w)
+				} else {
+					w.WriteEvent(e)
+				}
+			case 'l', 'L':
+				day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(string(e.Text)), time.Local)
+				if err != nil {
+					w.WriteEvent(e)
+					break
+				}
+				if err := backfillJournal(day); err != nil {
+					notifyError("failed to open journal entry: %v", err)
+				}
+				refreshStreak(w)
+			default:
+				w.WriteEvent(e)
+			}
+		}
+	}()
+}
+
+func refreshStreak(w *acme.Win) {
+	streak, err := readStreak()
+	if err != nil {
+		logging.Errorf("failed to read journal streak: %v", err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", formatStreak(streak, time.Now()))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// backfillJournal opens the journal entry for day, creating it if
+// needed, by running Djournal with the offset of day from today.
+func backfillJournal(day time.Time) error {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(today.Sub(day).Hours()/24 + 0.5)
+	if out, err := exec.Command("Djournal", fmt.Sprintf("-%dd", days)).CombinedOutput(); err != nil {
+		return fmt.Errorf("Djournal: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// journalTitle titles a journal entry written at t the way Djournal
+// does, according to config.JournalTitleFormat.
+func journalTitle(t time.Time) string {
+	switch config.JournalTitleFormat {
+	case "date":
+		return t.Format("Monday 2 January 2006")
+	case "day":
+		return t.Format("Monday")
+	case "year":
+		return t.Format("2006")
+	default:
+		return t.Format("Monday 2 January 2006 15:04")
+	}
+}
+
+// cmdJournalImport converts the entries of a jrnl or Day One export into
+// journal entries in the journal subdirectory, one note per entry,
+// identified by the entry's time. Entries whose time is already the
+// identifier of a journal entry are skipped, so an export can be imported
+// again after adding to it.
+func cmdJournalImport(args []string) error {
+	fs := flag.NewFlagSet("journal import", flag.ContinueOnError)
+	format := fs.String("format", "jrnl", "export format: jrnl or dayone")
+	ftype := fs.String("type", config.DefaultFileType, "file type: org, md-yaml, md-toml, or txt")
+	encrypt := fs.Bool("encrypt", false, "encrypt the imported entries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: Denote journal import [-format jrnl|dayone] [-type md-yaml] [-encrypt] <file>")
+	}
+	fileType := metadata.FileType(*ftype)
+	if metadata.GetExtension(fileType) == "" {
+		return fmt.Errorf("unknown file type: %s", *ftype)
+	}
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entries, err := importer.ReadJournal(in, *format, time.Local)
+	in.Close()
+	if err != nil {
+		return err
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		if err := setFilter(f, ""); err != nil {
+			return err
+		}
+		rs, err := readIndex(f)
+		if err != nil {
+			return err
+		}
+		ids := importer.Identifiers{}
+		imported := make(map[string]bool)
+		for _, r := range rs {
+			ids[r.Identifier] = true
+			if slices.Contains(r.Tags, journalTag) {
+				imported[r.Identifier] = true
+			}
+		}
+		journalDir := filepath.Join(dir, journalTag)
+		if err := os.MkdirAll(journalDir, 0755); err != nil {
+			return err
+		}
+
+		added, skipped := 0, 0
+		for _, e := range entries {
+			if imported[e.Date.Format("20060102T150405")] {
+				skipped++
+				continue
+			}
+			path, err := importer.WriteEncrypted(journalDir, e.Note([]string{journalTag}, journalTitle), fileType, ids, encryptNew(dir, *encrypt))
+			if err != nil {
+				return err
+			}
+			fmt.Println(path)
+			runNewHook(f, path)
+			added++
+		}
+		fmt.Printf("imported %d entries, skipped %d already imported\n", added, skipped)
+		if added == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/lock"
+	"denote/pkg/metadata"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// lockHolder names this user and host in note locks.
+func lockHolder() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return user + "@" + host
+}
+
+// lockNote takes the advisory lock on the note with identifier id as it
+// is opened, warning if someone else holds it.
+func lockNote(id string) {
+	if !metadata.IsIdentifier(id) {
+		logging.Warnf("not locking %q: not an identifier", id)
+		return
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return
+	}
+	l, err := lock.Acquire(dir, id, lockHolder())
+	switch {
+	case errors.Is(err, lock.ErrHeld):
+		notifyError("%s is open by %s since %s", id, l.Holder, l.Since.Format("2006-01-02 15:04"))
+	case err != nil:
+		logging.Warnf("failed to lock %s: %v", id, err)
+	}
+}
+
+// noteWindow returns the acme window showing path, if there is one.
+func noteWindow(path string) (acme.WinInfo, bool) {
+	wins, err := acme.Windows()
+	if err != nil {
+		return acme.WinInfo{}, false
+	}
+	for _, w := range wins {
+		if w.Name == path {
+			return w, true
+		}
+	}
+	return acme.WinInfo{}, false
+}
+
+// noteLock returns the lock on the note with identifier id at path, or
+// nil. This user's lock on a note no longer open in acme is stale and is
+// released instead.
+func noteLock(dir, id, path string) *lock.Lock {
+	l, err := lock.Get(dir, id)
+	if err != nil {
+		logging.Warnf("failed to read lock of %s: %v", id, err)
+		return nil
+	}
+	if l == nil || l.Holder != lockHolder() {
+		return l
+	}
+	if _, open := noteWindow(path); open {
+		return l
+	}
+	if err := lock.Release(dir, id, l.Holder); err != nil {
+		logging.Warnf("failed to release lock of %s: %v", id, err)
+	}
+	return nil
+}
+
+// warnLocked warns before the note with identifier id at path is renamed
+// or deleted if someone else holds its lock.
+func warnLocked(dir, id, path string) {
+	if l := noteLock(dir, id, path); l != nil && l.Holder != lockHolder() {
+		notifyError("%s is open by %s since %s", id, l.Holder, l.Since.Format("2006-01-02 15:04"))
+	}
+}
+
+// checkUnsaved fails if path has unsaved changes in an acme window.
+// Renaming or deleting the file underneath would leave the window
+// pointing at a file that no longer exists, and a later Put would
+// recreate it.
+func checkUnsaved(path string) error {
+	if w, open := noteWindow(path); open && w.IsModified {
+		return fmt.Errorf("%s has unsaved changes in acme window %d; Put or Get it first", path, w.ID)
+	}
+	return nil
+}
+
+// reloadNoteWindow makes an acme window showing oldPath show newPath, as
+// it is now on disk, after the file was renamed or its front matter
+// rewritten. Windows with unsaved changes are left alone, with a warning,
+// so that the changes are not lost.
+func reloadNoteWindow(oldPath, newPath string) {
+	info, open := noteWindow(oldPath)
+	if !open {
+		return
+	}
+	if info.IsModified {
+		notifyError("%s changed on disk but has unsaved changes in acme window %d", newPath, info.ID)
+		return
+	}
+	w, err := acme.Open(info.ID, nil)
+	if err != nil {
+		logging.Warnf("failed to open window %d: %v", info.ID, err)
+		return
+	}
+	defer w.CloseFiles()
+	if newPath != oldPath {
+		w.Name(newPath)
+	}
+	if config.CryptExt != "" && strings.HasSuffix(newPath, config.CryptExt) {
+		// The window is one of openEncrypted's, showing the note
+		// decrypted: get would load the ciphertext into it. A rename
+		// leaves the content as it was.
+		if newPath == oldPath {
+			if err := loadDecrypted(w, newPath); err != nil {
+				logging.Warnf("failed to reload window %d: %v", info.ID, err)
+			}
+		}
+		return
+	}
+	if err := w.Ctl("get"); err != nil {
+		logging.Warnf("failed to reload window %d: %v", info.ID, err)
+	}
+}
+
+// cmdLocks lists the advisory locks on notes: who has which note open,
+// and since when.
+func cmdLocks(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote locks")
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		locks, err := lock.List(dir)
+		if err != nil {
+			return err
+		}
+		for _, l := range locks {
+			path, _ := p9client.ReadFile(f, "n/"+l.Identifier+"/path")
+			if noteLock(dir, l.Identifier, path) != nil {
+				fmt.Println(l)
+			}
+		}
+		return nil
+	})
+}
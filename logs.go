@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"denote/internal/logging"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func logPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acme-denote", "denote.log"), nil
+}
+
+// openLog starts logging to the log file, including debug messages if
+// verbose is set. Without a log file, messages still go to standard
+// error.
+func openLog(verbose bool) {
+	if verbose {
+		logging.SetLevel(logging.Debug)
+	}
+	path, err := logPath()
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = logging.Open(path)
+		}
+	}
+	if err != nil {
+		logging.Warnf("failed to open log file: %v", err)
+	}
+}
+
+// cmdLog prints the last lines of the log file.
+func cmdLog(args []string) error {
+	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+	n := fs.Int("n", 50, "number of lines to print (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: Denote log [-n lines]")
+	}
+	path, err := logPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if *n > 0 {
+		lines := bytes.SplitAfter(data, []byte("\n"))
+		if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > *n {
+			data = bytes.Join(lines[len(lines)-*n:], nil)
+		}
+	}
+	os.Stdout.Write(data)
+	return nil
+}
@@ -1,31 +1,117 @@
 package main
 
 import (
+	"denote/internal/logging"
 	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
 	"denote/pkg/encoding/results"
 	"denote/pkg/metadata"
+	"denote/pkg/metrics"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"9fans.net/go/acme"
 	"9fans.net/go/plan9/client"
 )
 
-const wname = "/Denote/"
+// wname is the name of the /Denote/ window, and the prefix of the names
+// of the windows Denote opens beside it, such as /Denote/+Tree. Services
+// other than p9client.DefaultService have their own, /Denote/<service>/,
+// so that an instance of Denote can run for each.
+var wname = "/Denote/"
 
-// readIndex reads and parses the index from 9P server.
+// readIndex reads and parses the index from 9P server. Index entries
+// carry no path: callers that open a note read its n/<id>/path file, so a
+// refresh costs one read however many notes it shows.
 func readIndex(f *client.Fsys) (metadata.Results, error) {
-	indexContent, err := p9client.ReadFile(f, "index")
+	rs, _, err := readIndexSeq(f)
+	return rs, err
+}
+
+// readIndexSeq is readIndex also returning the sequence number of the
+// index read, or -1 for servers without a seq file. An index that changed
+// while it was read is read again, as it may mix two versions.
+func readIndexSeq(f *client.Fsys) (metadata.Results, int, error) {
+	if err := checkIndexVersion(f); err != nil {
+		return nil, 0, err
+	}
+	indexContent, seq, err := p9client.ReadStable(f, "index", "seq")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read index: %w", err)
+	}
+	n, err := strconv.Atoi(seq)
+	if err != nil {
+		n = -1
+	}
+	rs, err := results.Unmarshal([]byte(indexContent))
+	return rs, n, err
+}
+
+// fullIndex caches the unfiltered index together with its sequence
+// number, for servers that report one, so that it can be brought up to
+// date from the changes since instead of being read again.
+var fullIndex struct {
+	dir string
+	seq int
+	rs  metadata.Results // nil if not cached
+}
+
+// readFullIndex returns the unfiltered index of dir. The filter must
+// already be cleared. The caller holds indexMu.
+func readFullIndex(f *client.Fsys, dir string) (metadata.Results, error) {
+	if fullIndex.rs != nil && fullIndex.dir == dir {
+		if c, err := readChanges(f, fullIndex.seq); err == nil {
+			fullIndex.seq, fullIndex.rs = c.Seq, c.Apply(fullIndex.rs)
+			return slices.Clone(fullIndex.rs), nil
+		}
+		fullIndex.rs = nil
+	}
+	rs, seq, err := readIndexSeq(f)
+	if err != nil {
+		return nil, err
+	}
+	if seq >= 0 {
+		fullIndex.dir, fullIndex.seq, fullIndex.rs = dir, seq, slices.Clone(rs)
+	}
+	return rs, nil
+}
+
+// readChanges asks the server for the index changes since seq. Servers
+// without a seq file do not support this.
+func readChanges(f *client.Fsys, seq int) (*results.Changes, error) {
+	if err := p9client.WriteFile(f, "ctl", "since "+strconv.Itoa(seq)); err != nil {
+		return nil, err
+	}
+	data, err := p9client.ReadFile(f, "changes")
+	if err != nil {
+		return nil, err
+	}
+	return results.UnmarshalChanges([]byte(data))
+}
+
+// checkIndexVersion fails if the server writes a newer index format than
+// this client reads. Servers without a version file write version 1.
+func checkIndexVersion(f *client.Fsys) error {
+	v, err := p9client.ReadFile(f, "version")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read index: %w", err)
+		return nil
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid index format version %q", v)
+	}
+	if version > results.FormatVersion {
+		return fmt.Errorf("index format version %d is newer than the supported version %d; update Denote", version, results.FormatVersion)
 	}
-	return results.Unmarshal([]byte(indexContent))
+	return nil
 }
 
 // setFilter sets or clears the filter on the 9P server.
@@ -37,40 +123,83 @@ func setFilter(f *client.Fsys, filterQuery string) error {
 	return p9client.WriteFile(f, "ctl", cmd)
 }
 
+// loadConfig (re)reads the config file and applies the settings that are
+// consumed by library packages.
+func loadConfig() {
+	config.Lock()
+	defer config.Unlock()
+	if err := config.Load(); err != nil {
+		logging.Warnf("failed to load config: %v", err)
+	}
+	metadata.DefaultScanOptions = metadata.ScanOptions{
+		MaxDepth:     config.ScanDepth,
+		Include:      config.ScanInclude,
+		Exclude:      config.ScanExclude,
+		Strict:       config.ScanStrict,
+		SkipSymlinks: config.ScanSymlinks == "skip",
+	}
+	metadata.SortTags = config.SortTags
+}
+
 func main() {
 	var err error
 	var w *acme.Win
-	args := os.Args[1:]
-	if len(args) == 1 {
-		if identifier, ok := strings.CutPrefix(args[0], "denote:"); ok {
-			// Plumb the identifier directly (plumbing rules handle the mount)
-			if err := exec.Command("plumb", "denote:"+identifier).Run(); err != nil {
-				log.Fatalf("failed to plumb identifier: %v", err)
+	loadConfig()
+	dirFlag := flag.String("dir", "", "denote directory (default $DENOTE_DIR or the dir setting)")
+	verbose := flag.Bool("v", false, "log debug messages")
+	service := flag.String("service", "", "denote service name, e.g. denote.work (default $DENOTE_SERVICE or denote)")
+	flag.Usage = printUsage
+	flag.Parse()
+	openLog(*verbose)
+	if *service != "" {
+		if !p9client.ValidService(*service) {
+			log.Fatalf("invalid service name: %s", *service)
+		}
+		// Exported so that denotesrv, the scripts, and hooks use it too.
+		p9client.Service = *service
+		os.Setenv("DENOTE_SERVICE", *service)
+	}
+	if p9client.Service != p9client.DefaultService {
+		wname += p9client.Service + "/"
+	}
+	args := flag.Args()
+	if len(args) > 0 {
+		if identifier, ok := strings.CutPrefix(args[0], "denote:"); ok && len(args) == 1 {
+			// Plumb the identifier directly (plumbing rules handle the
+			// mount); encrypted notes are decrypted into a window served
+			// by this process until it is deleted.
+			if err := openNote(resolveAlias(identifier)); err != nil {
+				log.Fatalf("failed to open note: %v", err)
 			}
 			return
 		}
-		fmt.Println("Usage: Denote [denote:<identifier>]")
+		if *dirFlag != "" {
+			if err := connect(*dirFlag); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := runCommand(args); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
 
 	// Connect to denotesrv, auto-starting if needed
-	if err := p9client.With9P(func(f *client.Fsys) error {
-		return nil
-	}); err != nil {
-		cmd := exec.Command("denotesrv", "start")
-		if err := cmd.Run(); err != nil {
-			log.Fatalf("failed to start denotesrv: %v", err)
-		}
-		for i := 0; i < 10; i++ {
-			if err := p9client.With9P(func(f *client.Fsys) error {
-				return nil
-			}); err == nil {
-				break
-			}
-			if i == 9 {
-				log.Fatal("denotesrv failed to start")
-			}
-		}
+	if err := connect(*dirFlag); err != nil {
+		log.Fatal(err)
+	}
+
+	// A Denote process already drives the /Denote/ window: show it
+	// rather than competing for its events.
+	if pid, ok := runningInstance(); ok {
+		acme.Show(wname)
+		logging.Infof("Denote is already running (pid %d)", pid)
+		return
+	}
+	if release, err := claimInstance(); err != nil {
+		logging.Errorf("failed to record instance: %v", err)
+	} else {
+		defer release()
 	}
 
 	// open window - look for existing /Denote/ window
@@ -94,136 +223,66 @@ func main() {
 	}
 	defer w.CloseFiles()
 
-	if _, err = w.Write("tag", []byte("New Put Remove Get")); err != nil {
-		w.Del(true)
-		log.Fatal(err)
-	}
+	startHTTP()
+	startPutWatcher()
 
-	// get initial results
-	var rs metadata.Results
-	err = p9client.With9P(func(f *client.Fsys) error {
-		if err := setFilter(f, ""); err != nil {
-			return err
-		}
-		rs, err = readIndex(f)
-		return err
-	})
+	// get initial results, restoring the previous session's view
+	st := loadState()
+	history.queries = st.History
+	rs, err := search(st.Query)
 	if err != nil {
-		log.Fatal(err)
+		logging.Warnf("failed to restore query %q: %v", st.Query, err)
+		st = viewState{}
+		if rs, err = search(""); err != nil {
+			log.Fatal(err)
+		}
 	}
-	metadata.Sort(rs, metadata.SortById, metadata.SortOrderDesc)
-	refreshWindow(w, rs)
+	iw := &indexWindow{win: w, name: wname, query: st.Query, main: true}
+	iw.show(rs)
+	warnDuplicates()
 
 	w.Ctl("clean")
-	w.Addr("#0")
+	if err := w.Addr("#%d", st.Dot); err != nil {
+		w.Addr("#0")
+	}
 	w.Ctl("dot=addr")
 	w.Ctl("show")
 
-	// event loop
-	for e := range w.EventChan() {
-		switch e.C2 {
-		case 'x', 'X':
-			switch string(e.Text) {
-			case "New":
-				input := strings.TrimSpace(string(e.Arg))
-				if input == "" {
-					break
-				}
-				if err := p9client.With9P(func(f *client.Fsys) error {
-					return p9client.WriteFile(f, "new", input)
-				}); err != nil {
-					log.Printf("failed to create note: %v", err)
-				}
-				refreshWindowWithDefaults(w)
-				w.Addr("#0")
-				w.Ctl("dot=addr")
-				w.Ctl("show")
-			case "Remove":
-				input := strings.TrimSpace(string(e.Arg))
-				if input == "" {
-					break
-				}
-				w.Ctl("addr=dot")
-				q0, q1, _ := w.ReadAddr()
-				if err := p9client.With9P(func(f *client.Fsys) error {
-					return p9client.WriteFile(f, filepath.Join("n", input, "ctl"), "d")
-				}); err != nil {
-					log.Printf("failed to delete file: %v", err)
-				}
-				refreshWindowWithDefaults(w)
-				w.Addr("#%d,#%d", q0, q1)
-				w.Ctl("dot=addr")
-				w.Ctl("show")
-			case "Look":
-				performSearch(w, string(e.Arg))
-				w.Addr("#0")
-				w.Ctl("dot=addr")
-				w.Ctl("show")
-			case "Get":
-				refreshWindowWithDefaults(w)
-				w.Addr("#0")
-				w.Ctl("dot=addr")
-				w.Ctl("show")
-			case "Put":
-				body, err := w.ReadAll("body")
-				if err != nil {
-					log.Printf("failed to read window body: %v", err)
-					break
-				}
-				entries, err := results.UnmarshalStrict(body)
-				if err != nil {
-					log.Printf("failed to parse window: %v", err)
-					break
-				}
-				if err := p9client.With9P(func(f *client.Fsys) error {
-					for _, e := range entries {
-						if err := p9client.WriteFile(f, "n/"+e.Identifier+"/title", e.Title); err != nil {
-							return err
-						}
-						if err := p9client.WriteFile(f, "n/"+e.Identifier+"/keywords", strings.Join(e.Tags, ",")); err != nil {
-							return err
-						}
-					}
-					return nil
-				}); err != nil {
-					log.Printf("failed to apply changes: %v", err)
-				}
-			default:
-				w.WriteEvent(e)
-			}
-		case 'l', 'L':
-			text := string(e.Text)
-			if isIdentifier(text) {
-				if err := exec.Command("plumb", "denote:"+text).Run(); err != nil {
-					log.Printf("failed to plumb identifier: %v", err)
-				}
-			} else {
-				w.WriteEvent(e)
-			}
-		default:
-			w.WriteEvent(e)
-		}
-	}
+	notifyAcme = true
+	iw.loop()
 }
 
-func performSearch(w *acme.Win, searchText string) {
+// search filters the index by searchText and sorts the results according
+// to any sort:<field>[,asc] argument. sort:relevance ranks by how well
+// each note matches the filters; sort:frequency and sort:lastopened by
+// how often and how recently notes were opened. body:<words> arguments are answered by
+// the full-text index rather than the server's filter, and enc: and
+// !enc: keep only encrypted or unencrypted notes. Encrypted notes are
+// marked with the enc pseudo-tag. due: and overdue: arguments are
+// answered from the notes' due front matter field (see
+// metadata.DueFilter).
+func search(searchText string) (metadata.Results, error) {
+	defer metrics.Since("denote_search_seconds", time.Now())
+	metrics.Add("denote_searches_total", 1)
 	args := parseArgs(searchText)
-	var filterArgs []string
-	sortBy := metadata.SortById
-	sortOrder := metadata.SortOrderDesc
+	var filterArgs, bodyArgs []string
+	var encFilter string
+	var dueFilters []func(*metadata.Metadata) bool
+	sortBy, sortOrder := parseSort(config.DefaultSort)
 
 	for _, arg := range args {
 		if sortSpec, ok := strings.CutPrefix(arg, "sort:"); ok {
-			parts := strings.Split(sortSpec, ",")
-			switch parts[0] {
-			case "id", "date":
-				sortBy = metadata.SortById
-			case "title":
-				sortBy = metadata.SortByTitle
-			}
-			if len(parts) > 1 && parts[1] == "asc" {
-				sortOrder = metadata.SortOrderAsc
+			sortBy, sortOrder = parseSort(sortSpec)
+		} else if words, ok := strings.CutPrefix(arg, "body:"); ok {
+			bodyArgs = append(bodyArgs, strings.Trim(words, `"'`))
+		} else if arg == "enc:" || arg == "!enc:" {
+			encFilter = arg
+		} else if strings.HasPrefix(arg, "due:") || arg == "overdue:" {
+			match, err := metadata.DueFilter(arg, time.Now())
+			if err != nil {
+				return nil, err
 			}
+			dueFilters = append(dueFilters, match)
 		} else {
 			filterArgs = append(filterArgs, arg)
 		}
@@ -231,44 +290,186 @@ func performSearch(w *acme.Win, searchText string) {
 
 	filterQuery := strings.Join(filterArgs, " ")
 	var rs metadata.Results
+	var dir, seq string
+	indexMu.Lock()
 	err := p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		if dir, err = readDenoteDir(f); err != nil {
+			return err
+		}
+		seq, _ = p9client.ReadFile(f, "seq")
 		if err := setFilter(f, filterQuery); err != nil {
 			return err
 		}
-		var err error
-		rs, err = readIndex(f)
+		if filterQuery == "" {
+			rs, err = readFullIndex(f, dir)
+		} else {
+			rs, err = readIndex(f)
+		}
 		return err
 	})
+	indexMu.Unlock()
 	if err != nil {
-		log.Printf("search error: %v", err)
-		return
+		return nil, err
+	}
+	if filterQuery == "" {
+		metrics.Set("denote_notes_indexed", float64(len(rs)))
 	}
-	metadata.Sort(rs, sortBy, sortOrder)
-	refreshWindow(w, rs)
+
+	if encFilter != "" || config.MarkEncrypted {
+		enc := encryptedNotes(dir, seq)
+		if encFilter != "" {
+			rs = slices.DeleteFunc(rs, func(n *metadata.Metadata) bool {
+				return enc[n.Identifier] == (encFilter == "!enc:")
+			})
+		}
+		if config.MarkEncrypted {
+			rs = markEncrypted(rs, enc)
+		}
+	}
+
+	if len(dueFilters) > 0 {
+		if rs, err = readDue(dir, rs); err != nil {
+			return nil, err
+		}
+		rs = slices.DeleteFunc(rs, func(n *metadata.Metadata) bool {
+			return slices.ContainsFunc(dueFilters, func(match func(*metadata.Metadata) bool) bool { return !match(n) })
+		})
+	}
+
+	var bodyScores map[string]float64
+	if len(bodyArgs) > 0 {
+		if bodyScores, err = searchBodies(dir, strings.Join(bodyArgs, " ")); err != nil {
+			return nil, err
+		}
+		rs = slices.DeleteFunc(rs, func(n *metadata.Metadata) bool {
+			_, ok := bodyScores[n.Identifier]
+			return !ok
+		})
+	}
+
+	if sortBy == metadata.SortByRelevance {
+		fs, err := metadata.Filters{}.Parse(filterArgs)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Rank(rs, func(n *metadata.Metadata) float64 {
+			return fs.Score(n) + bodyScores[n.Identifier]
+		})
+	} else if sortBy == metadata.SortByFrequency || sortBy == metadata.SortByLastOpened {
+		if err := sortByOpens(rs, sortBy, sortOrder); err != nil {
+			return nil, err
+		}
+	} else {
+		metadata.Sort(rs, sortBy, sortOrder)
+	}
+	logging.Debugf("search %q: %d notes", searchText, len(rs))
+	return rs, nil
 }
 
-func refreshWindow(w *acme.Win, rs metadata.Results) {
-	w.Addr(",")
-	w.Write("data", results.Marshal(rs))
-	w.Ctl("show")
+// parseSort parses the <field>[,asc] part of a sort: argument. Unknown
+// fields sort by identifier; the order is descending unless asc is given.
+func parseSort(spec string) (metadata.SortBy, metadata.SortOrder) {
+	sortBy := metadata.SortById
+	sortOrder := metadata.SortOrderDesc
+	parts := strings.Split(spec, ",")
+	switch parts[0] {
+	case "id", "date":
+		sortBy = metadata.SortById
+	case "title":
+		sortBy = metadata.SortByTitle
+	case "tags":
+		sortBy = metadata.SortByTags
+	case "relevance":
+		sortBy = metadata.SortByRelevance
+	case "frequency":
+		sortBy = metadata.SortByFrequency
+	case "lastopened":
+		sortBy = metadata.SortByLastOpened
+	}
+	if len(parts) > 1 && parts[1] == "asc" {
+		sortOrder = metadata.SortOrderAsc
+	}
+	return sortBy, sortOrder
 }
 
-func refreshWindowWithDefaults(w *acme.Win) {
-	var rs metadata.Results
-	err := p9client.With9P(func(f *client.Fsys) error {
-		if err := setFilter(f, ""); err != nil {
-			return err
+// viewStatus summarizes a view of n notes selected by query, e.g.
+// "12 notes (filter: tag:journal !tag:draft, sort: title asc)".
+func viewStatus(n int, query string) string {
+	var filterArgs []string
+	sortBy, sortOrder := parseSort(config.DefaultSort)
+	for _, arg := range parseArgs(query) {
+		if spec, ok := strings.CutPrefix(arg, "sort:"); ok {
+			sortBy, sortOrder = parseSort(spec)
+		} else {
+			filterArgs = append(filterArgs, arg)
 		}
-		var err error
-		rs, err = readIndex(f)
-		return err
-	})
+	}
+	order := "desc"
+	if sortOrder == metadata.SortOrderAsc {
+		order = "asc"
+	}
+	status := fmt.Sprintf("%d notes (", n)
+	if n == 1 {
+		status = "1 note ("
+	}
+	if len(filterArgs) > 0 {
+		status += "filter: " + strings.Join(filterArgs, " ") + ", "
+	}
+	if sortBy == metadata.SortByRelevance {
+		return status + "sort: relevance)"
+	}
+	return status + fmt.Sprintf("sort: %s %s)", sortBy, order)
+}
+
+var sortArgPattern = regexp.MustCompile(`(^|\s)sort:\S*`)
+
+// toggleSort returns query sorted by field: in the opposite order if
+// query already sorts by field, otherwise in field's usual order.
+// Identifiers sort newest first; other fields alphabetically.
+func toggleSort(query string, field metadata.SortBy) string {
+	sortBy, sortOrder := parseSort(config.DefaultSort)
+	for _, arg := range parseArgs(query) {
+		if spec, ok := strings.CutPrefix(arg, "sort:"); ok {
+			sortBy, sortOrder = parseSort(spec)
+		}
+	}
+	asc := field != metadata.SortById
+	if sortBy == field {
+		asc = sortOrder == metadata.SortOrderDesc
+	}
+	spec := "sort:" + string(field)
+	if asc {
+		spec += ",asc"
+	}
+	query = strings.TrimSpace(sortArgPattern.ReplaceAllString(query, ""))
+	return strings.TrimSpace(query + " " + spec)
+}
+
+// notePaths returns the paths of the notes in dir by identifier. The
+// index carries no file names, so dir is scanned for them.
+func notePaths(dir string) (map[string]string, error) {
+	notes, err := metadata.ScanDir(dir)
 	if err != nil {
-		log.Printf("error refreshing: %v", err)
-		return
+		return nil, err
+	}
+	paths := make(map[string]string, len(notes))
+	for _, n := range notes {
+		paths[n.Identifier] = n.Path
 	}
-	metadata.Sort(rs, metadata.SortById, metadata.SortOrderDesc)
-	refreshWindow(w, rs)
+	return paths, nil
+}
+
+// refreshWindow writes rs to the index window w, with section headers if
+// sections is set.
+func refreshWindow(w *acme.Win, rs metadata.Results, sections bool) {
+	data := results.Marshal
+	if sections {
+		data = results.MarshalSections
+	}
+	w.Addr(",")
+	w.Write("data", append([]byte(results.Header+"\n"), data(rs)...))
+	w.Ctl("show")
 }
 
 func parseArgs(s string) []string {
@@ -312,8 +513,16 @@ func parseArgs(s string) []string {
 	return args
 }
 
-var identifierPattern = regexp.MustCompile(`^\d{8}T\d{6}$`)
-
-func isIdentifier(s string) bool {
-	return identifierPattern.MatchString(s)
+// plumbIdentifier opens the note named by text if it is an identifier,
+// reporting whether it was.
+func plumbIdentifier(text string) bool {
+	if !metadata.IsIdentifier(text) {
+		return false
+	}
+	go func() {
+		if err := openNote(resolveAlias(text)); err != nil {
+			logging.Errorf("failed to open note: %v", err)
+		}
+	}()
+	return true
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/encoding/results"
+	"denote/pkg/fulltext"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"9fans.net/go/acme"
+)
+
+const (
+	matchesWname = "+Search"
+	grepWname    = "+Grep"
+)
+
+// matchesPerNote bounds the snippets listed for a single note.
+const matchesPerNote = 3
+
+// searchMatches runs query, which must have a body: argument, and returns
+// the lines of the matching notes that contain a searched word, notes in
+// the order search returns them.
+func searchMatches(query string) ([]results.Match, error) {
+	var words []string
+	for _, arg := range parseArgs(query) {
+		if w, ok := strings.CutPrefix(arg, "body:"); ok {
+			words = append(words, strings.Trim(w, `"'`))
+		}
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no body: words to show matches for: %s", query)
+	}
+	rs, err := search(query)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := notePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []results.Match
+	for _, n := range rs {
+		content, err := os.ReadFile(paths[n.Identifier])
+		if err != nil {
+			continue
+		}
+		for _, s := range fulltext.Snippets(string(content), strings.Join(words, " "), matchesPerNote) {
+			ms = append(ms, results.Match{Identifier: n.Identifier, Title: n.Title, Line: s.Line, Snippet: s.Text})
+		}
+	}
+	return ms, nil
+}
+
+// grepNotes returns the lines matching the regular expression pattern
+// in the text notes matching query, a Look query, so that a grep can be
+// restricted to the notes of the current view.
+func grepNotes(pattern, query string) ([]results.Match, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := search(query)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := notePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []results.Match
+	for _, n := range rs {
+		path := paths[n.Identifier]
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".org", ".txt":
+		default:
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, s := range fulltext.Grep(string(content), re) {
+			ms = append(ms, results.Match{Identifier: n.Identifier, Title: n.Title, Line: s.Line, Snippet: s.Text})
+		}
+	}
+	return ms, nil
+}
+
+// cmdGrep prints the lines matching a regular expression in the notes
+// matching a Look query, or in every note.
+func cmdGrep(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: Denote grep <regexp> [filter...]")
+	}
+	ms, err := grepNotes(args[0], quoteArgs(args[1:]))
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(results.MarshalMatches(ms))
+	return nil
+}
+
+// matchesRuns holds the function that lists the matches of each window
+// opened by openMatchesWindow, by window name.
+var matchesRuns sync.Map
+
+// openMatchesWindow shows the matches run lists in the window name, one
+// per line. Right-clicking a line opens its note at that line; Get runs
+// the search again.
+func openMatchesWindow(name string, run func() ([]results.Match, error)) {
+	matchesRuns.Store(name, run)
+	w := acme.Show(name)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open %s: %v", name, err)
+			return
+		}
+		w.Name(name)
+		w.Write("tag", []byte("Get"))
+		go matchesLoop(w, name)
+	}
+	refreshMatches(w, name)
+}
+
+func matchesLoop(w *acme.Win, name string) {
+	defer w.CloseFiles()
+	for e := range w.EventChan() {
+		switch e.C2 {
+		case 'x', 'X':
+			if string(e.Text) == "Get" {
+				refreshMatches(w, name)
+			} else {
+				w.WriteEvent(e)
+			}
+		case 'l', 'L':
+			if plumbIdentifier(string(e.Text)) {
+				break
+			}
+			body, err := w.ReadAll("body")
+			if err != nil {
+				w.WriteEvent(e)
+				break
+			}
+			m, err := results.UnmarshalMatch(lineAt(body, e.Q0))
+			if err != nil {
+				w.WriteEvent(e)
+				break
+			}
+			if err := openMatch(m); err != nil {
+				notifyError("failed to open %s: %v", m.Identifier, err)
+			}
+		default:
+			w.WriteEvent(e)
+		}
+	}
+}
+
+func refreshMatches(w *acme.Win, name string) {
+	run, ok := matchesRuns.Load(name)
+	if !ok {
+		return
+	}
+	ms, err := run.(func() ([]results.Match, error))()
+	if err != nil {
+		notifyError("%s: %v", name, err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", results.MarshalMatches(ms))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// lineAt returns the line of body holding the rune at offset q.
+func lineAt(body []byte, q int) string {
+	runes := []rune(string(body))
+	start, end := lineBounds(runes, q)
+	return string(runes[start:end])
+}
+
+// lineBounds returns the rune offsets of the start and end of the line of
+// runes holding offset q.
+func lineBounds(runes []rune, q int) (int, int) {
+	q = min(max(q, 0), len(runes))
+	start, end := q, q
+	for start > 0 && runes[start-1] != '\n' {
+		start--
+	}
+	for end < len(runes) && runes[end] != '\n' {
+		end++
+	}
+	return start, end
+}
+
+// openMatch plumbs the file of the note of m with its line as address,
+// which acme opens at that line.
+func openMatch(m results.Match) error {
+	path := resolveNotePath(m.Identifier)
+	if path == "" {
+		return fmt.Errorf("no note %s", m.Identifier)
+	}
+	lockNote(m.Identifier)
+	recordOpen(m.Identifier)
+	return exec.Command("plumb", fmt.Sprintf("%s:%d", path, m.Line)).Run()
+}
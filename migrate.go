@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+const migrateUsage = "usage: Denote migrate-frontmatter [-n] -from <type> -to <type> [filters]"
+
+// cmdMigrateFrontMatter rewrites the front matter of the notes matching
+// the filters, as in Look, from one file type to another, e.g. "Denote
+// migrate-frontmatter -from md-toml -to md-yaml tag:old". Every field and
+// the body are kept. Only types sharing an extension can be migrated in
+// place, since a note's file name is not changed; notes whose front
+// matter is not of the -from type are left alone.
+func cmdMigrateFrontMatter(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-frontmatter", flag.ContinueOnError)
+	from := fs.String("from", "", "convert front matter of file `type` md-yaml, md-toml, org, or txt")
+	to := fs.String("to", "", "to front matter of file `type`")
+	dryRun := fs.Bool("n", false, "print the notes that would be migrated without changing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fromType, toType := metadata.FileType(*from), metadata.FileType(*to)
+	ext := metadata.GetExtension(fromType)
+	if ext == "" || metadata.GetExtension(toType) == "" || fromType == toType {
+		return fmt.Errorf(migrateUsage)
+	}
+	if metadata.GetExtension(toType) != ext {
+		return fmt.Errorf("cannot migrate %s to %s in place: the file extension would change", fromType, toType)
+	}
+
+	rs, err := search(quoteArgs(fs.Args()))
+	if err != nil {
+		return err
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		paths, err := notePaths(dir)
+		if err != nil {
+			return err
+		}
+		migrated, failed := 0, 0
+		for _, r := range rs {
+			if ctx.Err() != nil {
+				break
+			}
+			path := paths[r.Identifier]
+			if path == "" || !strings.EqualFold(filepath.Ext(path), ext) {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				failed++
+				continue
+			}
+			converted, err := frontmatter.Convert(string(content), fromType, toType)
+			if err != nil {
+				// Not front matter of the -from type.
+				continue
+			}
+			fmt.Println(path)
+			if *dryRun {
+				continue
+			}
+			warnLocked(dir, r.Identifier, path)
+			if err := checkUnsaved(path); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed++
+				continue
+			}
+			snapshotNote(r.Identifier, path, "migrate")
+			if err := util.WriteFile(path, []byte(converted)); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				failed++
+				continue
+			}
+			reloadNoteWindow(path, path)
+			autoCommit(f, vcs.UpdateMessage(r.Identifier, path), path)
+			runHook(hookUpdate, r.Identifier, "", path)
+			migrated++
+		}
+		if ctx.Err() != nil {
+			if migrated > 0 {
+				if err := reloadIndex(f, dir); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("interrupted: migrated %d, failed %d", migrated, failed)
+		}
+		if failed > 0 {
+			return fmt.Errorf("migrated %d, failed %d", migrated, failed)
+		}
+		if migrated == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/config"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"9fans.net/go/acme"
+)
+
+// notifyAcme is set once the /Denote/ window is running, so that errors
+// from its event loop also reach the +Errors window.
+var notifyAcme bool
+
+// errorsDir is the denote directory whose +Errors window receives errors.
+var errorsDir = sync.OnceValues(denoteDir)
+
+// notifyError reports a failure of an operation that has no caller to
+// return it to, such as a command run from the Denote window. Besides
+// the log, the message goes to the +Errors window of the denote
+// directory (config.NotifyErrors) and to config.NotifyCommand.
+func notifyError(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	logging.Errorf("%s", msg)
+	if notifyAcme && config.NotifyErrors {
+		if dir, err := errorsDir(); err == nil {
+			acme.Err(dir+"/", "Denote: "+msg)
+		}
+	}
+	if config.NotifyCommand != "" {
+		cmd := exec.Command("sh", "-c", config.NotifyCommand+` "$1"`, "sh", msg)
+		if err := cmd.Start(); err != nil {
+			logging.Errorf("failed to run notify command: %v", err)
+			return
+		}
+		go cmd.Wait()
+	}
+}
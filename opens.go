@@ -0,0 +1,50 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/metadata"
+	"denote/pkg/opens"
+	"slices"
+	"time"
+)
+
+// recordOpen logs that the note with identifier id was opened, for
+// sort:frequency and sort:lastopened. Failures are only logged: they must
+// not keep the note from opening.
+func recordOpen(id string) {
+	path, err := opens.Path()
+	if err == nil {
+		err = opens.Record(path, id, time.Now())
+	}
+	if err != nil {
+		logging.Warnf("failed to record opening %s: %v", id, err)
+	}
+}
+
+// sortByOpens orders rs by how often (sort:frequency) or how recently
+// (sort:lastopened) their notes were opened, most first unless order is
+// ascending. Notes never opened come last, newest first among equals.
+func sortByOpens(rs metadata.Results, sortBy metadata.SortBy, order metadata.SortOrder) error {
+	path, err := opens.Path()
+	if err != nil {
+		return err
+	}
+	stats, err := opens.Load(path)
+	if err != nil {
+		return err
+	}
+	metadata.Rank(rs, func(n *metadata.Metadata) float64 {
+		s := stats[n.Identifier]
+		if sortBy == metadata.SortByFrequency {
+			return float64(s.Count)
+		}
+		if s.Count == 0 {
+			return 0
+		}
+		return float64(s.Last.Unix())
+	})
+	if order == metadata.SortOrderAsc {
+		slices.Reverse(rs)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"denote/pkg/encoding/results"
+	"denote/pkg/metadata"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// cmdOrphans lists notes that neither link to nor are linked from any
+// other note and have not been modified for a while, in the index
+// format, so that they can be opened from acme.
+func cmdOrphans(args []string) error {
+	fs := flag.NewFlagSet("orphans", flag.ContinueOnError)
+	days := fs.Int("days", 90, "only list notes unmodified for `n` days")
+	exclude := fs.String("x", "", "skip notes with any of these comma-separated `tags`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: Denote orphans [-days n] [-x tag,...]")
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return err
+	}
+	opts := metadata.OrphanOptions{MinAge: time.Duration(*days) * 24 * time.Hour}
+	if *exclude != "" {
+		opts.ExcludeTags = strings.Split(*exclude, ",")
+	}
+	orphans := metadata.Orphans(notes, opts, time.Now())
+
+	// Show titles as the index has them rather than as slugs.
+	if index, err := search(""); err == nil {
+		byID := make(map[string]*metadata.Metadata, len(index))
+		for _, n := range index {
+			byID[n.Identifier] = n
+		}
+		for i, n := range orphans {
+			if md, ok := byID[n.Identifier]; ok {
+				orphans[i] = md
+			}
+		}
+	}
+	os.Stdout.Write(results.Marshal(orphans))
+	return nil
+}
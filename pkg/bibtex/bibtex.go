@@ -0,0 +1,230 @@
+// Package bibtex reads entries from BibTeX files for citing works in
+// notes and creating reference notes.
+package bibtex
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Entry is a single BibTeX entry. Field names are lowercase.
+type Entry struct {
+	Type   string
+	Key    string
+	Fields map[string]string
+}
+
+// Title returns the entry title with BibTeX braces removed.
+func (e Entry) Title() string {
+	return clean(e.Fields["title"])
+}
+
+// Author returns the entry authors with BibTeX braces removed.
+func (e Entry) Author() string {
+	return clean(e.Fields["author"])
+}
+
+// Year returns the publication year.
+func (e Entry) Year() string {
+	if y := e.Fields["year"]; y != "" {
+		return clean(y)
+	}
+	if d := e.Fields["date"]; len(d) >= 4 {
+		return d[:4]
+	}
+	return ""
+}
+
+func clean(s string) string {
+	s = strings.NewReplacer("{", "", "}", "", "\\&", "&").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ReadFile parses every entry in the BibTeX file at path.
+func ReadFile(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(data))
+}
+
+// Parse parses BibTeX source. @comment, @preamble, and @string blocks
+// are skipped; string macros are not expanded.
+func Parse(src string) ([]Entry, error) {
+	p := &parser{src: src}
+	var entries []Entry
+	for {
+		at := strings.IndexByte(p.src[p.pos:], '@')
+		if at < 0 {
+			return entries, nil
+		}
+		p.pos += at + 1
+		typ := strings.ToLower(p.ident())
+		p.skipSpace()
+		if p.pos >= len(p.src) || (p.src[p.pos] != '{' && p.src[p.pos] != '(') {
+			continue // stray '@' in a comment
+		}
+		if typ == "comment" || typ == "preamble" || typ == "string" {
+			if _, err := p.delimited(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e, err := p.entry(typ)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("bibtex: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ',' || c == '=' || c == '{' || c == '}' || c == '(' || c == ')' || c == '"' || unicode.IsSpace(rune(c)) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// delimited consumes a balanced {...}, (...), or "..." group and returns
+// its contents.
+func (p *parser) delimited() (string, error) {
+	open := p.src[p.pos]
+	close := map[byte]byte{'{': '}', '(': ')', '"': '"'}[open]
+	start := p.pos + 1
+	depth := 0
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case open == '"' && c == '{':
+			depth++
+		case open == '"' && c == '}':
+			depth--
+		case open == '"' && c == '"' && depth == 0:
+			p.pos++
+			return p.src[start : p.pos-1], nil
+		case open != '"' && c == open:
+			depth++
+		case open != '"' && c == close:
+			if depth == 0 {
+				p.pos++
+				return p.src[start : p.pos-1], nil
+			}
+			depth--
+		}
+	}
+	return "", p.errorf("unterminated %c", open)
+}
+
+func (p *parser) entry(typ string) (Entry, error) {
+	close := map[byte]byte{'{': '}', '(': ')'}[p.src[p.pos]]
+	p.pos++
+	p.skipSpace()
+	e := Entry{Type: typ, Key: p.ident(), Fields: map[string]string{}}
+	if e.Key == "" {
+		return e, p.errorf("entry without key")
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return e, p.errorf("unterminated entry %s", e.Key)
+		}
+		switch p.src[p.pos] {
+		case ',':
+			p.pos++
+			continue
+		case close:
+			p.pos++
+			return e, nil
+		}
+
+		name := strings.ToLower(p.ident())
+		p.skipSpace()
+		if name == "" || p.pos >= len(p.src) || p.src[p.pos] != '=' {
+			return e, p.errorf("expected field in entry %s", e.Key)
+		}
+		p.pos++
+		value, err := p.value()
+		if err != nil {
+			return e, err
+		}
+		e.Fields[name] = value
+	}
+}
+
+// value parses a field value: delimited strings, bare words, or numbers,
+// concatenated with '#'.
+func (p *parser) value() (string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return "", p.errorf("missing value")
+		}
+		if c := p.src[p.pos]; c == '{' || c == '"' {
+			s, err := p.delimited()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		} else {
+			parts = append(parts, p.ident())
+		}
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '#' {
+			p.pos++
+			continue
+		}
+		return strings.Join(parts, ""), nil
+	}
+}
+
+// Search returns the entries whose key, title, or author contain every
+// word of query, ignoring case. An entry whose key equals query exactly
+// is returned alone.
+func Search(entries []Entry, query string) []Entry {
+	for _, e := range entries {
+		if strings.EqualFold(e.Key, query) {
+			return []Entry{e}
+		}
+	}
+	words := strings.Fields(strings.ToLower(query))
+	var matches []Entry
+	for _, e := range entries {
+		hay := strings.ToLower(e.Key + " " + e.Title() + " " + e.Author())
+		ok := true
+		for _, w := range words {
+			if !strings.Contains(hay, w) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
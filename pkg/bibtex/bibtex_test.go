@@ -0,0 +1,99 @@
+package bibtex
+
+import "testing"
+
+const sample = `
+@comment{ this file is managed by hand }
+@string{ acm = "ACM" }
+
+@book{knuth1984,
+  author    = {Donald E. Knuth},
+  title     = {The {\TeX}book},
+  year      = 1984,
+  publisher = "Addison-Wesley",
+}
+
+@Article(pike2000,
+  Author = "Rob Pike",
+  Title = {Systems Software Research is Irrelevant},
+  date = {2000-02-21}
+)
+
+@inproceedings{ritchie1974, title = "The {UNIX} " # {Time-Sharing System}, author = {Ritchie, Dennis and Thompson, Ken}}
+`
+
+func TestParse(t *testing.T) {
+	entries, err := Parse(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Parse() returned %d entries, want 3", len(entries))
+	}
+
+	tests := []struct {
+		key, typ, title, author, year string
+	}{
+		{"knuth1984", "book", `The \TeXbook`, "Donald E. Knuth", "1984"},
+		{"pike2000", "article", "Systems Software Research is Irrelevant", "Rob Pike", "2000"},
+		{"ritchie1974", "inproceedings", "The UNIX Time-Sharing System", "Ritchie, Dennis and Thompson, Ken", ""},
+	}
+	for i, tt := range tests {
+		e := entries[i]
+		if e.Key != tt.key || e.Type != tt.typ {
+			t.Errorf("entry %d = %s/%s, want %s/%s", i, e.Type, e.Key, tt.typ, tt.key)
+		}
+		if e.Title() != tt.title {
+			t.Errorf("%s Title() = %q, want %q", e.Key, e.Title(), tt.title)
+		}
+		if e.Author() != tt.author {
+			t.Errorf("%s Author() = %q, want %q", e.Key, e.Author(), tt.author)
+		}
+		if e.Year() != tt.year {
+			t.Errorf("%s Year() = %q, want %q", e.Key, e.Year(), tt.year)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"@book{key, title = {unterminated}",
+		"@book{, title = {x}}",
+		"@book{key, title {x}}",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", src)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	entries, _ := Parse(sample)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"knuth1984", []string{"knuth1984"}},
+		{"pike", []string{"pike2000"}},
+		{"unix thompson", []string{"ritchie1974"}},
+		{"e", []string{"knuth1984", "pike2000", "ritchie1974"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		got := Search(entries, tt.query)
+		var keys []string
+		for _, e := range got {
+			keys = append(keys, e.Key)
+		}
+		if len(keys) != len(tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, keys, tt.want)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tt.want[i] {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, keys, tt.want)
+			}
+		}
+	}
+}
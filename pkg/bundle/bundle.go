@@ -0,0 +1,242 @@
+// Package bundle gathers a subset of the denote directory, with the
+// notes it links to and the files it references, into an archive that
+// can be handed to someone else.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of the manifest file at the root of a bundle.
+const ManifestName = "MANIFEST"
+
+// Entry is one file in a bundle.
+type Entry struct {
+	Path       string // relative to the denote directory
+	Name       string // in the archive, slash-separated
+	Identifier string // of the note, or of the note referencing an attachment
+	Title      string // empty for attachments
+	Reason     string // why the file is in the bundle
+}
+
+// Bundle is a set of files from a denote directory.
+type Bundle struct {
+	Dir     string
+	Entries []Entry
+
+	// Convert, if set, rewrites the content of each entry as it is
+	// written, e.g. to encrypt or decrypt it.
+	Convert func(e Entry, content []byte) ([]byte, error)
+}
+
+var (
+	mdLinkRe  = regexp.MustCompile(`\]\(<?([^)<>\s]+)>?\)`)
+	orgLinkRe = regexp.MustCompile(`\[\[(?:file:)?([^\]]+)\]`)
+)
+
+// Links returns the identifiers of the notes content links to with
+// denote: links, in order of first appearance.
+func Links(content []byte) []string {
+	var ids []string
+	for _, m := range metadata.LinkRe.FindAllSubmatch(content, -1) {
+		if id := string(m[1]); !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Attachments returns the local file targets of the markdown and org
+// links in content, in order of first appearance. URLs and denote:
+// links are not attachments.
+func Attachments(content []byte) []string {
+	var targets []string
+	for _, re := range []*regexp.Regexp{mdLinkRe, orgLinkRe} {
+		for _, m := range re.FindAllSubmatch(content, -1) {
+			target, _, _ := strings.Cut(string(m[1]), "#")
+			target, _, _ = strings.Cut(target, "::")
+			if target == "" || strings.Contains(target, ":") || slices.Contains(targets, target) {
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// Collect returns the bundle of the notes matching ids, every note they
+// link to, transitively, and the attachments of all of them. notes are
+// the notes of dir, e.g. from metadata.ScanDir. Attachments outside dir
+// are left out.
+func Collect(dir string, ids []string, notes metadata.Results) (*Bundle, error) {
+	byID := make(map[string]*metadata.Metadata, len(notes))
+	for _, n := range notes {
+		byID[n.Identifier] = n
+	}
+
+	b := &Bundle{Dir: dir}
+	seen := map[string]bool{}
+	add := func(path, id, title, reason string) bool {
+		if !util.Within(dir, path) {
+			return false
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || seen[rel] {
+			return false
+		}
+		seen[rel] = true
+		b.Entries = append(b.Entries, Entry{Path: rel, Name: filepath.ToSlash(rel), Identifier: id, Title: title, Reason: reason})
+		return true
+	}
+
+	type item struct{ id, reason string }
+	var queue []item
+	for _, id := range ids {
+		queue = append(queue, item{id, "match"})
+	}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		n, ok := byID[it.id]
+		if !ok || !add(n.Path, n.Identifier, n.Title, it.reason) {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(n.Path)) {
+		case ".md", ".org", ".txt":
+		default:
+			continue
+		}
+		content, err := os.ReadFile(n.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range Links(content) {
+			queue = append(queue, item{id, "linked from " + n.Identifier})
+		}
+		for _, target := range Attachments(content) {
+			path := target
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(n.Path), path)
+			}
+			if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			add(path, n.Identifier, "", "attachment of "+n.Identifier)
+		}
+	}
+	return b, nil
+}
+
+// Manifest lists the entries of b, one per line.
+// Format: path | identifier | title | reason
+func (b *Bundle) Manifest() []byte {
+	var buf strings.Builder
+	for _, e := range b.Entries {
+		fmt.Fprintf(&buf, "%s | %s | %s | %s\n", e.Name, e.Identifier, e.Title, e.Reason)
+	}
+	return []byte(buf.String())
+}
+
+// file is a member of an archive.
+type file struct {
+	name  string
+	mode  os.FileMode
+	size  int64
+	mtime time.Time
+}
+
+// WriteTar writes b and its manifest to w as a gzip-compressed tar
+// archive.
+func (b *Bundle) WriteTar(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := b.each(func(f file, r io.Reader) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    f.name,
+			Mode:    int64(f.mode),
+			Size:    f.size,
+			ModTime: f.mtime,
+		}); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, r)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteZip writes b and its manifest to w as a zip archive.
+func (b *Bundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := b.each(func(f file, r io.Reader) error {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: f.mtime}
+		hdr.SetMode(f.mode)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, r)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// each calls fn with the manifest and then with every entry.
+func (b *Bundle) each(fn func(f file, r io.Reader) error) error {
+	manifest := b.Manifest()
+	if err := fn(file{ManifestName, 0644, int64(len(manifest)), time.Now()}, bytes.NewReader(manifest)); err != nil {
+		return err
+	}
+	for _, e := range b.Entries {
+		if err := b.write(e, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write calls fn with e, converted by b.Convert if it is set.
+func (b *Bundle) write(e Entry, fn func(f file, r io.Reader) error) error {
+	f, err := os.Open(filepath.Join(b.Dir, e.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if b.Convert == nil {
+		return fn(file{e.Name, fi.Mode().Perm(), fi.Size(), fi.ModTime()}, f)
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if content, err = b.Convert(e, content); err != nil {
+		return fmt.Errorf("%s: %w", e.Path, err)
+	}
+	return fn(file{e.Name, fi.Mode().Perm(), int64(len(content)), fi.ModTime()}, bytes.NewReader(content))
+}
@@ -0,0 +1,128 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"denote/pkg/metadata"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	content := []byte("![fig](img/fig.png) [site](https://example.com) [n](denote:20240101T120000)\n" +
+		"[[file:data.csv]] [[./notes.pdf::3][p3]] [[denote:20240102T120000][x]] ![fig](img/fig.png#top)\n")
+	want := []string{"img/fig.png", "data.csv", "./notes.pdf"}
+	if got := Attachments(content); !slices.Equal(got, want) {
+		t.Errorf("Attachments() = %q, want %q", got, want)
+	}
+	if got, want := Links(content), []string{"20240101T120000", "20240102T120000"}; !slices.Equal(got, want) {
+		t.Errorf("Links() = %q, want %q", got, want)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	files := map[string]string{
+		"20240101T120000--project__project.md": "see denote:20240102T120000 and ![plan](attach/plan.png)\n",
+		"20240102T120000--design.md":           "back to denote:20240101T120000, on to [[denote:20240103T120000]]\n[x](" + outside + ")\n![leak](attach/leak.txt)\n",
+		"20240103T120000--details.org":         "[[file:missing.png]]\n",
+		"20240104T120000--unrelated.md":        "\n",
+		"attach/plan.png":                      "png",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(outside, []byte("x"), 0644)
+	// A symlink in dir must not pull in a file from outside it.
+	if err := os.Symlink(outside, filepath.Join(dir, "attach", "leak.txt")); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Collect(dir, []string{"20240101T120000"}, notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range b.Entries {
+		got = append(got, e.Path+" ("+e.Reason+")")
+	}
+	want := []string{
+		"20240101T120000--project__project.md (match)",
+		filepath.Join("attach", "plan.png") + " (attachment of 20240101T120000)",
+		"20240102T120000--design.md (linked from 20240101T120000)",
+		"20240103T120000--details.org (linked from 20240102T120000)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Collect() entries =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteTar(&buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if len(names) != 5 || names[0] != ManifestName {
+		t.Errorf("archive members = %q, want the manifest and 4 entries", names)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "20240101T120000--a.md"), []byte("plain"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := &Bundle{
+		Dir:     dir,
+		Entries: []Entry{{Path: "20240101T120000--a.md", Name: "20240101T120000--a.md.x", Reason: "match"}},
+		Convert: func(e Entry, content []byte) ([]byte, error) {
+			return bytes.ToUpper(content), nil
+		},
+	}
+	var buf bytes.Buffer
+	if err := b.WriteTar(&buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	tr.Next() // manifest
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(tr)
+	if hdr.Name != "20240101T120000--a.md.x" || string(content) != "PLAIN" {
+		t.Errorf("entry = %q %q, want %q %q", hdr.Name, content, "20240101T120000--a.md.x", "PLAIN")
+	}
+}
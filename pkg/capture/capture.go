@@ -0,0 +1,154 @@
+// Package capture implements the HTTP endpoint used by browser
+// bookmarklets and extensions to clip pages into denote notes.
+package capture
+
+import (
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Request is a capture submitted as JSON or as form values.
+type Request struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Body  string `json:"body"`
+	Tags  string `json:"tags"`
+	Token string `json:"token"`
+}
+
+// CreateFunc creates a note and returns its path.
+type CreateFunc func(importer.Note) (string, error)
+
+// SettingsFunc returns the capture settings in effect: the tags of
+// requests that have none, and the token requests must carry.
+type SettingsFunc func() (defaultTags []string, token string)
+
+// maxRequestBytes bounds the size of a capture request body.
+const maxRequestBytes = 8 << 20
+
+// Handler serves POST /capture. Notes are created with create. The
+// settings are read for each request: tags default to defaultTags when
+// the request has none, and if token is not empty, requests must carry
+// it in the X-Denote-Token header or the token field. Without a token,
+// any token the client sends is ignored: requests must be JSON and must
+// not come from another site, so that pages open in the browser cannot
+// post to the endpoint. Browsers send cross-site JSON only after a CORS
+// preflight, which is never granted.
+func Handler(create CreateFunc, settings SettingsFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/capture", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		defaultTags, token := settings()
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		req, isJSON, err := parseRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		authorized := token != "" && (req.Token == token || r.Header.Get("X-Denote-Token") == token)
+		switch {
+		case token != "" && !authorized:
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		case !authorized && !isJSON:
+			http.Error(w, "form captures require a token", http.StatusForbidden)
+			return
+		case !authorized && crossSite(r):
+			http.Error(w, "cross-site captures require a token", http.StatusForbidden)
+			return
+		}
+		n, err := req.Note(defaultTags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		path, err := create(n)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintln(w, path)
+	})
+	return mux
+}
+
+// parseRequest reads a capture from r and reports whether it was JSON.
+func parseRequest(r *http.Request) (Request, bool, error) {
+	var req Request
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, true, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return req, true, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return req, false, err
+	}
+	req.Title = r.PostForm.Get("title")
+	req.URL = r.PostForm.Get("url")
+	req.Body = r.PostForm.Get("body")
+	req.Tags = r.PostForm.Get("tags")
+	req.Token = r.PostForm.Get("token")
+	return req, false, nil
+}
+
+// crossSite reports whether the browser says r comes from a page of
+// another origin. Requests from curl and other tools carry neither
+// header.
+func crossSite(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+// Note converts the request into a note. Tags may be separated by commas
+// or spaces; the source URL heads the body.
+func (req Request) Note(defaultTags []string) (importer.Note, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = strings.TrimSpace(req.URL)
+	}
+	if title == "" {
+		return importer.Note{}, fmt.Errorf("title or url is required")
+	}
+
+	tags := strings.FieldsFunc(strings.ToLower(req.Tags), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(tags) == 0 {
+		tags = defaultTags
+	}
+	if invalid := metadata.ValidateTags(tags); len(invalid) > 0 {
+		return importer.Note{}, fmt.Errorf("invalid tags: %s", strings.Join(invalid, ", "))
+	}
+
+	var body strings.Builder
+	if req.URL != "" {
+		fmt.Fprintf(&body, "Source: <%s>\n\n", req.URL)
+	}
+	body.WriteString(strings.ReplaceAll(req.Body, "\r\n", "\n"))
+	if !strings.HasSuffix(body.String(), "\n") {
+		body.WriteString("\n")
+	}
+	return importer.Note{Date: time.Now(), Title: title, Tags: tags, Body: body.String()}, nil
+}
@@ -0,0 +1,194 @@
+package capture
+
+import (
+	"denote/pkg/importer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	var got importer.Note
+	create := func(n importer.Note) (string, error) {
+		got = n
+		return "/doc/note.md", nil
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		token       string
+		header      map[string]string
+		wantStatus  int
+		wantTitle   string
+		wantTags    []string
+	}{
+		{
+			name:        "form capture",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"title": {"Go Proverbs"}, "url": {"https://go-proverbs.github.io"}, "tags": {"go,reading"}, "token": {"secret"}}.Encode(),
+			token:       "secret",
+			header:      map[string]string{"Origin": "https://go-proverbs.github.io", "Sec-Fetch-Site": "cross-site"},
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "Go Proverbs",
+			wantTags:    []string{"go", "reading"},
+		},
+		{
+			name:        "form capture without token",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"title": {"x"}}.Encode(),
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "form capture with unconfigured token",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"title": {"x"}, "token": {"x"}}.Encode(),
+			header:      map[string]string{"X-Denote-Token": "x"},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "cross-site json with unconfigured token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x","token":"x"}`,
+			header:      map[string]string{"Origin": "https://evil.example", "Sec-Fetch-Site": "cross-site"},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "cross-site origin without token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x"}`,
+			header:      map[string]string{"Origin": "https://evil.example"},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "cross-site fetch without token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x"}`,
+			header:      map[string]string{"Sec-Fetch-Site": "cross-site"},
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "same origin without token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x"}`,
+			header:      map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"},
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "x",
+			wantTags:    []string{"clip"},
+		},
+		{
+			name:        "body too large",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x","body":"` + strings.Repeat("a", maxRequestBytes) + `"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "json capture with default tags",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"Page","body":"selected text"}`,
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "Page",
+			wantTags:    []string{"clip"},
+		},
+		{
+			name:        "url used as title",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"url":"https://example.com"}`,
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "https://example.com",
+			wantTags:    []string{"clip"},
+		},
+		{
+			name:        "missing title",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"body":"text"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "invalid tags",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x","tags":"a-b"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "get not allowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "missing token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x"}`,
+			token:       "secret",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "valid token",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        `{"title":"x","token":"secret"}`,
+			token:       "secret",
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "x",
+			wantTags:    []string{"clip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = importer.Note{}
+			h := Handler(create, func() ([]string, string) { return []string{"clip"}, tt.token })
+			req := httptest.NewRequest(tt.method, "/capture", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			if !slices.Equal(got.Tags, tt.wantTags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestRequestNoteBody(t *testing.T) {
+	n, err := Request{Title: "t", URL: "https://example.com", Body: "line\r\nline"}.Note(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Source: <https://example.com>\n\nline\nline\n"
+	if n.Body != want {
+		t.Errorf("Body = %q, want %q", n.Body, want)
+	}
+}
@@ -14,3 +14,307 @@ var DefaultDenoteDir = os.Getenv("HOME") + "/doc"
 // Examples of alternative configurations:
 // var DefaultDenoteDir = "/home/lkn/notes"
 // var DefaultDenoteDir = os.Getenv("DENOTE_DIR")
+
+// ============================================================
+// CONFIGURATION: Git Auto-Commit
+//
+// When true and the denote directory lives inside a git
+// working tree, Denote commits each rename, update, and
+// delete it performs with a structured message, e.g.
+// "rename 20251112T221141: old--name.md -> new--name.md".
+// Use `Denote history <identifier>` to view a note's log.
+// ============================================================
+var GitAutoCommit = false
+
+// ============================================================
+// CONFIGURATION: Snapshots
+//
+// When true, Denote saves the current version of a note to a
+// snapshot store in the user cache directory before it
+// rewrites the note: on Put, Ddate, conflict resolution, and
+// Dmerge. Use `Denote snapshots <identifier>` to list them and
+// `Denote restore <identifier> <snapshot>` to roll back.
+// Encrypted notes are never saved.
+// ============================================================
+var Snapshots = false
+
+// ============================================================
+// CONFIGURATION: Sync Command
+//
+// Shell command run in the denote directory when `Get` is
+// executed in the /Denote/ window, before the index is
+// reloaded from disk. Output is shown in the directory's
+// +Errors window. Leave empty to only reload the index.
+// ============================================================
+var SyncCommand = ""
+
+// Examples of alternative configurations:
+// var SyncCommand = "git pull --rebase"
+// var SyncCommand = "rclone sync remote:notes ."
+
+// ============================================================
+// CONFIGURATION: Mail Import Tags
+//
+// Tags given to notes created by `Denote import-mail` when no
+// -tags flag is passed.
+// ============================================================
+var MailImportTags = []string{"mail"}
+
+// ============================================================
+// CONFIGURATION: HTTP Endpoint
+//
+// Loopback address for the optional HTTP endpoint (POST
+// /capture for the web clipper, GET /feed for an Atom
+// feed). When set, the /Denote/
+// window serves it while open; `Denote serve` runs it on
+// its own. Leave empty to disable. If CaptureToken is set,
+// captures must carry it in the X-Denote-Token header or
+// a token field. Without a token, only same-site JSON
+// captures are accepted.
+// ============================================================
+var HTTPAddr = ""
+var CaptureToken = ""
+var CaptureTags = []string{"clip"}
+
+// Examples of alternative configurations:
+// var HTTPAddr = "127.0.0.1:8737"
+
+// ============================================================
+// CONFIGURATION: Browser
+//
+// Command used by `Dpreview -web` to open rendered notes.
+// When empty, $BROWSER is used, falling back to xdg-open
+// (open on macOS).
+// ============================================================
+var Browser = ""
+
+// ============================================================
+// CONFIGURATION: Spell Checking
+//
+// Command run by Dspell to check a note. It must speak the
+// ispell pipe protocol (-a), as aspell and hunspell do.
+// ============================================================
+var SpellCommand = "aspell -a"
+
+// Examples of alternative configurations:
+// var SpellCommand = "hunspell -a -d en_GB"
+// var SpellCommand = "aspell -a --lang=de"
+
+// ============================================================
+// CONFIGURATION: Bibliography
+//
+// BibTeX file searched by `Denote cite`, and the tags given
+// to reference notes created with `Denote cite -note`.
+// ============================================================
+var BibFile = ""
+var BibTags = []string{"bib"}
+
+// Examples of alternative configurations:
+// var BibFile = os.Getenv("HOME") + "/lib/references.bib"
+
+// ============================================================
+// CONFIGURATION: Query History
+//
+// Number of Look queries remembered for the `History` and
+// `Again` commands; at least 1.
+// ============================================================
+var HistorySize = 100
+
+// ============================================================
+// CONFIGURATION: Section Headers
+//
+// Head every letter of title-sorted index windows with a
+// "-- A --" line, for `Jump <letter>` to move to.
+// ============================================================
+var SectionHeaders = false
+
+// ============================================================
+// CONFIGURATION: Defaults for New Notes and Searches
+//
+// File type of notes created outside of acme (web clipper,
+// mail import): org, md-yaml, md-toml, or txt. DefaultSort
+// applies to queries without a sort: argument, e.g.
+// "title,asc" or "relevance".
+// ============================================================
+var DefaultFileType = "md-yaml"
+var DefaultSort = ""
+
+// ============================================================
+// CONFIGURATION: Tag Order
+//
+// Tags are always lowercased and deduplicated when Denote
+// writes a file name or front matter. With SortTags they are
+// also sorted alphabetically, so the same set of tags always
+// gives the same file name; otherwise they keep the order in
+// which they were given.
+// ============================================================
+var SortTags = false
+
+// ============================================================
+// CONFIGURATION: Known Tags
+//
+// An optional controlled vocabulary. When KnownTags is set,
+// a new note or retag that introduces another tag is handled
+// by TagPolicy: "warn" keeps it with a warning, "reject"
+// refuses the change, and "suggest" replaces it with the
+// closest known tag when there is one. Known tags are also
+// offered by `Denote complete tags` and the +filter window.
+// ============================================================
+var KnownTags []string
+var TagPolicy = "warn"
+
+// Examples of alternative configurations:
+// var KnownTags = []string{"journal", "meeting", "project"}
+
+// ============================================================
+// CONFIGURATION: Extensions
+//
+// Read by the rc extensions through `Denote config <key>`.
+// Silos are listed by Dsilo; the journal settings are used
+// by Djournal; CryptExt is the extension added to encrypted
+// notes; TemplateDir holds note templates.
+//
+// CryptRecipients picks the key a note is encrypted to by
+// where it lives: rules of the form dir=recipient, where dir
+// is absolute (a silo) or relative to the denote directory
+// (a subdirectory such as journal). The longest matching dir
+// wins; without a match gpg uses your default key.
+// ============================================================
+var Silos []string
+var JournalInterval = "daily"
+var JournalTitleFormat = "full"
+var JournalSignature = ""
+var CryptExt = ".gpg"
+var TemplateDir = ""
+var CryptRecipients []string
+
+// ============================================================
+// CONFIGURATION: Directory Scanning
+//
+// Limits on which files are treated as notes when Denote
+// walks the denote directory (tree view, full-text index).
+// ScanDepth 0 searches all subdirectories, 1 only the top
+// level. Globs without a slash match any path element
+// (e.g. "software"); with a slash they match the path
+// relative to the denote directory ("journal/2024").
+// ScanStrict accepts only names that follow the full Denote
+// scheme. ScanSymlinks is "follow" or "skip".
+// ============================================================
+var ScanDepth = 0
+var ScanInclude []string
+var ScanExclude []string
+var ScanStrict = false
+var ScanSymlinks = "follow"
+
+// ============================================================
+// CONFIGURATION: Error Notifications
+//
+// Failures of commands run from the Denote window (renames,
+// deletes, syncs, git commits, hooks) are logged, and with
+// NotifyErrors also shown in the +Errors window of the denote
+// directory. NotifyCommand, if set, is run through sh with the
+// message appended as an argument.
+// ============================================================
+var NotifyErrors = true
+var NotifyCommand = ""
+
+// Examples of alternative configurations:
+// var NotifyCommand = "notify-send Denote"
+
+// ============================================================
+// CONFIGURATION: Title Fallback
+//
+// Title shown for .md, .org, and .txt notes without a front
+// matter title: "" (none), "line" for the first non-empty
+// line, or "heading" for the first markdown or org heading.
+// ============================================================
+var TitleFallback = ""
+
+// ============================================================
+// CONFIGURATION: Encryption Policy
+//
+// Rules of the form dir:<dir> or tag:<tag> naming notes that
+// must be encrypted (CryptExt): those below the subdirectory
+// or with the tag. Notes Denote writes itself (captures, mail
+// and citation imports) are encrypted as they are created;
+// New warns; `Denote audit-encryption` lists the notes that
+// break the rules.
+//
+// MarkEncrypted shows encrypted notes with the enc pseudo-tag
+// in the index. Finding them takes a directory scan, redone
+// only when the index changes; turn it off for very large
+// directories. enc: and !enc: searches scan regardless.
+// ============================================================
+var EncryptPolicy []string
+var MarkEncrypted = true
+
+// Examples of alternative configurations:
+// var EncryptPolicy = []string{"dir:journal", "tag:private"}
+
+// ============================================================
+// CONFIGURATION: Subdirectory Routing
+//
+// Rules of the form tag=dir placing new notes with the tag in
+// a subdirectory of the denote directory, as if the title had
+// been written 'dir/:title'. The first rule matching one of a
+// note's tags applies; an explicit dir/: in the title wins.
+// ============================================================
+var Routes []string
+
+// Examples of alternative configurations:
+// var Routes = []string{"journal=journal", "work=projects/work"}
+
+// ============================================================
+// CONFIGURATION: Delete Confirmation
+//
+// With ConfirmDelete set, a Remove in the /Denote/ window only
+// asks for confirmation; the note is deleted if Remove is
+// executed again on it within that many seconds. 0 deletes at
+// once.
+// ============================================================
+var ConfirmDelete = 5
+
+// ============================================================
+// CONFIGURATION: Trash
+//
+// Notes deleted with Remove are kept in the trash,
+// $XDG_DATA_HOME/acme-denote/trash, and can be brought back
+// with `Denote trash restore`. Notes deleted more than
+// TrashRetentionDays days ago are purged automatically on the
+// next delete; 0 keeps them until `Denote trash purge`.
+// ============================================================
+var TrashRetentionDays = 30
+
+// ============================================================
+// CONFIGURATION: Window Tags
+//
+// Commands written to the tag of the /Denote/ window and of
+// Look+ query windows, after Look. TagCommands add commands of
+// the form Name=executable to both: executing Name runs the
+// executable with the identifiers of the selected notes on its
+// standard input, one per line.
+// ============================================================
+var WindowTag = "New Put Remove Get"
+var QueryWindowTag = "Put Remove Get"
+var TagCommands []string
+
+// Examples of alternative configurations:
+// var WindowTag = "New Put Remove Get Agenda Tree"
+// var TagCommands = []string{"Publish=/home/user/bin/publish"}
+
+// ============================================================
+// CONFIGURATION: Adopting Files on Put
+//
+// What the Denote window does when a text note (.md, .org,
+// .txt) whose name lacks a Denote identifier is Put in the
+// denote directory, e.g. one created with acme's New: "ask"
+// offers the Denote adopt-dir command for it in the +Errors
+// window, once per file; "on" adopts it at once, giving it an
+// identifier, a name by the convention, and front matter; and
+// "off" leaves it alone. Hidden and .denoteignore'd files are
+// never adopted.
+// ============================================================
+var AdoptOnPut = "ask"
+
+// Examples of alternative configurations:
+// var AdoptOnPut = "on"
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The variables above may be overridden without recompiling, first by
+// the config file (see Path) and then by environment variables. The file
+// holds key=value lines; blank lines and lines starting with # are
+// ignored, and values may be single-quoted. Lists are comma-separated:
+//
+//	dir=/home/user/notes
+//	silos=/home/user/notes,/home/user/work
+//	git-autocommit=true
+//	capture-tags=clip,web
+//
+// Each key can also be set with an environment variable named DENOTE_
+// followed by the key in upper case with - replaced by _, e.g. DENOTE_DIR
+// or DENOTE_GIT_AUTOCOMMIT.
+
+// setting binds a config key to one of the configuration variables.
+type setting struct {
+	key string
+	get func() string
+	set func(string) error
+}
+
+func stringSetting(key string, v *string) setting {
+	return setting{key, func() string { return *v }, func(s string) error { *v = s; return nil }}
+}
+
+func listSetting(key string, v *[]string) setting {
+	return setting{key, func() string { return strings.Join(*v, ",") }, func(s string) error {
+		*v = nil
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*v = append(*v, item)
+			}
+		}
+		return nil
+	}}
+}
+
+func boolSetting(key string, v *bool) setting {
+	return setting{key, func() string { return strconv.FormatBool(*v) }, func(s string) error {
+		switch s {
+		case "true", "on", "yes", "1":
+			*v = true
+		case "false", "off", "no", "0", "":
+			*v = false
+		default:
+			return fmt.Errorf("invalid boolean: %s", s)
+		}
+		return nil
+	}}
+}
+
+func intSetting(key string, v *int) setting {
+	return minIntSetting(key, v, math.MinInt)
+}
+
+// minIntSetting is an intSetting whose value must be at least lo.
+func minIntSetting(key string, v *int, lo int) setting {
+	return setting{key, func() string { return strconv.Itoa(*v) }, func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid number: %s", s)
+		}
+		if n < lo {
+			return fmt.Errorf("must be at least %d: %s", lo, s)
+		}
+		*v = n
+		return nil
+	}}
+}
+
+var settings = []setting{
+	stringSetting("dir", &DefaultDenoteDir),
+	listSetting("silos", &Silos),
+	stringSetting("filetype", &DefaultFileType),
+	stringSetting("sort", &DefaultSort),
+	boolSetting("sort-tags", &SortTags),
+	listSetting("known-tags", &KnownTags),
+	stringSetting("tag-policy", &TagPolicy),
+	boolSetting("git-autocommit", &GitAutoCommit),
+	boolSetting("snapshots", &Snapshots),
+	stringSetting("sync-command", &SyncCommand),
+	stringSetting("adopt-on-put", &AdoptOnPut),
+	listSetting("mail-tags", &MailImportTags),
+	stringSetting("http-addr", &HTTPAddr),
+	stringSetting("capture-token", &CaptureToken),
+	listSetting("capture-tags", &CaptureTags),
+	stringSetting("browser", &Browser),
+	stringSetting("spell-command", &SpellCommand),
+	stringSetting("bib-file", &BibFile),
+	listSetting("bib-tags", &BibTags),
+	minIntSetting("history-size", &HistorySize, 1),
+	boolSetting("section-headers", &SectionHeaders),
+	stringSetting("journal-interval", &JournalInterval),
+	stringSetting("journal-title-format", &JournalTitleFormat),
+	stringSetting("journal-signature", &JournalSignature),
+	stringSetting("crypt-ext", &CryptExt),
+	stringSetting("template-dir", &TemplateDir),
+	listSetting("crypt-recipients", &CryptRecipients),
+	intSetting("scan-depth", &ScanDepth),
+	listSetting("scan-include", &ScanInclude),
+	listSetting("scan-exclude", &ScanExclude),
+	boolSetting("scan-strict", &ScanStrict),
+	stringSetting("scan-symlinks", &ScanSymlinks),
+	stringSetting("title-fallback", &TitleFallback),
+	boolSetting("notify-errors", &NotifyErrors),
+	stringSetting("notify-command", &NotifyCommand),
+	listSetting("routes", &Routes),
+	listSetting("encrypt-policy", &EncryptPolicy),
+	boolSetting("mark-encrypted", &MarkEncrypted),
+	intSetting("confirm-delete", &ConfirmDelete),
+	stringSetting("window-tag", &WindowTag),
+	stringSetting("query-window-tag", &QueryWindowTag),
+	listSetting("tag-commands", &TagCommands),
+	intSetting("trash-retention", &TrashRetentionDays),
+}
+
+// defaults holds the compiled-in values so that Load can start afresh.
+var defaults = snapshot()
+
+func snapshot() map[string]string {
+	m := make(map[string]string, len(settings))
+	for _, s := range settings {
+		m[s.key] = s.get()
+	}
+	return m
+}
+
+func lookup(key string) (setting, bool) {
+	i := slices.IndexFunc(settings, func(s setting) bool { return s.key == key })
+	if i < 0 {
+		return setting{}, false
+	}
+	return settings[i], true
+}
+
+// Keys returns the names of all settings.
+func Keys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// Get returns the current value of key in config file syntax.
+func Get(key string) (string, bool) {
+	s, ok := lookup(key)
+	if !ok {
+		return "", false
+	}
+	return s.get(), true
+}
+
+// Set assigns value to key.
+func Set(key, value string) error {
+	s, ok := lookup(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	if err := s.set(value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// EnvVar returns the environment variable that overrides key.
+func EnvVar(key string) string {
+	return "DENOTE_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Path returns the config file location: $DENOTE_CONFIG if set, otherwise
+// acme-denote/config in the user config directory.
+func Path() string {
+	if p := os.Getenv("DENOTE_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "acme-denote", "config")
+}
+
+// mu guards the configuration variables while Load replaces them.
+var mu sync.RWMutex
+
+// Lock and Unlock guard a reload of the configuration: a program that
+// calls Load while other goroutines may read the variables holds Lock
+// around it. Those goroutines hold RLock while they run, so that they
+// never see a configuration half loaded.
+func Lock()    { mu.Lock() }
+func Unlock()  { mu.Unlock() }
+func RLock()   { mu.RLock() }
+func RUnlock() { mu.RUnlock() }
+
+// Load resets every setting to its compiled-in default, then applies the
+// config file, if any, and environment overrides. It is safe to call again
+// to pick up changes. If loading fails, the settings are left as they
+// were.
+func Load() error {
+	prev := snapshot()
+	if err := load(); err != nil {
+		for _, s := range settings {
+			s.set(prev[s.key])
+		}
+		return err
+	}
+	return nil
+}
+
+func load() error {
+	for _, s := range settings {
+		s.set(defaults[s.key])
+	}
+	if path := Path(); path != "" {
+		f, err := os.Open(path)
+		if err == nil {
+			err = Parse(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(EnvVar(s.key)); ok {
+			if err := Set(s.key, v); err != nil {
+				return fmt.Errorf("$%s: %w", EnvVar(s.key), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Parse applies the key=value lines read from r.
+func Parse(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key=value", n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		if err := Set(strings.TrimSpace(key), value); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// DenoteDir resolves the denote directory to start the server in: dir
+// (typically from a -dir flag) if set, otherwise $DENOTE_DIR, otherwise
+// the dir setting, which defaults to DefaultDenoteDir. A leading ~ is
+// expanded and the result is absolute.
+func DenoteDir(dir string) (string, error) {
+	if dir == "" {
+		dir = os.Getenv(EnvVar("dir"))
+	}
+	if dir == "" {
+		dir = DefaultDenoteDir
+	}
+	if rest, ok := strings.CutPrefix(dir, "~"); ok && (rest == "" || rest[0] == '/') {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = home + rest
+	}
+	return filepath.Abs(dir)
+}
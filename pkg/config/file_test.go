@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Cleanup(func() { Load() })
+	t.Setenv("DENOTE_CONFIG", filepath.Join(t.TempDir(), "none"))
+	input := `# comment
+dir = /tmp/notes
+silos=/tmp/notes, /tmp/work
+git-autocommit=on
+sync-command='git pull --rebase'
+journal-title-format='it''s'
+history-size=10
+`
+	if err := Parse(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if DefaultDenoteDir != "/tmp/notes" {
+		t.Errorf("DefaultDenoteDir = %q", DefaultDenoteDir)
+	}
+	if !slices.Equal(Silos, []string{"/tmp/notes", "/tmp/work"}) {
+		t.Errorf("Silos = %q", Silos)
+	}
+	if !GitAutoCommit {
+		t.Error("GitAutoCommit = false")
+	}
+	if SyncCommand != "git pull --rebase" {
+		t.Errorf("SyncCommand = %q", SyncCommand)
+	}
+	if JournalTitleFormat != "it's" {
+		t.Errorf("JournalTitleFormat = %q", JournalTitleFormat)
+	}
+	if HistorySize != 10 {
+		t.Errorf("HistorySize = %d", HistorySize)
+	}
+
+	for _, bad := range []string{"nokey", "unknown=1", "history-size=many", "history-size=0", "history-size=-1", "git-autocommit=maybe"} {
+		if err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Cleanup(func() { Load() })
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("browser=firefox\nsort=title,asc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DENOTE_CONFIG", path)
+	t.Setenv("DENOTE_SORT", "relevance")
+
+	SyncCommand = "stale"
+	if err := Load(); err != nil {
+		t.Fatal(err)
+	}
+	if Browser != "firefox" {
+		t.Errorf("Browser = %q, want firefox", Browser)
+	}
+	if DefaultSort != "relevance" {
+		t.Errorf("DefaultSort = %q, want environment override", DefaultSort)
+	}
+	if SyncCommand != "" {
+		t.Errorf("SyncCommand = %q, want default restored", SyncCommand)
+	}
+	if v, ok := Get("sort"); !ok || v != "relevance" {
+		t.Errorf("Get(sort) = %q, %v", v, ok)
+	}
+	if EnvVar("git-autocommit") != "DENOTE_GIT_AUTOCOMMIT" {
+		t.Errorf("EnvVar(git-autocommit) = %s", EnvVar("git-autocommit"))
+	}
+
+	// A failed reload keeps the settings in effect.
+	if err := os.WriteFile(path, []byte("browser=chromium\nhistory-size=many\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(); err == nil {
+		t.Fatal("Load() with an invalid value succeeded, want error")
+	}
+	if Browser != "firefox" || DefaultSort != "relevance" {
+		t.Errorf("Browser = %q, DefaultSort = %q after failed Load, want firefox, relevance", Browser, DefaultSort)
+	}
+}
+
+func TestDenoteDir(t *testing.T) {
+	t.Cleanup(func() { Load() })
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	DefaultDenoteDir = "/srv/notes"
+	t.Setenv("DENOTE_DIR", "")
+	tests := []struct {
+		flag, env, want string
+	}{
+		{"", "", "/srv/notes"},
+		{"", "/env/notes", "/env/notes"},
+		{"/flag/notes", "/env/notes", "/flag/notes"},
+		{"~/notes", "", filepath.Join(home, "notes")},
+		{"/a/../b", "", "/b"},
+	}
+	for _, tt := range tests {
+		os.Setenv("DENOTE_DIR", tt.env)
+		got, err := DenoteDir(tt.flag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("DenoteDir(%q) with DENOTE_DIR=%q = %q, want %q", tt.flag, tt.env, got, tt.want)
+		}
+	}
+}
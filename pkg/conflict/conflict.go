@@ -0,0 +1,132 @@
+// Package conflict detects conflicting copies left behind by file
+// synchronization tools (Syncthing, rclone, Dropbox, Nextcloud) and
+// resolves them against the note they conflict with.
+package conflict
+
+import (
+	"bytes"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/ignore"
+	"denote/pkg/util"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Conflict is a conflicting copy and the note it was copied from.
+type Conflict struct {
+	Path     string // the conflicting copy
+	Original string // the note it conflicts with (may no longer exist)
+}
+
+// markers match the part of a file name that sync tools insert to mark a
+// conflicting copy. Removing the match yields the original file name.
+var markers = []*regexp.Regexp{
+	// Syncthing: name.sync-conflict-20250101-120000-ABCDEFG.ext
+	regexp.MustCompile(`\.sync-conflict-\d{8}-\d{6}-[A-Z0-9]+`),
+	// rclone bisync: name.ext.conflict1 or name.conflict1.ext
+	regexp.MustCompile(`\.conflict\d+`),
+	// Dropbox / Nextcloud: name (user's conflicted copy 2025-01-01).ext
+	regexp.MustCompile(`(?i) \([^)]*conflicted copy[^)]*\)`),
+}
+
+// Original returns the original file name for a conflicting copy, and
+// false if name is not a conflicting copy.
+func Original(name string) (string, bool) {
+	for _, re := range markers {
+		if loc := re.FindStringIndex(name); loc != nil {
+			return name[:loc[0]] + name[loc[1]:], true
+		}
+	}
+	return "", false
+}
+
+// Find walks dir and returns every conflicting copy beneath it. Hidden
+// directories such as .git and .stversions are skipped, as are paths
+// listed in dir/.denoteignore.
+func Find(dir string) ([]Conflict, error) {
+	ign, err := ignore.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cs []Conflict
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if ign.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if orig, ok := Original(d.Name()); ok {
+			cs = append(cs, Conflict{Path: path, Original: filepath.Join(filepath.Dir(path), orig)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Delete discards the conflicting copy, keeping the original.
+func (c Conflict) Delete() error {
+	return os.Remove(c.Path)
+}
+
+// Take replaces the original with the conflicting copy.
+func (c Conflict) Take() error {
+	return util.Rename(c.Path, c.Original)
+}
+
+// Merge appends the body of the conflicting copy to the original under
+// an annotation, then deletes the copy. Identical copies are simply
+// deleted.
+func (c Conflict) Merge(annotation string) error {
+	orig, err := os.ReadFile(c.Original)
+	if err != nil {
+		return err
+	}
+	copied, err := os.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(orig, copied) {
+		return c.Delete()
+	}
+
+	body := string(copied)
+	if _, ft, err := frontmatter.Unmarshal(copied, filepath.Ext(c.Original)); err == nil && ft != "" {
+		body = util.StripFrontMatter(body, ft)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(orig)
+	if !bytes.HasSuffix(orig, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "\n* * *\n\n%s %s\n\n%s", annotation, filepath.Base(c.Path), body)
+
+	if err := util.WriteFile(c.Original, buf.Bytes()); err != nil {
+		return err
+	}
+	return c.Delete()
+}
@@ -0,0 +1,134 @@
+package conflict
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOriginal(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "syncthing",
+			input:  "20240101T120000--note__a.sync-conflict-20240102-101010-ABCDEF1.md",
+			want:   "20240101T120000--note__a.md",
+			wantOk: true,
+		},
+		{
+			name:   "rclone suffix",
+			input:  "20240101T120000--note.md.conflict1",
+			want:   "20240101T120000--note.md",
+			wantOk: true,
+		},
+		{
+			name:   "dropbox",
+			input:  "20240101T120000--note (lkn's conflicted copy 2024-01-02).md",
+			want:   "20240101T120000--note.md",
+			wantOk: true,
+		},
+		{
+			name:   "nextcloud",
+			input:  "20240101T120000--note (Conflicted Copy 2024-01-02 101010).org",
+			want:   "20240101T120000--note.org",
+			wantOk: true,
+		},
+		{
+			name:   "regular note",
+			input:  "20240101T120000--conflict-resolution__work.md",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Original(tt.input)
+			if ok != tt.wantOk {
+				t.Fatalf("Original(%q) ok = %v, want %v", tt.input, ok, tt.wantOk)
+			}
+			if got != tt.want {
+				t.Errorf("Original(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"20240101T120000--note.md",
+		"20240101T120000--note.sync-conflict-20240102-101010-ABCDEF1.md",
+		"sub/20240103T120000--other.org.conflict2",
+		".stversions/20240101T120000--note.sync-conflict-20240102-101010-ABCDEF1.md",
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cs, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 2 {
+		t.Fatalf("Find() returned %d conflicts, want 2: %v", len(cs), cs)
+	}
+	want := filepath.Join(dir, "sub", "20240103T120000--other.org")
+	if cs[1].Original != want {
+		t.Errorf("Find()[1].Original = %q, want %q", cs[1].Original, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	orig := filepath.Join(dir, "20240101T120000--note.md")
+	copied := filepath.Join(dir, "20240101T120000--note.sync-conflict-20240102-101010-ABCDEF1.md")
+	fm := "---\ntitle:      note\nidentifier: 20240101T120000\n---\n\n"
+	os.WriteFile(orig, []byte(fm+"original body\n"), 0600)
+	os.WriteFile(copied, []byte(fm+"edited elsewhere\n"), 0644)
+
+	c := Conflict{Path: copied, Original: orig}
+	if err := c.Merge("MERGED CONFLICT:"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Errorf("conflicting copy still exists after Merge")
+	}
+	got, _ := os.ReadFile(orig)
+	if strings.Count(string(got), "title:") != 1 {
+		t.Errorf("merged note should contain one front matter block:\n%s", got)
+	}
+	for _, want := range []string{"original body", "MERGED CONFLICT:", "edited elsewhere"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("merged note missing %q:\n%s", want, got)
+		}
+	}
+	if info, _ := os.Stat(orig); info.Mode().Perm() != 0600 {
+		t.Errorf("Merge changed permissions to %v", info.Mode().Perm())
+	}
+}
+
+func TestMergeIdentical(t *testing.T) {
+	dir := t.TempDir()
+	orig := filepath.Join(dir, "20240101T120000--note.md")
+	copied := orig + ".conflict1"
+	os.WriteFile(orig, []byte("same\n"), 0644)
+	os.WriteFile(copied, []byte("same\n"), 0644)
+
+	if err := (Conflict{Path: copied, Original: orig}).Merge("MERGED CONFLICT:"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(orig)
+	if string(got) != "same\n" {
+		t.Errorf("identical merge modified original: %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+// Package diff compares two versions of a note word by word, so that a
+// changed line shows which of its words changed rather than the whole
+// line twice. Lines are compared first; the words of each run of changed
+// lines are then compared and marked as [-removed-]{+added+}, as git's
+// --word-diff does.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op is an edit of a sequence: keep, delete, or insert an element.
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is an Op applied to one element of either sequence.
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Hunk is a run of changes with the unchanged lines around them.
+type Hunk struct {
+	Line int    // 1-based line of the hunk's first line in the new text
+	Text string // the lines, with changed words marked
+}
+
+// maxEdits bounds the edits Sequences looks for. Sequences that differ
+// more are given as a whole deletion and insertion, keeping time and
+// memory within bounds.
+const maxEdits = 2000
+
+// Sequences returns the shortest edit script turning a into b (Myers'
+// algorithm).
+func Sequences(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	off := n + m // offset of diagonal 0 in v
+	if off == 0 {
+		return nil
+	}
+	v := make([]int, 2*off+1)
+	// trace[d] holds diagonals -d..d of v before step d, all that
+	// backtrack needs of it.
+	var trace [][]int
+	for d := 0; d <= min(off, maxEdits); d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, d)
+			}
+		}
+	}
+	edits := make([]Edit, 0, off)
+	for _, s := range a {
+		edits = append(edits, Edit{Delete, s})
+	}
+	for _, s := range b {
+		edits = append(edits, Edit{Insert, s})
+	}
+	return edits
+}
+
+// backtrack walks the trace of Sequences back from the end of both
+// sequences to recover the edits.
+func backtrack(trace [][]int, a, b []string, d int) []Edit {
+	x, y := len(a), len(b)
+	var edits []Edit
+	for ; d > 0; d-- {
+		v := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v(k-1) < v(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Equal, a[x]})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Insert, b[y]})
+		} else {
+			x--
+			edits = append(edits, Edit{Delete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, Edit{Equal, a[x]})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// tokens splits s into words, runs of spaces, and single other runes, so
+// that joining them gives s back.
+func tokens(s string) []string {
+	var toks []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWord(runes[i]):
+			for j < len(runes) && isWord(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]) && runes[i] != '\n':
+			for j < len(runes) && unicode.IsSpace(runes[j]) && runes[j] != '\n' {
+				j++
+			}
+		}
+		toks = append(toks, string(runes[i:j]))
+		i = j
+	}
+	return toks
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '\''
+}
+
+// Words compares old and new text and returns the changed places, each
+// with up to context unchanged lines before and after. Hunks closer than
+// twice context lines are merged.
+func Words(old, new string, context int) []Hunk {
+	a := strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(new, "\n"), "\n")
+	edits := Sequences(a, b)
+
+	// Group the line edits into blocks: a run of equal lines, or a run
+	// of changed lines, which is word-diffed as a whole.
+	type block struct {
+		equal    bool
+		old, new []string
+		line     int // 1-based line of the block in new
+	}
+	var blocks []block
+	line := 1
+	for i := 0; i < len(edits); {
+		blk := block{equal: edits[i].Op == Equal, line: line}
+		for ; i < len(edits) && (edits[i].Op == Equal) == blk.equal; i++ {
+			switch edits[i].Op {
+			case Equal:
+				blk.old = append(blk.old, edits[i].Text)
+				blk.new = append(blk.new, edits[i].Text)
+				line++
+			case Delete:
+				blk.old = append(blk.old, edits[i].Text)
+			case Insert:
+				blk.new = append(blk.new, edits[i].Text)
+				line++
+			}
+		}
+		blocks = append(blocks, blk)
+	}
+
+	var hunks []Hunk
+	var cur *Hunk
+	var text strings.Builder
+	flush := func() {
+		if cur != nil {
+			cur.Text = text.String()
+			hunks = append(hunks, *cur)
+			cur = nil
+			text.Reset()
+		}
+	}
+	for i, blk := range blocks {
+		if blk.equal {
+			lines := blk.new
+			switch {
+			case cur == nil && i+1 < len(blocks):
+				// Leading context of the next change.
+				from := max(0, len(lines)-context)
+				cur = &Hunk{Line: blk.line + from}
+				text.WriteString(strings.Join(lines[from:], "\n") + "\n")
+			case cur != nil && i+1 < len(blocks) && len(lines) <= 2*context:
+				text.WriteString(strings.Join(lines, "\n") + "\n")
+			case cur != nil:
+				text.WriteString(strings.Join(lines[:min(context, len(lines))], "\n") + "\n")
+				flush()
+				if i+1 < len(blocks) {
+					from := max(0, len(lines)-context)
+					cur = &Hunk{Line: blk.line + from}
+					text.WriteString(strings.Join(lines[from:], "\n") + "\n")
+				}
+			}
+			continue
+		}
+		if cur == nil {
+			cur = &Hunk{Line: blk.line}
+		}
+		text.WriteString(markWords(strings.Join(blk.old, "\n"), strings.Join(blk.new, "\n"), len(blk.old) > 0, len(blk.new) > 0) + "\n")
+	}
+	flush()
+	return hunks
+}
+
+// markWords returns new with the words changed from old marked. hasOld
+// and hasNew tell an empty line from no line at all.
+func markWords(old, new string, hasOld, hasNew bool) string {
+	if !hasOld {
+		return "{+" + new + "+}"
+	}
+	if !hasNew {
+		return "[-" + old + "-]"
+	}
+	var b strings.Builder
+	edits := Sequences(tokens(old), tokens(new))
+	for i := 0; i < len(edits); {
+		op := edits[i].Op
+		var run strings.Builder
+		for ; i < len(edits) && edits[i].Op == op; i++ {
+			run.WriteString(edits[i].Text)
+		}
+		switch op {
+		case Equal:
+			b.WriteString(run.String())
+		case Delete:
+			b.WriteString("[-" + run.String() + "-]")
+		case Insert:
+			b.WriteString("{+" + run.String() + "+}")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSequences(t *testing.T) {
+	edits := Sequences(strings.Split("abcabba", ""), strings.Split("cbabac", ""))
+	var a, b string
+	dels := 0
+	for _, e := range edits {
+		if e.Op != Insert {
+			a += e.Text
+		}
+		if e.Op != Delete {
+			b += e.Text
+		}
+		if e.Op != Equal {
+			dels++
+		}
+	}
+	if a != "abcabba" || b != "cbabac" {
+		t.Errorf("Sequences() applies to %q, %q", a, b)
+	}
+	if dels != 5 {
+		t.Errorf("Sequences() made %d edits, want 5", dels)
+	}
+	for _, pair := range [][2]string{{"", "abc"}, {"abc", ""}, {"kitten", "sitting"}, {"aaaa", "aaaa"}} {
+		var a, b string
+		for _, e := range Sequences(strings.Split(pair[0], ""), strings.Split(pair[1], "")) {
+			if e.Op != Insert {
+				a += e.Text
+			}
+			if e.Op != Delete {
+				b += e.Text
+			}
+		}
+		if a != pair[0] || b != pair[1] {
+			t.Errorf("Sequences(%q, %q) applies to %q, %q", pair[0], pair[1], a, b)
+		}
+	}
+	if edits := Sequences(nil, nil); edits != nil {
+		t.Errorf("Sequences(nil, nil) = %v", edits)
+	}
+}
+
+func TestWords(t *testing.T) {
+	old := "one\ntwo\nthe quick brown fox\nfour\nfive\nsix\nseven\neight\nnine\n"
+	new := "one\ntwo\nthe slow brown fox\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	got := Words(old, new, 1)
+	want := []Hunk{
+		{Line: 2, Text: "two\nthe [-quick-]{+slow+} brown fox\nfour\n"},
+		{Line: 9, Text: "nine\n{+ten+}\n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %q, want %q", got, want)
+	}
+
+	got = Words("a\nb\nc\n", "a\nc\n", 3)
+	want = []Hunk{{Line: 1, Text: "a\n[-b-]\nc\n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words(deletion) = %q, want %q", got, want)
+	}
+
+	if got := Words("same\n", "same\n", 3); got != nil {
+		t.Errorf("Words(unchanged) = %q", got)
+	}
+}
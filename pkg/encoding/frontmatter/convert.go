@@ -0,0 +1,186 @@
+package frontmatter
+
+import (
+	"denote/pkg/metadata"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// field is a front matter line, in the order found.
+type field struct {
+	key, value string
+}
+
+var (
+	orgFieldRe  = regexp.MustCompile(`^#\+([^:\s]+):[ \t]*(.*)$`)
+	yamlFieldRe = regexp.MustCompile(`^([^:\s]+):[ \t]*(.*)$`)
+	tomlFieldRe = regexp.MustCompile(`^([^=\s]+)[ \t]*=[ \t]*(.*)$`)
+	txtRuleRe   = regexp.MustCompile(`^-{3,}$`)
+)
+
+// Convert rewrites the front matter of content from the syntax of one
+// file type to that of another, keeping every field, including ones
+// Denote does not know, in order, and the body as it is. Tags and the
+// date are rewritten in the syntax of the new type; other values are
+// kept, without quotes in org and text front matter.
+func Convert(content string, from, to metadata.FileType) (string, error) {
+	if from == to {
+		return "", fmt.Errorf("front matter is already %s", to)
+	}
+	if _, ok := templates[to]; !ok {
+		return "", fmt.Errorf("unknown file type %q", to)
+	}
+	text := strings.ReplaceAll(content, "\r\n", "\n")
+	fields, body, ok := splitFields(text, from)
+	if !ok {
+		return "", fmt.Errorf("no %s front matter", from)
+	}
+
+	var b strings.Builder
+	switch to {
+	case metadata.FileTypeMdYaml:
+		b.WriteString("---\n")
+	case metadata.FileTypeMdToml:
+		b.WriteString("+++\n")
+	}
+	for _, f := range fields {
+		key, value := f.key, f.value
+		switch {
+		case key == "tags" || key == "filetags":
+			key = "tags"
+			if to == metadata.FileTypeOrg {
+				key = "filetags"
+			}
+			value = formatTagsAs(parseTags(value, from), to)
+		case key == "date":
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			if to == metadata.FileTypeOrg && value != "" {
+				value = "[" + value + "]"
+			}
+		case to == metadata.FileTypeOrg || to == metadata.FileTypeTxt:
+			if v, ok := metadata.Unquote(value); ok {
+				value = v
+			}
+		}
+		b.WriteString(formatField(key, value, to))
+	}
+	switch to {
+	case metadata.FileTypeMdYaml:
+		b.WriteString("---\n")
+	case metadata.FileTypeMdToml:
+		b.WriteString("+++\n")
+	case metadata.FileTypeTxt:
+		b.WriteString("---------------------------\n")
+	}
+	return b.String() + body, nil
+}
+
+// Body returns the text of content after its front matter, or all of it
+// if it has none. ext is the file extension, as for Unmarshal.
+func Body(content []byte, ext string) string {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	_, fileType, _ := Unmarshal(content, ext)
+	if _, body, ok := splitFields(text, fileType); ok {
+		return body
+	}
+	return text
+}
+
+// splitFields returns the front matter fields of text, written as
+// fileType, and the text after them.
+func splitFields(text string, fileType metadata.FileType) ([]field, string, bool) {
+	lines := strings.SplitAfter(text, "\n")
+	line := func(i int) string { return strings.TrimSuffix(lines[i], "\n") }
+	var fields []field
+	add := func(re *regexp.Regexp, s string) {
+		if m := re.FindStringSubmatch(s); m != nil {
+			fields = append(fields, field{m[1], strings.TrimSpace(m[2])})
+		}
+	}
+	switch fileType {
+	case metadata.FileTypeMdYaml, metadata.FileTypeMdToml:
+		delim, re := "---", yamlFieldRe
+		if fileType == metadata.FileTypeMdToml {
+			delim, re = "+++", tomlFieldRe
+		}
+		if len(lines) == 0 || line(0) != delim {
+			return nil, "", false
+		}
+		for i := 1; i < len(lines); i++ {
+			if line(i) == delim {
+				return fields, strings.Join(lines[i+1:], ""), true
+			}
+			add(re, line(i))
+		}
+	case metadata.FileTypeOrg:
+		i := 0
+		for ; i < len(lines) && strings.HasPrefix(lines[i], "#+"); i++ {
+			add(orgFieldRe, line(i))
+		}
+		return fields, strings.Join(lines[i:], ""), i > 0
+	case metadata.FileTypeTxt:
+		if len(lines) == 0 || !strings.HasPrefix(lines[0], "title:") {
+			return nil, "", false
+		}
+		for i := 0; i < len(lines); i++ {
+			if txtRuleRe.MatchString(line(i)) {
+				return fields, strings.Join(lines[i+1:], ""), true
+			}
+			add(yamlFieldRe, line(i))
+		}
+	}
+	return nil, "", false
+}
+
+// parseTags returns the tags of a tags field written as fileType.
+func parseTags(value string, fileType metadata.FileType) []string {
+	var tags []string
+	switch fileType {
+	case metadata.FileTypeOrg:
+		tags = strings.Split(strings.Trim(value, ":"), ":")
+	case metadata.FileTypeTxt:
+		tags = strings.Fields(value)
+	default:
+		tags = strings.Split(strings.Trim(value, "[]"), ",")
+	}
+	var out []string
+	for _, t := range tags {
+		if t = strings.Trim(strings.TrimSpace(t), `"'`); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// formatTagsAs writes tags as formatTags does, but as they are: a
+// conversion does not normalize them.
+func formatTagsAs(tags []string, fileType metadata.FileType) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	switch fileType {
+	case metadata.FileTypeOrg:
+		return ":" + strings.Join(tags, ":") + ":"
+	case metadata.FileTypeMdYaml, metadata.FileTypeMdToml:
+		return "[" + strings.Join(tags, ", ") + "]"
+	default:
+		return strings.Join(tags, " ")
+	}
+}
+
+// formatField writes a field aligned as in the templates, with at least
+// one space after a long key.
+func formatField(key, value string, fileType metadata.FileType) string {
+	pad := func(s string, width int) string {
+		return s + strings.Repeat(" ", max(1, width-len(s)))
+	}
+	switch fileType {
+	case metadata.FileTypeOrg:
+		return pad("#+"+key+":", 14) + value + "\n"
+	case metadata.FileTypeMdToml:
+		return pad(key, 11) + "= " + value + "\n"
+	default:
+		return pad(key+":", 12) + value + "\n"
+	}
+}
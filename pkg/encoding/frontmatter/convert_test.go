@@ -0,0 +1,102 @@
+package frontmatter
+
+import (
+	"denote/pkg/metadata"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	toml := `+++
+title      = "Go concurrency"
+date       = 2024-01-15 Mon 09:30
+tags       = ["go", "notes"]
+identifier = "20240115T093000"
+signature  = 
+aliases    = ["goroutines"]
++++
+
+Body with = and: colons.
+`
+	yaml := `---
+title:      "Go concurrency"
+date:       2024-01-15 Mon 09:30
+tags:       [go, notes]
+identifier: "20240115T093000"
+signature:  
+aliases:    ["goroutines"]
+---
+
+Body with = and: colons.
+`
+	org := `#+title:      Go concurrency
+#+date:       [2024-01-15 Mon 09:30]
+#+filetags:   :go:notes:
+#+identifier: 20240115T093000
+#+signature:  
+#+aliases:    ["goroutines"]
+
+Body with = and: colons.
+`
+	txt := `title:      Go concurrency
+date:       2024-01-15 Mon 09:30
+tags:       go notes
+identifier: 20240115T093000
+signature:  
+aliases:    ["goroutines"]
+---------------------------
+
+Body with = and: colons.
+`
+	tests := []struct {
+		in       string
+		from, to metadata.FileType
+		want     string
+	}{
+		{toml, metadata.FileTypeMdToml, metadata.FileTypeMdYaml, yaml},
+		{yaml, metadata.FileTypeMdYaml, metadata.FileTypeOrg, org},
+		{org, metadata.FileTypeOrg, metadata.FileTypeTxt, txt},
+		{txt, metadata.FileTypeTxt, metadata.FileTypeMdYaml, yamlUnquoted},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.in, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Convert(%s -> %s) error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Convert(%s -> %s) =\n%s\nwant\n%s", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := Convert("no front matter\n", metadata.FileTypeMdToml, metadata.FileTypeMdYaml); err == nil {
+		t.Error("Convert() of a note without front matter succeeded")
+	}
+	if _, err := Convert(yaml, metadata.FileTypeMdYaml, metadata.FileTypeMdYaml); err == nil {
+		t.Error("Convert() to the same type succeeded")
+	}
+}
+
+const yamlUnquoted = `---
+title:      Go concurrency
+date:       2024-01-15 Mon 09:30
+tags:       [go, notes]
+identifier: 20240115T093000
+signature:  
+aliases:    ["goroutines"]
+---
+
+Body with = and: colons.
+`
+
+func TestBody(t *testing.T) {
+	for _, c := range []struct{ content, ext, want string }{
+		{"---\ntitle: Go\ntags: [go]\n---\n\nBody text\n", ".md", "\nBody text\n"},
+		{"#+title: Go\r\n#+filetags: :go:\r\n\r\nBody text\r\n", ".org", "\nBody text\n"},
+		{"title: Go\n---------\n\nBody text\n", ".txt", "\nBody text\n"},
+		{"No front matter\n", ".md", "No front matter\n"},
+	} {
+		if got := Body([]byte(c.content), c.ext); got != c.want {
+			t.Errorf("Body(%q, %s) = %q, want %q", c.content, c.ext, got, c.want)
+		}
+	}
+}
@@ -45,25 +45,39 @@ signature:  %s
 `,
 }
 
+// bibLines format the optional bib field. It is written after the
+// signature only when set, so notes without citations are unchanged.
+var bibLines = map[metadata.FileType]string{
+	metadata.FileTypeOrg:    "#+bib:        %s\n",
+	metadata.FileTypeMdYaml: "bib:        %s\n",
+	metadata.FileTypeMdToml: "bib        = %s\n",
+	metadata.FileTypeTxt:    "bib:        %s\n",
+}
+
+// dueLines format the optional due field, a deadline or reminder date,
+// written after bib.
+var dueLines = map[metadata.FileType]string{
+	metadata.FileTypeOrg:    "#+due:        %s\n",
+	metadata.FileTypeMdYaml: "due:        %s\n",
+	metadata.FileTypeMdToml: "due        = %s\n",
+	metadata.FileTypeTxt:    "due:        %s\n",
+}
+
 // formatTags formats tags according to file type
 func formatTags(tags []string, fileType metadata.FileType) string {
-	if len(tags) == 0 {
-		return ""
-	}
-	switch fileType {
-	case metadata.FileTypeOrg:
-		return ":" + strings.Join(tags, ":") + ":"
-	case metadata.FileTypeMdYaml, metadata.FileTypeMdToml:
-		return "[" + strings.Join(tags, ", ") + "]"
-	default:
-		return strings.Join(tags, " ")
-	}
+	return formatTagsAs(metadata.NormalizeTags(tags), fileType)
 }
 
 // Marshal returns the formatted frontmatter content as bytes
 func Marshal(fm *metadata.FrontMatter, fileType metadata.FileType) []byte {
+	return MarshalAt(fm, fileType, time.Now())
+}
+
+// MarshalAt is like Marshal but records date as the note's date
+// instead of the current time, e.g. for imported notes.
+func MarshalAt(fm *metadata.FrontMatter, fileType metadata.FileType, date time.Time) []byte {
 	template := templates[fileType]
-	dateStr := time.Now().Format("2006-01-02 Mon 15:04")
+	dateStr := date.Format("2006-01-02 Mon 15:04")
 
 	// For org-mode, wrap date in brackets for timestamp
 	if fileType == metadata.FileTypeOrg {
@@ -72,6 +86,22 @@ func Marshal(fm *metadata.FrontMatter, fileType metadata.FileType) []byte {
 
 	keywordsStr := formatTags(fm.Tags, fileType)
 	content := fmt.Sprintf(template, fm.Title, dateStr, keywordsStr, fm.Identifier, fm.Signature)
+	var extra string
+	if fm.Bib != "" {
+		extra += fmt.Sprintf(bibLines[fileType], fm.Bib)
+	}
+	if fm.Due != "" {
+		extra += fmt.Sprintf(dueLines[fileType], fm.Due)
+	}
+	if extra != "" {
+		// Insert after the signature line, which ends every template's fields.
+		sig := strings.Index(content, "\nsignature")
+		if fileType == metadata.FileTypeOrg {
+			sig = strings.Index(content, "\n#+signature")
+		}
+		end := sig + 1 + strings.Index(content[sig+1:], "\n") + 1
+		content = content[:end] + extra + content[end:]
+	}
 	return []byte(content)
 }
 
@@ -80,7 +110,8 @@ func Marshal(fm *metadata.FrontMatter, fileType metadata.FileType) []byte {
 // Returns the parsed frontmatter and the detected FileType.
 func Unmarshal(content []byte, ext string) (*metadata.FrontMatter, metadata.FileType, error) {
 	ext = strings.ToLower(ext)
-	text := string(content)
+	// Notes edited on Windows may have CRLF line endings.
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
 
 	fm := &metadata.FrontMatter{}
 	var fileType metadata.FileType
@@ -100,6 +131,12 @@ func Unmarshal(content []byte, ext string) (*metadata.FrontMatter, metadata.File
 		if m := regexp.MustCompile(`(?m)^#\+signature:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
 			fm.Signature = strings.TrimSpace(m[1])
 		}
+		if m := regexp.MustCompile(`(?m)^#\+bib:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
+			fm.Bib = strings.TrimSpace(m[1])
+		}
+		if m := regexp.MustCompile(`(?m)^#\+due:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
+			fm.Due = strings.TrimSpace(m[1])
+		}
 
 	case ".md":
 		// Try YAML first
@@ -122,9 +159,15 @@ func Unmarshal(content []byte, ext string) (*metadata.FrontMatter, metadata.File
 			if m := regexp.MustCompile(`(?m)^identifier:[ \t]*["']?(.+?)["']?$`).FindStringSubmatch(yamlContent); m != nil {
 				fm.Identifier = strings.TrimSpace(m[1])
 			}
-			if m := regexp.MustCompile(`(?m)^signature:[ \t]*["']?(.*)["']?$`).FindStringSubmatch(yamlContent); m != nil {
+			if m := regexp.MustCompile(`(?m)^signature:[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(yamlContent); m != nil {
 				fm.Signature = strings.TrimSpace(m[1])
 			}
+			if m := regexp.MustCompile(`(?m)^bib:[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(yamlContent); m != nil {
+				fm.Bib = strings.TrimSpace(m[1])
+			}
+			if m := regexp.MustCompile(`(?m)^due:[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(yamlContent); m != nil {
+				fm.Due = strings.TrimSpace(m[1])
+			}
 		} else {
 			// Try TOML
 			tomlRe := regexp.MustCompile(`(?ms)^\+\+\+\n(.*?)\n\+\+\+`)
@@ -146,9 +189,15 @@ func Unmarshal(content []byte, ext string) (*metadata.FrontMatter, metadata.File
 				if m := regexp.MustCompile(`(?m)^identifier[ \t]*=[ \t]*["']?(.+?)["']?$`).FindStringSubmatch(tomlContent); m != nil {
 					fm.Identifier = strings.TrimSpace(m[1])
 				}
-				if m := regexp.MustCompile(`(?m)^signature[ \t]*=[ \t]*["']?(.*)["']?$`).FindStringSubmatch(tomlContent); m != nil {
+				if m := regexp.MustCompile(`(?m)^signature[ \t]*=[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(tomlContent); m != nil {
 					fm.Signature = strings.TrimSpace(m[1])
 				}
+				if m := regexp.MustCompile(`(?m)^bib[ \t]*=[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(tomlContent); m != nil {
+					fm.Bib = strings.TrimSpace(m[1])
+				}
+				if m := regexp.MustCompile(`(?m)^due[ \t]*=[ \t]*["']?(.*?)["']?$`).FindStringSubmatch(tomlContent); m != nil {
+					fm.Due = strings.TrimSpace(m[1])
+				}
 			}
 		}
 
@@ -166,6 +215,12 @@ func Unmarshal(content []byte, ext string) (*metadata.FrontMatter, metadata.File
 		if m := regexp.MustCompile(`(?m)^signature:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
 			fm.Signature = strings.TrimSpace(m[1])
 		}
+		if m := regexp.MustCompile(`(?m)^bib:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
+			fm.Bib = strings.TrimSpace(m[1])
+		}
+		if m := regexp.MustCompile(`(?m)^due:[ \t]*(.*)$`).FindStringSubmatch(text); m != nil {
+			fm.Due = strings.TrimSpace(m[1])
+		}
 	}
 
 	return fm, fileType, nil
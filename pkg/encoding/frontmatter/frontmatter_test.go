@@ -386,3 +386,83 @@ date: 2024-01-01
 	}
 }
 
+// TestBibRoundTrip validates the optional bib field is written only when
+// set and is parsed back for every file type
+func TestBibRoundTrip(t *testing.T) {
+	exts := map[metadata.FileType]string{
+		metadata.FileTypeOrg:    ".org",
+		metadata.FileTypeMdYaml: ".md",
+		metadata.FileTypeMdToml: ".md",
+		metadata.FileTypeTxt:    ".txt",
+	}
+	for fileType, ext := range exts {
+		t.Run(string(fileType), func(t *testing.T) {
+			fm := metadata.NewFrontMatter("Signature Ideas", "1a", []string{"ref"}, "20240101T120000")
+			if got := string(Marshal(fm, fileType)); strings.Contains(got, "bib:") || strings.Contains(got, "bib ") {
+				t.Errorf("Marshal() wrote empty bib field:\n%s", got)
+			}
+
+			fm.Bib = "knuth1984"
+			content := Marshal(fm, fileType)
+			got, _, err := Unmarshal(content, ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Bib != "knuth1984" {
+				t.Errorf("Bib = %q, want %q\n%s", got.Bib, "knuth1984", content)
+			}
+			if got.Signature != "1a" || got.Title != "Signature Ideas" {
+				t.Errorf("bib field disturbed other fields: %+v\n%s", got, content)
+			}
+		})
+	}
+}
+
+// TestDueRoundTrip validates the optional due field is written only when
+// set, after bib, and is parsed back for every file type
+func TestDueRoundTrip(t *testing.T) {
+	exts := map[metadata.FileType]string{
+		metadata.FileTypeOrg:    ".org",
+		metadata.FileTypeMdYaml: ".md",
+		metadata.FileTypeMdToml: ".md",
+		metadata.FileTypeTxt:    ".txt",
+	}
+	for fileType, ext := range exts {
+		t.Run(string(fileType), func(t *testing.T) {
+			fm := metadata.NewFrontMatter("Tax Return", "", []string{"admin"}, "20240101T120000")
+			if got := string(Marshal(fm, fileType)); strings.Contains(got, "due") {
+				t.Errorf("Marshal() wrote empty due field:\n%s", got)
+			}
+
+			fm.Bib = "irs2024"
+			fm.Due = "2024-04-15"
+			content := Marshal(fm, fileType)
+			got, _, err := Unmarshal(content, ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Due != "2024-04-15" || got.Bib != "irs2024" {
+				t.Errorf("Due, Bib = %q, %q\n%s", got.Due, got.Bib, content)
+			}
+			if got.Title != "Tax Return" || got.Identifier != "20240101T120000" {
+				t.Errorf("due field disturbed other fields: %+v\n%s", got, content)
+			}
+		})
+	}
+}
+
+func TestUnmarshalQuotedSignature(t *testing.T) {
+	inputs := []string{
+		"---\ntitle: \"x\"\nsignature:  \"1a\"\n---\n",
+		"+++\ntitle = \"x\"\nsignature  = \"1a\"\n+++\n",
+	}
+	for _, in := range inputs {
+		fm, _, err := Unmarshal([]byte(in), ".md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fm.Signature != "1a" {
+			t.Errorf("Unmarshal(%q).Signature = %q, want %q", in, fm.Signature, "1a")
+		}
+	}
+}
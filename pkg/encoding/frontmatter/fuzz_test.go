@@ -0,0 +1,69 @@
+package frontmatter
+
+import (
+	"denote/pkg/metadata"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var fuzzExts = []string{".org", ".md", ".txt", ".MD", ""}
+
+func FuzzUnmarshal(f *testing.F) {
+	fm := &metadata.FrontMatter{Title: "Go Proverbs", Tags: []string{"golang", "reading"}, Identifier: "20251112T221141", Signature: "1a"}
+	for _, ft := range []metadata.FileType{metadata.FileTypeOrg, metadata.FileTypeMdYaml, metadata.FileTypeMdToml, metadata.FileTypeTxt} {
+		f.Add(Marshal(fm, ft))
+	}
+	f.Add([]byte("---\ntitle:\n---"))
+	f.Add([]byte("+++\ntags = []\n+++\n"))
+	f.Add([]byte("#+filetags: ::\n"))
+	f.Fuzz(func(t *testing.T, content []byte) {
+		// Line endings must not change what is parsed.
+		lf := strings.ReplaceAll(string(content), "\r\n", "\n")
+		crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+		for _, ext := range fuzzExts {
+			fm, ft, err := Unmarshal([]byte(lf), ext)
+			if err != nil {
+				continue
+			}
+			if fm == nil {
+				t.Fatalf("Unmarshal(%q, %q) returned no front matter and no error", lf, ext)
+			}
+			fm2, ft2, err := Unmarshal([]byte(crlf), ext)
+			if err != nil || ft2 != ft || fm2.Title != fm.Title || !slices.Equal(fm2.Tags, fm.Tags) ||
+				fm2.Identifier != fm.Identifier || fm2.Signature != fm.Signature || fm2.Bib != fm.Bib {
+				t.Errorf("Unmarshal(%q, %q) = %+v (%s), but with CRLF line endings %+v (%s, %v)", lf, ext, fm, ft, fm2, ft2, err)
+			}
+		}
+	})
+}
+
+// fuzzWord matches the values Marshal writes unquoted and unescaped.
+var (
+	fuzzWord = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} ._-]*$`)
+	fuzzTag  = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{Nd}]+$`)
+)
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("Go Proverbs", "golang", "20251112T221141", "1a")
+	f.Add("a", "b", "c", "")
+	f.Fuzz(func(t *testing.T, title, tag, identifier, signature string) {
+		title, identifier = strings.TrimSpace(title), strings.TrimSpace(identifier)
+		if !fuzzWord.MatchString(title) || !fuzzWord.MatchString(identifier) || !fuzzTag.MatchString(tag) ||
+			(signature != "" && !fuzzWord.MatchString(signature)) || strings.TrimSpace(signature) != signature {
+			return
+		}
+		want := &metadata.FrontMatter{Title: title, Tags: []string{tag}, Identifier: identifier, Signature: signature}
+		for _, ft := range []metadata.FileType{metadata.FileTypeOrg, metadata.FileTypeMdYaml, metadata.FileTypeMdToml, metadata.FileTypeTxt} {
+			got, gotType, err := Unmarshal(Marshal(want, ft), "."+strings.SplitN(string(ft), "-", 2)[0])
+			if err != nil {
+				t.Fatalf("%s: Unmarshal: %v", ft, err)
+			}
+			if gotType != ft || got.Title != want.Title || !slices.Equal(got.Tags, want.Tags) ||
+				got.Identifier != want.Identifier || got.Signature != want.Signature {
+				t.Errorf("%s round trip: got %+v (%s), want %+v", ft, got, gotType, want)
+			}
+		}
+	})
+}
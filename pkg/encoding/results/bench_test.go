@@ -0,0 +1,50 @@
+package results
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"denote/pkg/metadata"
+)
+
+var benchTags = []string{"work", "meeting", "golang", "journal", "reading", "project", "idea", "home"}
+
+// synthResults returns n notes with varied titles and tags.
+func synthResults(n int) metadata.Results {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := make(metadata.Results, n)
+	for i := range rs {
+		rs[i] = &metadata.Metadata{
+			Identifier: start.Add(time.Duration(i) * time.Minute).Format("20060102T150405"),
+			Title:      fmt.Sprintf("note %d about %s", i, benchTags[i%len(benchTags)]),
+			Tags:       []string{benchTags[i%len(benchTags)], benchTags[(i/3)%len(benchTags)]},
+		}
+	}
+	return rs
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		rs := synthResults(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Marshal(rs)
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		data := Marshal(synthResults(n))
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Unmarshal(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
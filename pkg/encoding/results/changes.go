@@ -0,0 +1,189 @@
+package results
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"denote/pkg/metadata"
+)
+
+// Changes lists the notes changed since a sequence number of the server's
+// index, so that a client holding the index as of that number can bring
+// it up to date without reading it again.
+//
+// The first line is "seq N", the sequence number the changes bring the
+// index to. Each following line is either an index line, for a note
+// created or changed, or "- identifier", for a note removed.
+type Changes struct {
+	Seq     int
+	Changed metadata.Results
+	Removed []string
+}
+
+// UnmarshalChanges parses the changes format.
+func UnmarshalChanges(data []byte) (*Changes, error) {
+	first, rest, _ := bytes.Cut(data, []byte("\n"))
+	seq, ok := strings.CutPrefix(strings.TrimSpace(string(first)), "seq ")
+	n, err := strconv.Atoi(strings.TrimSpace(seq))
+	if !ok || err != nil {
+		return nil, fmt.Errorf("invalid changes header %q", first)
+	}
+	c := &Changes{Seq: n}
+	var changed []byte
+	for _, line := range strings.Split(string(rest), "\n") {
+		if id, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+			id = strings.TrimPrefix(strings.TrimSpace(id), "denote:")
+			if !metadata.IsIdentifier(id) {
+				return nil, fmt.Errorf("invalid removed identifier %q", id)
+			}
+			c.Removed = append(c.Removed, id)
+			continue
+		}
+		changed = append(changed, line+"\n"...)
+	}
+	if c.Changed, err = Unmarshal(changed); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Apply returns rs with the changes in c applied: removed notes are
+// dropped, changed notes replaced, and new notes appended. rs itself is
+// not modified.
+func (c *Changes) Apply(rs metadata.Results) metadata.Results {
+	byID := make(map[string]*metadata.Metadata, len(c.Changed))
+	for _, n := range c.Changed {
+		byID[n.Identifier] = n
+	}
+	out := make(metadata.Results, 0, len(rs)+len(c.Changed))
+	for _, n := range rs {
+		if slices.Contains(c.Removed, n.Identifier) {
+			continue
+		}
+		if changed, ok := byID[n.Identifier]; ok {
+			out = append(out, changed)
+			delete(byID, n.Identifier)
+			continue
+		}
+		out = append(out, n)
+	}
+	for _, n := range c.Changed {
+		if _, ok := byID[n.Identifier]; ok {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Report describes how the index changed between two readings, e.g.
+// before and after a sync, note by note. Each list is in identifier
+// order; a note both retitled and retagged is in both lists.
+type Report struct {
+	Added    metadata.Results
+	Removed  metadata.Results
+	Retitled []Change
+	Retagged []Change
+}
+
+// Change is a note as it was and as it is.
+type Change struct {
+	Old, New *metadata.Metadata
+}
+
+// Compare returns the report of the changes from the index old to new.
+// Tags are compared as sets, so reordering them is not a change.
+func Compare(old, new metadata.Results) *Report {
+	byID := make(map[string]*metadata.Metadata, len(old))
+	for _, n := range old {
+		byID[n.Identifier] = n
+	}
+	r := &Report{}
+	for _, n := range new {
+		o, ok := byID[n.Identifier]
+		if !ok {
+			r.Added = append(r.Added, n)
+			continue
+		}
+		delete(byID, n.Identifier)
+		if o.Title != n.Title {
+			r.Retitled = append(r.Retitled, Change{o, n})
+		}
+		if !sameTags(o.Tags, n.Tags) {
+			r.Retagged = append(r.Retagged, Change{o, n})
+		}
+	}
+	for _, n := range old {
+		if _, ok := byID[n.Identifier]; ok {
+			r.Removed = append(r.Removed, n)
+		}
+	}
+	byIdentifier := func(a, b *metadata.Metadata) int { return strings.Compare(a.Identifier, b.Identifier) }
+	slices.SortFunc(r.Added, byIdentifier)
+	slices.SortFunc(r.Removed, byIdentifier)
+	for _, cs := range [][]Change{r.Retitled, r.Retagged} {
+		slices.SortFunc(cs, func(a, b Change) int { return byIdentifier(a.New, b.New) })
+	}
+	return r
+}
+
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// Empty reports whether r has no changes.
+func (r *Report) Empty() bool {
+	return len(r.Added)+len(r.Removed)+len(r.Retitled)+len(r.Retagged) == 0
+}
+
+// MarshalReport writes r as a summary line followed by a section for
+// each kind of change, listing the notes in the index format. Retitled
+// and retagged notes are followed by an indented line with what they
+// were.
+//
+//	1 added, 0 removed, 1 retitled, 0 retagged
+//
+//	added
+//	20240104T120000 | fourth |
+//
+//	retitled
+//	20240102T120000 | second, renamed | b
+//		was: second
+func MarshalReport(r *Report) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d added, %d removed, %d retitled, %d retagged\n",
+		len(r.Added), len(r.Removed), len(r.Retitled), len(r.Retagged))
+	section := func(name string, rs metadata.Results) {
+		if len(rs) > 0 {
+			buf.WriteString("\n" + name + "\n")
+			buf.Write(Marshal(rs))
+		}
+	}
+	changes := func(name string, cs []Change, was func(*metadata.Metadata) string) {
+		if len(cs) == 0 {
+			return
+		}
+		buf.WriteString("\n" + name + "\n")
+		for _, c := range cs {
+			buf.Write(Marshal(metadata.Results{c.New}))
+			buf.WriteString("\twas: " + was(c.Old) + "\n")
+		}
+	}
+	section("added", r.Added)
+	section("removed", r.Removed)
+	changes("retitled", r.Retitled, func(m *metadata.Metadata) string {
+		if m.Title == "" {
+			return untitled
+		}
+		return m.Title
+	})
+	changes("retagged", r.Retagged, func(m *metadata.Metadata) string {
+		return strings.Join(m.Tags, ",")
+	})
+	return buf.Bytes()
+}
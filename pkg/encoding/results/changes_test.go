@@ -0,0 +1,68 @@
+package results
+
+import (
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	rs, err := Unmarshal([]byte("20240101T120000 | first | a\n20240102T120000 | second | b\n20240103T120000 | third | c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := UnmarshalChanges([]byte("seq 7\n20240102T120000 | second, renamed | b,d\n- 20240103T120000\n20240104T120000 | fourth | \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Seq != 7 || len(c.Changed) != 2 || len(c.Removed) != 1 {
+		t.Fatalf("UnmarshalChanges() = %+v", c)
+	}
+
+	got := c.Apply(rs)
+	want := "20240101T120000 | first | a\n20240102T120000 | second, renamed | b,d\n20240104T120000 | fourth | \n"
+	if string(Marshal(got)) != want {
+		t.Errorf("Apply() = %q, want %q", Marshal(got), want)
+	}
+	if len(rs) != 3 || rs[1].Title != "second" {
+		t.Errorf("Apply() modified its argument: %v", rs)
+	}
+}
+
+func TestUnmarshalChangesInvalid(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"20240101T120000 | first | a\n",
+		"seq x\n",
+		"seq 1\n- nonsense\n",
+	} {
+		if _, err := UnmarshalChanges([]byte(data)); err == nil {
+			t.Errorf("UnmarshalChanges(%q) succeeded", data)
+		}
+	}
+	c, err := UnmarshalChanges([]byte("seq 3\n"))
+	if err != nil || c.Seq != 3 || len(c.Changed)+len(c.Removed) != 0 {
+		t.Errorf("UnmarshalChanges(no changes) = %+v, %v", c, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old, err := Unmarshal([]byte("20240101T120000 | first | a,b\n20240102T120000 | second | b\n20240103T120000 | third | c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := Unmarshal([]byte("20240104T120000 | fourth | \n20240101T120000 | first | b,a\n20240102T120000 | second, renamed | b,d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Compare(old, new)
+	want := "1 added, 1 removed, 1 retitled, 1 retagged\n" +
+		"\nadded\n20240104T120000 | fourth | \n" +
+		"\nremoved\n20240103T120000 | third | c\n" +
+		"\nretitled\n20240102T120000 | second, renamed | b,d\n\twas: second\n" +
+		"\nretagged\n20240102T120000 | second, renamed | b,d\n\twas: b\n"
+	if got := string(MarshalReport(r)); got != want {
+		t.Errorf("MarshalReport() =\n%q\nwant\n%q", got, want)
+	}
+	if r.Empty() || !Compare(old, old).Empty() {
+		t.Errorf("Empty() is wrong")
+	}
+}
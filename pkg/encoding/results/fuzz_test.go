@@ -0,0 +1,47 @@
+package results
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add([]byte("20240101T120000 | Meeting notes | work,meeting\n"))
+	f.Add([]byte(Header + "\ndenote:20240101T120000 | (untitled) | \n"))
+	f.Add([]byte("20240101T120000 | a | b | c\n"))
+	f.Add([]byte(" | | \n"))
+	f.Add([]byte("denote:denote:1 | t | \n"))
+	f.Add([]byte("denote: 1 | t | \n"))
+	f.Add([]byte("1 | a \\| b \\\\ | \n"))
+	f.Add([]byte("20240101T120000 | 日本語 | 日本,語\r\n\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rs, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		for _, r := range rs {
+			if r.Identifier == "" {
+				t.Errorf("empty identifier in %q", data)
+			}
+			fields := append([]string{r.Identifier, r.Title}, r.Tags...)
+			if slices.ContainsFunc(fields, func(s string) bool { return strings.Contains(s, "\n") }) {
+				t.Errorf("field with a line break in %q: %+v", data, r)
+			}
+		}
+
+		// What Unmarshal accepts must survive a round trip.
+		again, err := Unmarshal(Marshal(rs))
+		if err != nil {
+			t.Fatalf("Unmarshal(Marshal(%q)): %v", data, err)
+		}
+		if len(again) != len(rs) {
+			t.Fatalf("round trip of %q: got %d notes, want %d", data, len(again), len(rs))
+		}
+		for i := range rs {
+			if again[i].Identifier != rs[i].Identifier || again[i].Title != rs[i].Title || !slices.Equal(again[i].Tags, rs[i].Tags) {
+				t.Errorf("round trip of %q: got %+v, want %+v", data, again[i], rs[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package results
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"denote/pkg/metadata"
+)
+
+// Match is a line of a note that matches a body search.
+//
+// The search results format lists one match per line:
+// identifier | title | line: snippet. A note matching on several lines
+// has a line for each. Columns are escaped as in the index format.
+type Match struct {
+	Identifier string
+	Title      string
+	Line       int
+	Snippet    string
+}
+
+// MarshalMatches serializes matches in the search results format.
+func MarshalMatches(ms []Match) []byte {
+	var buf strings.Builder
+	for _, m := range ms {
+		title := m.Title
+		if title == "" {
+			title = untitled
+		}
+		fmt.Fprintf(&buf, "%s | %s | %d: %s\n", escaper.Replace(m.Identifier), escaper.Replace(title), m.Line, escaper.Replace(m.Snippet))
+	}
+	return []byte(buf.String())
+}
+
+// UnmarshalMatch parses a line of the search results format.
+func UnmarshalMatch(line string) (Match, error) {
+	parts := splitColumns(strings.TrimSpace(line))
+	if len(parts) != 3 {
+		return Match{}, fmt.Errorf("expected 3 columns, got %d (line: %q)", len(parts), line)
+	}
+	m := Match{
+		Identifier: strings.TrimPrefix(strings.TrimSpace(parts[0]), "denote:"),
+		Title:      strings.TrimSpace(parts[1]),
+	}
+	if m.Title == untitled {
+		m.Title = ""
+	}
+	if !metadata.IsIdentifier(m.Identifier) {
+		return Match{}, fmt.Errorf("invalid identifier %q", m.Identifier)
+	}
+	num, snippet, ok := strings.Cut(strings.TrimSpace(parts[2]), ":")
+	n, err := strconv.Atoi(num)
+	if !ok || err != nil || n < 1 {
+		return Match{}, fmt.Errorf("invalid line number in %q", parts[2])
+	}
+	m.Line, m.Snippet = n, strings.TrimSpace(snippet)
+	return m, nil
+}
@@ -0,0 +1,31 @@
+package results
+
+import (
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	ms := []Match{
+		{Identifier: "20240101T120000", Title: "Go notes", Line: 12, Snippet: "select | case: done"},
+		{Identifier: "20240102T120000", Line: 3, Snippet: `C:\tmp`},
+	}
+	data := string(MarshalMatches(ms))
+	want := "20240101T120000 | Go notes | 12: select \\| case: done\n20240102T120000 | (untitled) | 3: C:\\\\tmp\n"
+	if data != want {
+		t.Errorf("MarshalMatches() = %q, want %q", data, want)
+	}
+	for i, line := range []string{"20240101T120000 | Go notes | 12: select \\| case: done", "20240102T120000 | (untitled) | 3: C:\\\\tmp"} {
+		m, err := UnmarshalMatch(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != ms[i] {
+			t.Errorf("UnmarshalMatch(%q) = %+v, want %+v", line, m, ms[i])
+		}
+	}
+	for _, line := range []string{"20240101T120000 | Go notes | tag1,tag2", "x | t | 1: s", "20240101T120000 | t | 0: s"} {
+		if _, err := UnmarshalMatch(line); err == nil {
+			t.Errorf("UnmarshalMatch(%q) succeeded", line)
+		}
+	}
+}
@@ -1,3 +1,12 @@
+// Package results encodes note lists in the index wire format shared by
+// the denote server's index file and the Denote window.
+//
+// Each note is one line: identifier | title | tags. The identifier is
+// bare (20240101T120000); parsers also accept it with a denote: prefix.
+// An empty title is written as (untitled). A | or \ in a column is
+// escaped with a backslash (\| and \\); any other backslash is literal.
+// Tags are comma-separated with no spaces. The server reports the version of this format in its
+// version file; FormatVersion is the version implemented here.
 package results
 
 import (
@@ -5,23 +14,66 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"strings"
 
 	"denote/pkg/metadata"
 )
 
+// FormatVersion is the index format version Marshal writes and
+// Unmarshal reads.
+const FormatVersion = 1
+
+// Header names the columns of the Marshal format. It heads the index in
+// the Denote window; Unmarshal skips it.
+const Header = "ID | TITLE | TAGS"
+
+// tagPattern allows lowercase Latin letters, other letters (CJK, etc.),
+// and digits, comma-separated with no spaces.
+var tagPattern = regexp.MustCompile(`^([\p{Ll}\p{Lo}\p{Nd}]+,)*[\p{Ll}\p{Lo}\p{Nd}]+$`)
+
+// untitled stands in for an empty title, which would otherwise leave an
+// empty column.
+const untitled = "(untitled)"
+
+// escaper escapes the column separator and the escape character.
+var escaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+
+// splitColumns splits line at unescaped |s and unescapes the columns.
+func splitColumns(line string) []string {
+	if !strings.Contains(line, `\`) {
+		return strings.Split(line, "|")
+	}
+	var cols []string
+	var col strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && (line[i+1] == '\\' || line[i+1] == '|'):
+			i++
+			col.WriteByte(line[i])
+		case c == '|':
+			cols = append(cols, col.String())
+			col.Reset()
+		default:
+			col.WriteByte(c)
+		}
+	}
+	return append(cols, col.String())
+}
+
 // Marshal serializes Results to a pipe-delimited byte format.
 // Format: identifier | title | tags (comma-separated)
 func Marshal(rs metadata.Results) []byte {
 	var buf strings.Builder
+	buf.Grow(len(rs) * 64) // a typical line
 	for _, e := range rs {
 		title := e.Title
 		if title == "" {
-			title = "(untitled)"
+			title = untitled
 		}
 
 		tags := strings.Join(e.Tags, ",")
-		fmt.Fprintf(&buf, "%s | %s | %s\n", e.Identifier, title, tags)
+		fmt.Fprintf(&buf, "%s | %s | %s\n", escaper.Replace(e.Identifier), escaper.Replace(title), escaper.Replace(tags))
 	}
 	return []byte(buf.String())
 }
@@ -40,29 +92,33 @@ func UnmarshalStrict(data []byte) (metadata.Results, error) {
 }
 
 func unmarshal(data []byte, strict bool) (metadata.Results, error) {
-	var results metadata.Results
 	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
-	// Allow lowercase Latin letters, other letters (CJK, etc.), and digits, no spaces
-	tagPattern := regexp.MustCompile(`^([\p{Ll}\p{Lo}\p{Nd}]+,)*[\p{Ll}\p{Lo}\p{Nd}]+$`)
+	results := make(metadata.Results, 0, len(lines))
 
 	for lineNum, line := range lines {
 		line = bytes.TrimSpace(line)
-		if len(line) == 0 {
+		if len(line) == 0 || string(line) == Header || IsSectionHeader(string(line)) {
 			continue
 		}
 
-		parts := bytes.Split(line, []byte("|"))
+		parts := splitColumns(string(line))
 		if len(parts) != 3 {
 			return nil, fmt.Errorf("line %d: expected 3 columns, got %d (line: %q)", lineNum+1, len(parts), line)
 		}
 
-		identifier := string(bytes.TrimSpace(parts[0]))
-		title := string(bytes.TrimSpace(parts[1]))
-		tagsStr := string(bytes.TrimSpace(parts[2]))
+		identifier := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[0]), "denote:"))
+		title := strings.TrimSpace(parts[1])
+		if title == untitled {
+			title = ""
+		}
+		tagsStr := strings.TrimSpace(parts[2])
 
 		if identifier == "" {
 			return nil, fmt.Errorf("line %d: identifier cannot be empty", lineNum+1)
 		}
+		if strings.HasPrefix(identifier, "denote:") {
+			return nil, fmt.Errorf("line %d: invalid identifier %q", lineNum+1, identifier)
+		}
 
 		var tags []string
 		if tagsStr != "" {
@@ -86,3 +142,30 @@ func unmarshal(data []byte, strict bool) (metadata.Results, error) {
 
 	return results, nil
 }
+
+// MarshalTree serializes notes with Folgezettel signatures as an indented
+// hierarchy, one note per line, ordered by signature. Notes whose
+// signature is not a sequence are omitted.
+// Format: <indent>signature identifier | title
+func MarshalTree(rs metadata.Results) []byte {
+	var seq metadata.Results
+	for _, e := range rs {
+		if metadata.SequenceParts(e.Signature) != nil {
+			seq = append(seq, e)
+		}
+	}
+	slices.SortStableFunc(seq, func(a, b *metadata.Metadata) int {
+		return metadata.CompareSequence(a.Signature, b.Signature)
+	})
+
+	var buf strings.Builder
+	for _, e := range seq {
+		title := e.Title
+		if title == "" {
+			title = untitled
+		}
+		depth := len(metadata.SequenceParts(e.Signature)) - 1
+		fmt.Fprintf(&buf, "%s%s %s | %s\n", strings.Repeat("  ", depth), e.Signature, e.Identifier, title)
+	}
+	return []byte(buf.String())
+}
@@ -54,6 +54,13 @@ func TestMarshal(t *testing.T) {
 			},
 			want: "20240101T120000 | First | a\n20240102T120000 | Second | b,c\n",
 		},
+		{
+			name: "title with pipe and backslash",
+			input: metadata.Results{
+				{Identifier: "20240101T120000", Title: `A|B \ C's`, Tags: []string{"a"}},
+			},
+			want: "20240101T120000 | A\\|B \\\\ C's | a\n",
+		},
 		{
 			name:  "empty results",
 			input: metadata.Results{},
@@ -104,6 +111,30 @@ func TestUnmarshal(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "denote: prefix",
+			input: []byte("denote:20240101T120000 | First | a"),
+			want: metadata.Results{
+				{Identifier: "20240101T120000", Title: "First", Tags: []string{"a"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "escaped pipe in title",
+			input: []byte(`20240101T120000 | A\|B \\ C:\dir | a`),
+			want: metadata.Results{
+				{Identifier: "20240101T120000", Title: `A|B \ C:\dir`, Tags: []string{"a"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "header line",
+			input: []byte(Header + "\n20240101T120000 | First | a"),
+			want: metadata.Results{
+				{Identifier: "20240101T120000", Title: "First", Tags: []string{"a"}},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "empty input",
 			input:   []byte(""),
@@ -184,3 +215,23 @@ func TestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+// TestMarshalTree validates the signature hierarchy format
+func TestMarshalTree(t *testing.T) {
+	input := metadata.Results{
+		{Identifier: "20240104T120000", Signature: "2", Title: "Second"},
+		{Identifier: "20240103T120000", Signature: "1a1", Title: "Grandchild"},
+		{Identifier: "20240101T120000", Signature: "1", Title: "First"},
+		{Identifier: "20240105T120000", Title: "No signature"},
+		{Identifier: "20240102T120000", Signature: "1a", Title: ""},
+		{Identifier: "20240106T120000", Signature: "draft", Title: "Word signature"},
+	}
+	want := "1 20240101T120000 | First\n" +
+		"  1a 20240102T120000 | (untitled)\n" +
+		"    1a1 20240103T120000 | Grandchild\n" +
+		"2 20240104T120000 | Second\n"
+
+	if got := string(MarshalTree(input)); got != want {
+		t.Errorf("MarshalTree() =\n%s\nwant\n%s", got, want)
+	}
+}
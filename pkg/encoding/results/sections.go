@@ -0,0 +1,51 @@
+package results
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"denote/pkg/metadata"
+)
+
+// Section returns the alphabetical section of title: its first letter in
+// upper case, or # for titles that do not start with a letter.
+func Section(title string) string {
+	r, _ := utf8.DecodeRuneInString(title)
+	if !unicode.IsLetter(r) {
+		return "#"
+	}
+	return string(unicode.ToUpper(r))
+}
+
+// SectionHeader returns the line that heads section in MarshalSections
+// output, e.g. "-- A --".
+func SectionHeader(section string) string {
+	return "-- " + section + " --"
+}
+
+// IsSectionHeader reports whether line is a section header. Unmarshal
+// skips such lines.
+func IsSectionHeader(line string) bool {
+	s, ok := strings.CutPrefix(strings.TrimSpace(line), "-- ")
+	if !ok {
+		return false
+	}
+	s, ok = strings.CutSuffix(s, " --")
+	return ok && utf8.RuneCountInString(s) == 1
+}
+
+// MarshalSections is like Marshal, for notes sorted by title, but heads
+// every alphabetical section with a SectionHeader line.
+func MarshalSections(rs metadata.Results) []byte {
+	var buf []byte
+	prev := ""
+	for i, e := range rs {
+		if s := Section(e.Title); s != prev {
+			buf = append(buf, SectionHeader(s)+"\n"...)
+			prev = s
+		}
+		buf = append(buf, Marshal(rs[i:i+1])...)
+	}
+	return buf
+}
@@ -0,0 +1,36 @@
+package results
+
+import (
+	"testing"
+
+	"denote/pkg/metadata"
+)
+
+func TestMarshalSections(t *testing.T) {
+	rs := metadata.Results{
+		{Identifier: "20240101T120000", Title: "2024 plans"},
+		{Identifier: "20240102T120000", Title: "apples", Tags: []string{"food"}},
+		{Identifier: "20240103T120000", Title: "Avocados"},
+		{Identifier: "20240104T120000", Title: "émigrés"},
+	}
+	got := string(MarshalSections(rs))
+	want := "-- # --\n20240101T120000 | 2024 plans | \n" +
+		"-- A --\n20240102T120000 | apples | food\n20240103T120000 | Avocados | \n" +
+		"-- É --\n20240104T120000 | émigrés | \n"
+	if got != want {
+		t.Errorf("MarshalSections() = %q, want %q", got, want)
+	}
+
+	back, err := UnmarshalStrict([]byte(Header + "\n" + got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != len(rs) {
+		t.Errorf("UnmarshalStrict() read %d notes, want %d", len(back), len(rs))
+	}
+	for _, line := range []string{"-- AB --", "--A--", "20240101T120000 | -- A -- | "} {
+		if IsSectionHeader(line) {
+			t.Errorf("IsSectionHeader(%q) = true", line)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package extract
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/ignore"
+	"denote/pkg/metadata"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Foreign walks dir for text notes (.md, .org, .txt) that carry a Denote
+// identifier in their front matter but not in their file name, such as
+// notes created by other tools. Hidden entries and paths listed in
+// dir/.denoteignore are skipped.
+func Foreign(dir string) (metadata.Results, error) {
+	ign, err := ignore.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	var rs metadata.Results
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") || ign.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		md, err := foreignNote(path)
+		if err != nil {
+			return err
+		}
+		if md != nil {
+			rs = append(rs, md)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// foreignNote returns the front matter metadata of path if it is a text
+// note with a front matter identifier and a non-Denote file name.
+func foreignNote(path string) (*metadata.Metadata, error) {
+	if metadata.ParseFilename(path).Identifier != "" {
+		return nil, nil
+	}
+	return FromFrontMatter(path)
+}
+
+// FromFrontMatter returns the metadata recorded in the front matter of
+// the text note (.md, .org, .txt) at path. If the front matter has no
+// valid identifier, the one in the file name is used. It returns nil for
+// other files and for notes without front matter or an identifier.
+func FromFrontMatter(path string) (*metadata.Metadata, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".md" && ext != ".org" && ext != ".txt" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, _, err := frontmatter.Unmarshal(content, ext)
+	if err != nil || (fm.Identifier == "" && fm.Title == "" && len(fm.Tags) == 0) {
+		return nil, nil // no front matter
+	}
+	id := fm.Identifier
+	if !metadata.IsIdentifier(id) {
+		id = metadata.ParseFilename(path).Identifier
+	}
+	if id == "" {
+		return nil, nil
+	}
+	return &metadata.Metadata{
+		Path:       path,
+		Identifier: id,
+		Signature:  fm.Signature,
+		Title:      fm.Title,
+		Tags:       fm.Tags,
+	}, nil
+}
+
+// AdoptedPath returns the Denote file name md would have in its current
+// directory.
+func AdoptedPath(md *metadata.Metadata) string {
+	fm := metadata.NewFrontMatter(md.Title, md.Signature, md.Tags, md.Identifier)
+	return filepath.Join(filepath.Dir(md.Path), metadata.BuildFilename(fm, filepath.Ext(md.Path)))
+}
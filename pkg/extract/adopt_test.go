@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForeign(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"obsidian/Meeting Notes.md": "---\ntitle: \"Meeting Notes\"\ntags: [\"work\"]\nidentifier: \"20250101T090000\"\n---\n",
+		"ideas.org":                 "#+title: Ideas\n#+identifier: 20250102T090000\n",
+		"no-id.md":                  "---\ntitle: \"x\"\n---\n",
+		"bad-id.txt":                "title: x\nidentifier: yesterday\n",
+		"20250103T090000--named.md": "---\nidentifier: \"20250103T090000\"\n---\n",
+		".hidden/x.md":              "---\nidentifier: \"20250104T090000\"\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rs, err := Foreign(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("Foreign() returned %d notes, want 2: %v", len(rs), rs)
+	}
+	want := map[string]string{
+		filepath.Join(dir, "ideas.org"):                 filepath.Join(dir, "20250102T090000--ideas.org"),
+		filepath.Join(dir, "obsidian/Meeting Notes.md"): filepath.Join(dir, "obsidian/20250101T090000--meeting-notes__work.md"),
+	}
+	for _, md := range rs {
+		if got := AdoptedPath(md); got != want[md.Path] {
+			t.Errorf("AdoptedPath(%s) = %s, want %s", md.Path, got, want[md.Path])
+		}
+	}
+}
+
+func TestFromFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20250101T090000--old-title__a.md": "---\ntitle: \"New Title\"\ntags: [\"b\", \"c\"]\nidentifier: \"20250101T090000\"\n---\n",
+		"20250102T090000--kept.org":        "#+title: Edited\n",
+		"20250103T090000--empty.md":        "no front matter\n",
+		"image.png":                        "png",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{
+		"20250101T090000--old-title__a.md": "20250101T090000--new-title__b_c.md",
+		"20250102T090000--kept.org":        "20250102T090000--edited.org",
+		"20250103T090000--empty.md":        "",
+		"image.png":                        "",
+	}
+	for name, wantName := range want {
+		md, err := FromFrontMatter(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if md != nil {
+			got = filepath.Base(AdoptedPath(md))
+		}
+		if got != wantName {
+			t.Errorf("FromFrontMatter(%s): renamed to %q, want %q", name, got, wantName)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// EPUBTitle returns the dc:title of an EPUB's package document (OPF).
+func EPUBTitle(content []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("invalid epub: %w", err)
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := decodeZipXML(zr, "META-INF/container.xml", &container); err != nil {
+		return "", err
+	}
+	if len(container.Rootfiles) == 0 {
+		return "", fmt.Errorf("invalid epub: no rootfile in container.xml")
+	}
+
+	var opf struct {
+		Titles []string `xml:"metadata>title"`
+	}
+	if err := decodeZipXML(zr, container.Rootfiles[0].FullPath, &opf); err != nil {
+		return "", err
+	}
+	if len(opf.Titles) == 0 {
+		return "", nil
+	}
+	return opf.Titles[0], nil
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("invalid epub: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid epub: %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package extract reads document titles from files that carry no Denote
+// front matter, such as PDFs, web pages, and e-books, so that imported
+// documents can be listed under their real titles.
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extractor returns the title stored in a document's content, or "" if it
+// has none.
+type Extractor func(content []byte) (string, error)
+
+var extractors = map[string]Extractor{
+	".pdf":  PDFTitle,
+	".html": HTMLTitle,
+	".htm":  HTMLTitle,
+	".epub": EPUBTitle,
+}
+
+// Register installs fn as the title extractor for files with extension
+// ext (including the dot), replacing any existing one.
+func Register(ext string, fn Extractor) {
+	extractors[strings.ToLower(ext)] = fn
+}
+
+// Supported reports whether a title extractor exists for path.
+func Supported(path string) bool {
+	_, ok := extractors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Title returns the title stored in the file at path. It returns "" and
+// no error if the format is unsupported or the document has no title.
+func Title(path string) (string, error) {
+	fn, ok := extractors[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	title, err := fn(content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return strings.Join(strings.Fields(title), " "), nil
+}
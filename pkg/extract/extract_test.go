@@ -0,0 +1,94 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTMLTitle(t *testing.T) {
+	got, err := HTMLTitle([]byte("<html><head><TITLE lang=en>Go &amp; Plan 9</TITLE></head></html>"))
+	if err != nil || got != "Go & Plan 9" {
+		t.Errorf("HTMLTitle() = %q, %v", got, err)
+	}
+	if got, _ := HTMLTitle([]byte("<p>no title</p>")); got != "" {
+		t.Errorf("HTMLTitle() without title = %q", got)
+	}
+}
+
+func TestPDFTitle(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"literal", "%PDF-1.4\n1 0 obj << /Title (The \\(Unix\\) Way) /Author (K) >> endobj", "The (Unix) Way"},
+		{"octal", "%PDF-1.4\n<< /Title (Caf\\351) >>", "Café"},
+		{"utf16", "%PDF-1.7\n<< /Title <FEFF0047006F> >>", "Go"},
+		{"incremental update", "%PDF-1.4\n<< /Title (old) >>\n<< /Title (new) >>", "new"},
+		{"xmp", "%PDF-1.4\n<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">From XMP</rdf:li></rdf:Alt></dc:title>", "From XMP"},
+		{"none", "%PDF-1.4\n<< /Author (K) >>", ""},
+		{"not a pdf", "/Title (nope)", ""},
+	}
+	for _, tt := range tests {
+		got, err := PDFTitle([]byte(tt.content))
+		if err != nil || got != tt.want {
+			t.Errorf("%s: PDFTitle() = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestEPUBTitle(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <metadata><dc:title>The Practice of Programming</dc:title><dc:creator>Kernighan</dc:creator></metadata>
+</package>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := EPUBTitle(buf.Bytes())
+	if err != nil || got != "The Practice of Programming" {
+		t.Errorf("EPUBTitle() = %q, %v", got, err)
+	}
+	if _, err := EPUBTitle([]byte("not a zip")); err == nil {
+		t.Error("EPUBTitle() of garbage succeeded")
+	}
+}
+
+func TestTitle(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "20250101T000000--page.HTML")
+	if err := os.WriteFile(page, []byte("<title>\n  Spaced\n  Out\n</title>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Title(page); err != nil || got != "Spaced Out" {
+		t.Errorf("Title(html) = %q, %v", got, err)
+	}
+	if got, err := Title(filepath.Join(dir, "missing.md")); err != nil || got != "" {
+		t.Errorf("Title(unsupported) = %q, %v", got, err)
+	}
+
+	Register(".test", func([]byte) (string, error) { return "custom", nil })
+	custom := filepath.Join(dir, "x.test")
+	os.WriteFile(custom, nil, 0644)
+	if got, _ := Title(custom); got != "custom" || !Supported(custom) {
+		t.Errorf("Title(registered) = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+package extract
+
+import (
+	"html"
+	"regexp"
+)
+
+var htmlTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// HTMLTitle returns the contents of an HTML document's <title> element.
+func HTMLTitle(content []byte) (string, error) {
+	m := htmlTitleRe.FindSubmatch(content)
+	if m == nil {
+		return "", nil
+	}
+	return html.UnescapeString(string(m[1])), nil
+}
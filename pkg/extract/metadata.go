@@ -0,0 +1,60 @@
+package extract
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Metadata returns the metadata of the note at path. Fields come from the
+// file name, overridden by any front matter title, tags, signature, and
+// identifier, so notes renamed by other tools are still described
+// correctly, and a valid due field sets Due. Text notes without a title
+// fall back to fallback; other documents to their embedded title (see
+// Title).
+func Metadata(path string, fallback Fallback) (*metadata.Metadata, error) {
+	md := metadata.ParseFilename(path)
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".md", ".org", ".txt":
+	default:
+		if md.Title == "" && Supported(path) {
+			title, err := Title(path)
+			if err != nil {
+				return nil, err
+			}
+			md.Title = title
+		}
+		return md, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, _, err := frontmatter.Unmarshal(content, ext)
+	if err != nil {
+		return nil, err
+	}
+	if fm.Identifier != "" {
+		md.Identifier = fm.Identifier
+	}
+	if fm.Signature != "" {
+		md.Signature = fm.Signature
+	}
+	if len(fm.Tags) > 0 {
+		md.Tags = fm.Tags
+	}
+	if due, err := metadata.ParseDue(fm.Due, time.Local); err == nil {
+		md.Due = due
+	}
+	if fm.Title != "" {
+		md.Title = fm.Title
+	} else if title := TextTitle(content, ext, fallback); title != "" {
+		md.Title = title
+	}
+	return md, nil
+}
@@ -0,0 +1,60 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Renamed outside Denote: the file name lost its signature and tags.
+	path := write("20250101T120000--old-name.md", `---
+title:      "Current Title"
+date:       2025-01-01
+tags:       ["go", "notes"]
+identifier: "20250101T120000"
+signature:  "1a"
+---
+
+Body
+`)
+	md, err := Metadata(path, FallbackNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Title != "Current Title" || md.Signature != "1a" || !slices.Equal(md.Tags, []string{"go", "notes"}) {
+		t.Errorf("Metadata() = %+v", md)
+	}
+	if md.Path != path || md.Identifier != "20250101T120000" {
+		t.Errorf("Metadata() path/identifier = %q, %q", md.Path, md.Identifier)
+	}
+
+	// No front matter: file name fields are kept.
+	path = write("20250102T120000==2--from-name__a.txt", "# Heading\n")
+	md, err = Metadata(path, FallbackNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Title != "from name" || md.Signature != "2" || !slices.Equal(md.Tags, []string{"a"}) {
+		t.Errorf("Metadata() without front matter = %+v", md)
+	}
+	if md, _ = Metadata(path, FallbackHeading); md.Title != "Heading" {
+		t.Errorf("Metadata() with heading fallback title = %q", md.Title)
+	}
+
+	// Documents without a title in the name use their embedded title.
+	path = write("20250103T120000__paper.html", "<title>Embedded</title>")
+	if md, err = Metadata(path, FallbackNone); err != nil || md.Title != "Embedded" {
+		t.Errorf("Metadata(html) = %+v, %v", md, err)
+	}
+}
@@ -0,0 +1,140 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/hex"
+	"regexp"
+	"unicode/utf16"
+)
+
+var (
+	pdfTitleRe = regexp.MustCompile(`/Title\s*(\(|<)`)
+	xmpTitleRe = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// PDFTitle returns the /Title entry of a PDF's document information
+// dictionary, falling back to the dc:title of its XMP metadata. Both are
+// found by scanning the raw file, so titles stored only inside compressed
+// object streams are not found.
+func PDFTitle(content []byte) (string, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return "", nil
+	}
+	// The last /Title wins, since incremental updates append to the file.
+	locs := pdfTitleRe.FindAllSubmatchIndex(content, -1)
+	for i := len(locs) - 1; i >= 0; i-- {
+		start := locs[i][2]
+		var raw []byte
+		if content[start] == '(' {
+			raw = pdfLiteralString(content[start+1:])
+		} else {
+			raw = pdfHexString(content[start+1:])
+		}
+		if title := pdfTextString(raw); title != "" {
+			return title, nil
+		}
+	}
+	if m := xmpTitleRe.FindSubmatch(content); m != nil {
+		return string(m[1]), nil
+	}
+	return "", nil
+}
+
+// pdfLiteralString decodes a (...) string body, handling nested
+// parentheses and backslash escapes.
+func pdfLiteralString(b []byte) []byte {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(b) {
+				return out
+			}
+			switch e := b[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for j := 0; j < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; j++ {
+						n = n*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					out = append(out, byte(n))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// pdfHexString decodes a <...> string body.
+func pdfHexString(b []byte) []byte {
+	end := bytes.IndexByte(b, '>')
+	if end < 0 {
+		return nil
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, b[:end])
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil
+	}
+	return out
+}
+
+// pdfTextString converts a PDF text string, which is UTF-16BE when it
+// starts with a byte order mark and PDFDocEncoding (close enough to
+// Latin-1 for titles) otherwise.
+func pdfTextString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		b = b[2:]
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		}
+		return string(utf16.Decode(u))
+	}
+	if bytes.HasPrefix(b, []byte("\xef\xbb\xbf")) {
+		return string(b[3:])
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
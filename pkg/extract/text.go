@@ -0,0 +1,52 @@
+package extract
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/util"
+	"regexp"
+	"strings"
+)
+
+// Fallback selects how TextTitle derives a title for a text note whose
+// front matter has none.
+type Fallback string
+
+const (
+	FallbackNone    Fallback = ""        // no title
+	FallbackLine    Fallback = "line"    // first non-empty line
+	FallbackHeading Fallback = "heading" // first markdown or org heading
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(?:#{1,6}|\*+)[ \t]+(.+?)(?:[ \t]+#+)?[ \t]*$`)
+	markerRe  = regexp.MustCompile(`^(?:#{1,6}|\*+|[-+>])[ \t]+`)
+)
+
+// TextTitle returns the display title of a .md, .org, or .txt note: the
+// front matter title if there is one, otherwise the title chosen by
+// fallback from the body. Heading and list markers are removed from a
+// first line used as the title.
+func TextTitle(content []byte, ext string, fallback Fallback) string {
+	fm, fileType, _ := frontmatter.Unmarshal(content, ext)
+	if fm.Title != "" {
+		return fm.Title
+	}
+	body := util.StripFrontMatter(string(content), fileType)
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		switch fallback {
+		case FallbackHeading:
+			if m := headingRe.FindStringSubmatch(line); m != nil {
+				return m[1]
+			}
+		case FallbackLine:
+			return markerRe.ReplaceAllString(line, "")
+		default:
+			return ""
+		}
+	}
+	return ""
+}
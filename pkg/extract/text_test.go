@@ -0,0 +1,28 @@
+package extract
+
+import "testing"
+
+func TestTextTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		ext      string
+		fallback Fallback
+		want     string
+	}{
+		{"front matter wins", "---\ntitle: \"Real\"\n---\n\n# Heading\n", ".md", FallbackHeading, "Real"},
+		{"no fallback", "# Heading\n", ".md", FallbackNone, ""},
+		{"md heading", "intro text\n\n## Second Level ##\n", ".md", FallbackHeading, "Second Level"},
+		{"org heading", "some text\n** Org Heading\n", ".org", FallbackHeading, "Org Heading"},
+		{"no heading", "just text\n", ".md", FallbackHeading, ""},
+		{"first line", "\n\n  Shopping list  \n- milk\n", ".txt", FallbackLine, "Shopping list"},
+		{"first line strips marker", "# Heading\n", ".md", FallbackLine, "Heading"},
+		{"first line after org keywords", "#+filetags: :a:\n\nBody\n", ".org", FallbackLine, "Body"},
+		{"empty", "", ".txt", FallbackLine, ""},
+	}
+	for _, tt := range tests {
+		if got := TextTitle([]byte(tt.content), tt.ext, tt.fallback); got != tt.want {
+			t.Errorf("%s: TextTitle() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package feed generates Atom feeds of notes.
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Entry is a note published in a feed.
+type Entry struct {
+	Identifier string
+	Title      string
+	Tags       []string
+	Link       string // optional URL of the published note
+	Published  time.Time
+	Updated    time.Time
+	HTML       string // rendered body
+}
+
+// Feed describes the feed as a whole.
+type Feed struct {
+	Title  string
+	Link   string
+	Author string
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       *atomLink      `xml:"link,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+// EntryID is the stable Atom id of a note. It depends only on the
+// identifier, so renaming or retagging a note keeps its feed entry.
+func EntryID(identifier string) string {
+	return "denote:" + identifier
+}
+
+// Atom renders f and entries as an Atom 1.0 document. The feed's updated
+// time is the latest entry update.
+func Atom(f Feed, entries []Entry) ([]byte, error) {
+	af := atomFeed{Title: f.Title, ID: f.Link}
+	if af.ID == "" {
+		af.ID = "denote:feed:" + f.Title
+	}
+	if f.Link != "" {
+		af.Link = &atomLink{Href: f.Link}
+	}
+	if f.Author != "" {
+		af.Author = &atomAuthor{Name: f.Author}
+	}
+
+	var latest time.Time
+	for _, e := range entries {
+		if e.Updated.After(latest) {
+			latest = e.Updated
+		}
+		ae := atomEntry{
+			Title:     e.Title,
+			ID:        EntryID(e.Identifier),
+			Published: e.Published.Format(time.RFC3339),
+			Updated:   e.Updated.Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Body: e.HTML},
+		}
+		if e.Link != "" {
+			ae.Link = &atomLink{Href: e.Link, Rel: "alternate"}
+		}
+		for _, tag := range e.Tags {
+			ae.Categories = append(ae.Categories, atomCategory{Term: tag})
+		}
+		af.Entries = append(af.Entries, ae)
+	}
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+	af.Updated = latest.Format(time.RFC3339)
+
+	out, err := xml.MarshalIndent(af, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
@@ -0,0 +1,59 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtom(t *testing.T) {
+	published := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	updated := published.Add(48 * time.Hour)
+	entries := []Entry{
+		{
+			Identifier: "20240101T120000",
+			Title:      "Hello & welcome",
+			Tags:       []string{"blog", "intro"},
+			Link:       "https://example.com/20240101T120000.html",
+			Published:  published,
+			Updated:    updated,
+			HTML:       "<p>first post</p>\n",
+		},
+		{
+			Identifier: "20231201T120000",
+			Title:      "Older",
+			Published:  published.AddDate(0, -1, 0),
+			Updated:    published.AddDate(0, -1, 0),
+			HTML:       "<p>older</p>\n",
+		},
+	}
+
+	out, err := Atom(Feed{Title: "Notes", Link: "https://example.com/", Author: "lkn"}, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<updated>2024-01-03T12:00:00Z</updated>`,
+		`<id>denote:20240101T120000</id>`,
+		`<title>Hello &amp; welcome</title>`,
+		`<category term="blog"></category>`,
+		`<content type="html">&lt;p&gt;first post&lt;/p&gt;`,
+		`<link href="https://example.com/20240101T120000.html" rel="alternate"></link>`,
+		`<name>lkn</name>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Atom() missing %q:\n%s", want, s)
+		}
+	}
+
+	var parsed atomFeed
+	if err := xml.Unmarshal(out, &parsed); err != nil {
+		t.Fatalf("Atom() produced invalid XML: %v", err)
+	}
+	if len(parsed.Entries) != 2 {
+		t.Errorf("parsed %d entries, want 2", len(parsed.Entries))
+	}
+}
@@ -0,0 +1,366 @@
+// Package fulltext maintains an inverted index of note bodies for fast
+// word search and relevance ranking. Front matter is not indexed. The
+// index is updated incrementally: only notes whose modification time
+// changed since the last update are re-read.
+package fulltext
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// formatVersion is the version of the saved index. Indexes saved with
+// another version are not loaded.
+const formatVersion = 2
+
+// Index maps terms to the notes that contain them.
+type Index struct {
+	Version  int
+	Docs     map[string]Doc            // path -> document info
+	Postings map[string]map[string]int // term -> path -> occurrences
+}
+
+// Doc records what was indexed for a single note.
+type Doc struct {
+	ModTime time.Time
+	Terms   []string // distinct terms, for removal
+}
+
+// Hit is a search result.
+type Hit struct {
+	Path  string
+	Score float64
+}
+
+// New returns an empty Index.
+func New() *Index {
+	return &Index{
+		Version:  formatVersion,
+		Docs:     make(map[string]Doc),
+		Postings: make(map[string]map[string]int),
+	}
+}
+
+// Tokenize splits text into lowercase words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes text as the content of path, replacing any previous entry.
+func (ix *Index) Add(path string, modTime time.Time, text string) {
+	ix.Remove(path)
+	counts := make(map[string]int)
+	for _, term := range Tokenize(text) {
+		counts[term]++
+	}
+	doc := Doc{ModTime: modTime}
+	for term, n := range counts {
+		if ix.Postings[term] == nil {
+			ix.Postings[term] = make(map[string]int)
+		}
+		ix.Postings[term][path] = n
+		doc.Terms = append(doc.Terms, term)
+	}
+	ix.Docs[path] = doc
+}
+
+// Remove drops path from the index.
+func (ix *Index) Remove(path string) {
+	doc, ok := ix.Docs[path]
+	if !ok {
+		return
+	}
+	for _, term := range doc.Terms {
+		delete(ix.Postings[term], path)
+		if len(ix.Postings[term]) == 0 {
+			delete(ix.Postings, term)
+		}
+	}
+	delete(ix.Docs, path)
+}
+
+// Update brings the index in line with the text notes (.md, .org, .txt)
+// in dir, re-reading only new or modified files and dropping deleted ones.
+// It reports whether the index changed. Notes that cannot be read are
+// skipped, keeping what was indexed for them; the second result says why
+// for each.
+func (ix *Index) Update(dir string) (bool, []error, error) {
+	rs, err := metadata.ScanDir(dir)
+	if err != nil {
+		return false, nil, err
+	}
+	changed := false
+	var skipped []error
+	seen := make(map[string]bool, len(rs))
+	for _, md := range rs {
+		if !isText(md.Path) {
+			continue
+		}
+		seen[md.Path] = true
+		c, err := ix.update(md.Path)
+		if err != nil {
+			skipped = append(skipped, err)
+		}
+		changed = changed || c
+	}
+	for path := range ix.Docs {
+		if !seen[path] {
+			ix.Remove(path)
+			changed = true
+		}
+	}
+	return changed, skipped, nil
+}
+
+// UpdateFile re-reads the note at path if it is in the index and was
+// modified, or drops it if it no longer exists, reporting whether the
+// index changed. Notes not in the index are left to Update.
+func (ix *Index) UpdateFile(path string) (bool, error) {
+	if _, ok := ix.Docs[path]; !ok {
+		return false, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		ix.Remove(path)
+		return true, nil
+	}
+	return ix.update(path)
+}
+
+// update indexes the body of the note at path unless its modification
+// time is the one indexed, reporting whether it did.
+func (ix *Index) update(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if doc, ok := ix.Docs[path]; ok && doc.ModTime.Equal(info.ModTime()) {
+		return false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	ix.Add(path, info.ModTime(), frontmatter.Body(content, filepath.Ext(path)))
+	return true, nil
+}
+
+// Search returns the notes containing every word of query, best first.
+// Scores are TF-IDF sums, so rare words weigh more than common ones.
+// Ties are broken by path, newest identifier first.
+func (ix *Index) Search(query string) []Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	scores := make(map[string]float64)
+	for i, term := range terms {
+		postings := ix.Postings[term]
+		idf := math.Log(1 + float64(len(ix.Docs))/float64(1+len(postings)))
+		next := make(map[string]float64)
+		for path, n := range postings {
+			if _, ok := scores[path]; i > 0 && !ok {
+				continue
+			}
+			next[path] = scores[path] + float64(n)*idf
+		}
+		scores = next
+	}
+	hits := make([]Hit, 0, len(scores))
+	for path, score := range scores {
+		hits = append(hits, Hit{Path: path, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return filepath.Base(hits[i].Path) > filepath.Base(hits[j].Path)
+	})
+	return hits
+}
+
+// Keyword is a term characteristic of a note.
+type Keyword struct {
+	Term  string
+	Score float64
+}
+
+// stopwords are common English words never proposed as keywords, which
+// TF-IDF alone does not rule out in a small collection.
+var stopwords = map[string]bool{
+	"about": true, "after": true, "again": true, "also": true, "and": true,
+	"are": true, "because": true, "been": true, "before": true, "but": true,
+	"can": true, "could": true, "did": true, "does": true, "each": true,
+	"for": true, "from": true, "had": true, "has": true, "have": true,
+	"her": true, "his": true, "how": true, "into": true, "its": true,
+	"just": true, "like": true, "more": true, "most": true, "not": true,
+	"now": true, "one": true, "only": true, "other": true, "our": true,
+	"out": true, "over": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "those": true,
+	"through": true, "very": true, "was": true, "were": true, "what": true,
+	"when": true, "where": true, "which": true, "while": true, "who": true,
+	"why": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true,
+}
+
+// Keywords returns up to n terms of the note at path that best set it
+// apart from the rest of the index, best first: terms frequent in the
+// note but rare elsewhere (TF-IDF). Stopwords, numbers, and words of
+// fewer than three letters are skipped. Ties are broken alphabetically.
+func (ix *Index) Keywords(path string, n int) []Keyword {
+	doc, ok := ix.Docs[path]
+	if !ok {
+		return nil
+	}
+	var kws []Keyword
+	for _, term := range doc.Terms {
+		if len([]rune(term)) < 3 || stopwords[term] || strings.IndexFunc(term, unicode.IsLetter) < 0 {
+			continue
+		}
+		postings := ix.Postings[term]
+		idf := math.Log(1 + float64(len(ix.Docs))/float64(len(postings)))
+		kws = append(kws, Keyword{Term: term, Score: float64(postings[path]) * idf})
+	}
+	sort.Slice(kws, func(i, j int) bool {
+		if kws[i].Score != kws[j].Score {
+			return kws[i].Score > kws[j].Score
+		}
+		return kws[i].Term < kws[j].Term
+	})
+	return kws[:min(n, len(kws))]
+}
+
+// Snippet is a line of a note that contains a search word.
+type Snippet struct {
+	Line int    // 1-based line number
+	Text string // the line, shortened around the first match
+}
+
+// snippetWidth bounds the length of a snippet in runes.
+const snippetWidth = 80
+
+// Snippets returns up to max lines of text containing a word of query,
+// in order, so that search results can show where a note matches.
+func Snippets(text, query string, max int) []Snippet {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	var snippets []Snippet
+	for i, line := range strings.Split(text, "\n") {
+		if len(snippets) == max {
+			break
+		}
+		pos := -1
+		lower := strings.ToLower(line)
+		for _, term := range Tokenize(line) {
+			if slices.Contains(terms, term) {
+				pos = strings.Index(lower, term)
+				break
+			}
+		}
+		if pos < 0 {
+			continue
+		}
+		snippets = append(snippets, Snippet{Line: i + 1, Text: shorten(line, pos)})
+	}
+	return snippets
+}
+
+// Grep returns the lines of text that re matches, in order.
+func Grep(text string, re *regexp.Regexp) []Snippet {
+	var snippets []Snippet
+	for i, line := range strings.Split(text, "\n") {
+		if loc := re.FindStringIndex(line); loc != nil {
+			snippets = append(snippets, Snippet{Line: i + 1, Text: shorten(line, loc[0])})
+		}
+	}
+	return snippets
+}
+
+// shorten trims line to snippetWidth runes, keeping the byte offset pos
+// in view.
+func shorten(line string, pos int) string {
+	line = strings.TrimRight(line, "\r")
+	runes := []rune(line)
+	if len(runes) <= snippetWidth {
+		return strings.TrimSpace(line)
+	}
+	at := len([]rune(line[:min(pos, len(line))]))
+	start := max(0, min(at-snippetWidth/4, len(runes)-snippetWidth))
+	s := strings.TrimSpace(string(runes[start : start+snippetWidth]))
+	if start > 0 {
+		s = "…" + s
+	}
+	if start+snippetWidth < len(runes) {
+		s += "…"
+	}
+	return s
+}
+
+// Load reads an index saved with Save. A missing file yields an empty
+// index, and one saved in another format version an error.
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ix := New()
+	ix.Version = 0
+	if err := gob.NewDecoder(f).Decode(ix); err != nil {
+		return nil, err
+	}
+	if ix.Version != formatVersion {
+		return nil, fmt.Errorf("full-text index %s has format version %d, want %d", path, ix.Version, formatVersion)
+	}
+	return ix, nil
+}
+
+// Save writes the index to path, creating parent directories as needed.
+// The index is written to a temporary file renamed into place, so that
+// processes saving at the same time never interleave their writes.
+func (ix *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := gob.NewEncoder(f).Encode(ix); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func isText(path string) bool {
+	switch filepath.Ext(path) {
+	case ".md", ".org", ".txt":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package fulltext
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Hello, World! go1.22 naïve")
+	want := []string{"hello", "world", "go1", "22", "naïve"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := New()
+	now := time.Now()
+	ix.Add("a", now, "channels and goroutines")
+	ix.Add("b", now, "goroutines goroutines leak")
+	ix.Add("c", now, "shopping list")
+
+	hits := ix.Search("goroutines")
+	if len(hits) != 2 || hits[0].Path != "b" || hits[1].Path != "a" {
+		t.Errorf("Search(goroutines) = %v, want [b a]", hits)
+	}
+	if hits := ix.Search("Channels GOROUTINES"); len(hits) != 1 || hits[0].Path != "a" {
+		t.Errorf("Search(channels goroutines) = %v, want [a]", hits)
+	}
+	if hits := ix.Search("missing"); len(hits) != 0 {
+		t.Errorf("Search(missing) = %v, want none", hits)
+	}
+
+	ix.Add("b", now, "nothing here")
+	if hits := ix.Search("leak"); len(hits) != 0 {
+		t.Errorf("Search(leak) after re-add = %v, want none", hits)
+	}
+	ix.Remove("a")
+	if _, ok := ix.Postings["channels"]; ok {
+		t.Error("Remove left empty posting list behind")
+	}
+}
+
+func TestUpdateAndPersist(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "20251112T221141--go__dev.md")
+	other := filepath.Join(dir, "20251112T221142--pdf.pdf")
+	if err := os.WriteFile(note, []byte("select statements"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(other, []byte("select"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ix := New()
+	if changed, _, err := ix.Update(dir); err != nil || !changed {
+		t.Fatalf("Update() = %v, %v, want true", changed, err)
+	}
+	if changed, _, err := ix.Update(dir); err != nil || changed {
+		t.Fatalf("second Update() = %v, %v, want false", changed, err)
+	}
+	if hits := ix.Search("select"); len(hits) != 1 || hits[0].Path != note {
+		t.Fatalf("Search(select) = %v, want [%s]", hits, note)
+	}
+
+	cache := filepath.Join(t.TempDir(), "sub", "index.gob")
+	if err := ix.Save(cache); err != nil {
+		t.Fatal(err)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(filepath.Dir(cache), "*.tmp")); len(tmps) != 0 {
+		t.Errorf("Save left temporary files %v", tmps)
+	}
+	ix, err := Load(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits := ix.Search("statements"); len(hits) != 1 {
+		t.Errorf("Search after Load = %v, want 1 hit", hits)
+	}
+
+	if err := os.Remove(note); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _, err := ix.Update(dir); err != nil || !changed {
+		t.Fatalf("Update() after delete = %v, %v, want true", changed, err)
+	}
+	if len(ix.Docs) != 0 || len(ix.Postings) != 0 {
+		t.Errorf("Update after delete left %d docs, %d terms", len(ix.Docs), len(ix.Postings))
+	}
+}
+
+func TestUpdateBodiesOnly(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "20251112T221141--go__dev.md")
+	content := "---\ntitle: Concurrency\ntags: [dev]\n---\n\nselect statements\n"
+	if err := os.WriteFile(note, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unreadable := filepath.Join(dir, "20251112T221142--secret.md")
+	if err := os.WriteFile(unreadable, []byte("select"), 0); err != nil {
+		t.Fatal(err)
+	}
+	_, readErr := os.ReadFile(unreadable) // root reads it anyway
+
+	ix := New()
+	changed, skipped, err := ix.Update(dir)
+	if err != nil || !changed {
+		t.Fatalf("Update() = %v, %v, want true", changed, err)
+	}
+	if readErr != nil {
+		if len(skipped) != 1 {
+			t.Errorf("Update() skipped %v, want the unreadable note", skipped)
+		}
+		if hits := ix.Search("select"); len(hits) != 1 {
+			t.Errorf("Search(select) = %v, want the readable note", hits)
+		}
+	}
+	for _, q := range []string{"concurrency", "dev", "title"} {
+		if hits := ix.Search(q); len(hits) != 0 {
+			t.Errorf("Search(%s) = %v, want front matter not indexed", q, hits)
+		}
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(note, []byte("---\ntitle: x\n---\nchannels\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(note, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ix.UpdateFile(note); err != nil || !changed {
+		t.Fatalf("UpdateFile() = %v, %v, want true", changed, err)
+	}
+	if hits := ix.Search("channels"); len(hits) != 1 {
+		t.Errorf("Search(channels) after UpdateFile = %v, want the note", hits)
+	}
+	if changed, err := ix.UpdateFile(filepath.Join(dir, "20251112T221143--new.md")); err != nil || changed {
+		t.Errorf("UpdateFile(not indexed) = %v, %v, want false", changed, err)
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	ix := New()
+	now := time.Now()
+	ix.Add("a", now, "the goroutines and the channels: goroutines leak, 2024 go")
+	ix.Add("b", now, "the channels of the shopping list")
+	ix.Add("c", now, "the garden")
+
+	var got []string
+	for _, kw := range ix.Keywords("a", 3) {
+		got = append(got, kw.Term)
+	}
+	if want := []string{"goroutines", "leak", "channels"}; !slices.Equal(got, want) {
+		t.Errorf("Keywords(a) = %v, want %v", got, want)
+	}
+	if kws := ix.Keywords("missing", 3); kws != nil {
+		t.Errorf("Keywords(missing) = %v, want none", kws)
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	text := "---\ntitle: Go notes\n---\n\nChannels are typed.\nA goroutine leaks\r\n" + strings.Repeat("x ", 60) + "goroutine " + strings.Repeat("y ", 60) + "\n"
+	got := Snippets(text, "GOROUTINE channels", 10)
+	if len(got) != 3 {
+		t.Fatalf("Snippets() = %q, want 3", got)
+	}
+	if got[0] != (Snippet{Line: 5, Text: "Channels are typed."}) || got[1] != (Snippet{Line: 6, Text: "A goroutine leaks"}) {
+		t.Errorf("Snippets() = %q", got)
+	}
+	if long := got[2]; long.Line != 7 || !strings.Contains(long.Text, "goroutine") || !strings.HasPrefix(long.Text, "…") || !strings.HasSuffix(long.Text, "…") {
+		t.Errorf("long line snippet = %+v", long)
+	}
+	if got := Snippets(text, "goroutine", 1); len(got) != 1 || got[0].Line != 6 {
+		t.Errorf("Snippets(max 1) = %q", got)
+	}
+	if got := Snippets(text, "go", 10); len(got) != 1 || got[0].Line != 2 {
+		t.Errorf("Snippets(go) = %q, want whole words only", got)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	got := Grep("first line\nTODO: fix\nnothing\n  todo later\n", regexp.MustCompile(`(?i)todo`))
+	want := []Snippet{{Line: 2, Text: "TODO: fix"}, {Line: 4, Text: "todo later"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Grep() = %q, want %q", got, want)
+	}
+}
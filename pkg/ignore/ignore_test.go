@@ -0,0 +1,67 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	m, err := Parse(strings.NewReader(`# build output
+*.log
+attachments/
+/private
+docs/**/draft-*
+!keep.log
+\#literal
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"20250101T000000--note.md", false, false},
+		{"build.log", false, true},
+		{"sub/dir/build.log", false, true},
+		{"keep.log", false, false},
+		{"attachments", true, true},
+		{"attachments", false, false},
+		{"journal/attachments/20250101T000000--scan.pdf", false, true},
+		{"private", true, true},
+		{"private/20250101T000000--secret.md", false, true},
+		{"journal/private", true, false},
+		{"docs/draft-a.md", false, true},
+		{"docs/x/y/draft-b.md", false, true},
+		{"notes/draft-c.md", false, false},
+		{"#literal", false, true},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	if err != nil || m != nil {
+		t.Fatalf("Load() without file = %v, %v; want nil, nil", m, err)
+	}
+	if m.Ignored("anything", false) {
+		t.Error("nil Matcher ignored a path")
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("tmp/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Ignored("tmp/x.md", false) {
+		t.Error("Ignored(tmp/x.md) = false, want true")
+	}
+}
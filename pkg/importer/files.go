@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/extract"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var separatorRe = regexp.MustCompile(`[-_.\s]+`)
+
+// FileTitle returns the title for a plain file: the title stored in a
+// text note's front matter or first heading, or in a PDF, HTML, or EPUB
+// file, and otherwise the file name with separators turned into spaces.
+func FileTitle(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".md", ".org", ".txt":
+		if content, err := os.ReadFile(path); err == nil {
+			if title := extract.TextTitle(content, ext, extract.FallbackHeading); title != "" {
+				return title
+			}
+		}
+	default:
+		if extract.Supported(path) {
+			if title, err := extract.Title(path); err == nil && title != "" {
+				return title
+			}
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.TrimSpace(separatorRe.ReplaceAllString(name, " "))
+}
+
+// Adopt turns the plain file at path into a denote note in dir and
+// returns its new path. The identifier comes from the file's
+// modification time and is allocated from ids. Text notes (.md, .org,
+// .txt) get front matter, keeping the title and tags of any they already
+// have; .md files without front matter get mdType's. tags are added to
+// the note's tags.
+//
+// A file already inside dir is renamed in place; any other file is
+// copied into dir and left as it is.
+func Adopt(path, dir string, tags []string, mdType metadata.FileType, ids Identifiers) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	fm := metadata.NewFrontMatter(FileTitle(path), "", nil, ids.Next(info.ModTime()))
+
+	var content []byte
+	if ext == ".md" || ext == ".org" || ext == ".txt" {
+		if content, err = os.ReadFile(path); err != nil {
+			return "", err
+		}
+		old, fileType, _ := frontmatter.Unmarshal(content, ext)
+		fm.Tags = mergeTags(old.Tags, tags)
+		fm.Signature = old.Signature
+		fm.Bib = old.Bib
+		fm.Due = old.Due
+		body := util.StripFrontMatter(string(content), fileType)
+		if fileType == "" {
+			fileType = textFileType(ext, mdType)
+		}
+		content = append(frontmatter.MarshalAt(fm, fileType, info.ModTime()), body...)
+	} else {
+		fm.Tags = mergeTags(nil, tags)
+	}
+
+	inDir := util.Within(dir, path)
+	newDir := dir
+	if inDir {
+		newDir = filepath.Dir(path)
+	}
+	newPath := filepath.Join(newDir, metadata.BuildFilename(fm, filepath.Ext(path)))
+
+	if inDir && content == nil {
+		if _, err := os.Stat(newPath); err == nil {
+			return "", fmt.Errorf("%s already exists", newPath)
+		}
+		return newPath, util.Rename(path, newPath)
+	}
+	if err := copyFile(path, newPath, content, info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	if inDir {
+		return newPath, os.Remove(path)
+	}
+	return newPath, nil
+}
+
+// copyFile writes content, or the contents of src if content is nil, to
+// a new file dst.
+func copyFile(src, dst string, content []byte, perm os.FileMode) error {
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if content != nil {
+		_, err = out.Write(content)
+	} else {
+		var in *os.File
+		if in, err = os.Open(src); err == nil {
+			_, err = io.Copy(out, in)
+			in.Close()
+		}
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func textFileType(ext string, mdType metadata.FileType) metadata.FileType {
+	switch ext {
+	case ".org":
+		return metadata.FileTypeOrg
+	case ".txt":
+		return metadata.FileTypeTxt
+	}
+	return mdType
+}
+
+// mergeTags returns the valid tags of a followed by those of b that are
+// not already present.
+func mergeTags(a, b []string) []string {
+	merged := []string{}
+	for _, tag := range append(slices.Clone(a), b...) {
+		if metadata.IsValidTag(tag) && !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,94 @@
+// Package importer converts content from other tools into denote notes
+// written directly to the denote directory.
+package importer
+
+import (
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Note is a note to be written by Write.
+type Note struct {
+	Date  time.Time
+	Title string
+	Tags  []string
+	Body  string
+	Bib   string // optional citation key for reference notes
+}
+
+// Identifiers tracks identifiers already in use so imported notes never
+// collide with existing ones or with each other.
+type Identifiers map[string]bool
+
+// Next returns the identifier for t, advancing one second at a time
+// until it finds one not yet taken, and marks it as taken.
+func (ids Identifiers) Next(t time.Time) string {
+	for {
+		id := t.Format("20060102T150405")
+		if !ids[id] {
+			ids[id] = true
+			return id
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+// Write creates n in dir with front matter for fileType and returns the
+// path of the new file. The identifier is allocated from ids.
+func Write(dir string, n Note, fileType metadata.FileType, ids Identifiers) (string, error) {
+	return WriteEncrypted(dir, n, fileType, ids, nil)
+}
+
+// EncryptFunc is given the path and content of a note about to be
+// written. It returns them unchanged, or the path and content of the
+// encrypted file to write instead.
+type EncryptFunc func(path string, content []byte) (string, []byte, error)
+
+// WriteEncrypted is Write passing the note through encrypt, if not nil,
+// before anything reaches the disk, so that a note that must be
+// encrypted is never written in plaintext. The note is written to a
+// temporary file linked into place; an existing file is never
+// replaced.
+func WriteEncrypted(dir string, n Note, fileType metadata.FileType, ids Identifiers, encrypt EncryptFunc) (string, error) {
+	// Identifiers and dates are in local time, whatever the zone of
+	// n.Date, as for notes created in acme.
+	date := n.Date.Local()
+	fm := metadata.NewFrontMatter(n.Title, "", n.Tags, ids.Next(date))
+	fm.Bib = n.Bib
+	path := filepath.Join(dir, metadata.BuildFilename(fm, metadata.GetExtension(fileType)))
+	content := append(frontmatter.MarshalAt(fm, fileType, date), n.Body...)
+	if encrypt != nil {
+		var err error
+		if path, content, err = encrypt(path, content); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create note: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write note: %w", err)
+	}
+	// A hard link, unlike a rename, fails if the note already exists.
+	err = os.Link(tmp, path)
+	os.Remove(tmp)
+	if err != nil {
+		return "", fmt.Errorf("failed to create note: %w", err)
+	}
+	return path, nil
+}
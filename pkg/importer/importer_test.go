@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"denote/pkg/metadata"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentifiersNext(t *testing.T) {
+	ids := Identifiers{"20240101T120000": true}
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+
+	if got := ids.Next(date); got != "20240101T120001" {
+		t.Errorf("Next() = %q, want %q", got, "20240101T120001")
+	}
+	if got := ids.Next(date); got != "20240101T120002" {
+		t.Errorf("second Next() = %q, want %q", got, "20240101T120002")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2024, 3, 5, 9, 30, 0, 0, time.Local)
+	n := Note{Date: date, Title: "Weekly Sync", Tags: []string{"mail", "work"}, Body: "hello\n"}
+
+	path, err := Write(dir, n, metadata.FileTypeMdYaml, Identifiers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "20240305T093000--weekly-sync__mail_work.md"); path != want {
+		t.Errorf("Write() path = %q, want %q", path, want)
+	}
+	got, _ := os.ReadFile(path)
+	for _, want := range []string{"title:      Weekly Sync", "date:       2024-03-05 Tue 09:30", "identifier: 20240305T093000", "hello\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("note missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteLocalTime(t *testing.T) {
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	n := Note{Date: time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC), Title: "Mail"}
+
+	path, err := Write(t.TempDir(), n, metadata.FileTypeMdYaml, Identifiers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata.ParseFilename(path).Identifier; got != "20240305T113000" {
+		t.Errorf("identifier = %q, want the local time 20240305T113000", got)
+	}
+}
+
+func TestWriteEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2024, 3, 5, 9, 30, 0, 0, time.Local)
+	n := Note{Date: date, Title: "Secret", Body: "plaintext\n"}
+	encrypt := func(path string, content []byte) (string, []byte, error) {
+		return path + ".gpg", []byte("ciphertext"), nil
+	}
+
+	path, err := WriteEncrypted(dir, n, metadata.FileTypeMdYaml, Identifiers{}, encrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "20240305T093000--secret.md.gpg"); path != want {
+		t.Errorf("WriteEncrypted() path = %q, want %q", path, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the encrypted note", len(entries))
+	}
+	if got, _ := os.ReadFile(path); string(got) != "ciphertext" {
+		t.Errorf("note = %q, want ciphertext", got)
+	}
+
+	// An existing file is never replaced.
+	if _, err := WriteEncrypted(dir, n, metadata.FileTypeMdYaml, Identifiers{}, encrypt); err == nil {
+		t.Error("second WriteEncrypted() succeeded, want error")
+	}
+}
+
+func TestFileTitle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"meeting_notes-2024.txt": "no heading here\n",
+		"plan.md":                "# Project Plan\n\nbody\n",
+		"scan.jpg":               "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]string{
+		"meeting_notes-2024.txt": "meeting notes 2024",
+		"plan.md":                "Project Plan",
+		"scan.jpg":               "scan",
+	}
+	for name, want := range tests {
+		if got := FileTitle(filepath.Join(dir, name)); got != want {
+			t.Errorf("FileTitle(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	dir := t.TempDir()
+	src := t.TempDir()
+	mtime := time.Date(2024, 3, 5, 9, 30, 0, 0, time.Local)
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := Identifiers{}
+
+	// A text file outside dir is copied with front matter added.
+	write(filepath.Join(src, "plan.md"), "# Project Plan\n\nbody\n")
+	path, err := Adopt(filepath.Join(src, "plan.md"), dir, []string{"work"}, metadata.FileTypeMdYaml, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "20240305T093000--project-plan__work.md"); path != want {
+		t.Errorf("Adopt() path = %q, want %q", path, want)
+	}
+	got, _ := os.ReadFile(path)
+	for _, want := range []string{"title:      Project Plan", "identifier: 20240305T093000", "# Project Plan\n\nbody\n"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("note missing %q:\n%s", want, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "plan.md")); err != nil {
+		t.Errorf("source file was not left in place: %v", err)
+	}
+
+	// Any other file inside dir is renamed in place, content untouched.
+	write(filepath.Join(dir, "scan.jpg"), "jpeg")
+	path, err = Adopt(filepath.Join(dir, "scan.jpg"), dir, nil, metadata.FileTypeMdYaml, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "20240305T093001--scan.jpg"); path != want {
+		t.Errorf("Adopt() path = %q, want %q", path, want)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "jpeg" {
+		t.Errorf("adopted content = %q, want %q", got, "jpeg")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "scan.jpg")); !os.IsNotExist(err) {
+		t.Errorf("original file still exists: %v", err)
+	}
+}
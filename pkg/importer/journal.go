@@ -0,0 +1,206 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"denote/pkg/metadata"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JournalEntry is an entry of a journal exported from jrnl or Day One.
+type JournalEntry struct {
+	Date  time.Time
+	Title string // may be empty
+	Tags  []string
+	Body  string
+}
+
+// Note converts e into a note tagged with tags and those of the entry's
+// own tags that are valid denote tags. An entry without a title is titled
+// by title(e.Date).
+func (e JournalEntry) Note(tags []string, title func(time.Time) string) Note {
+	own := slices.DeleteFunc(slices.Clone(e.Tags), func(t string) bool { return !metadata.IsValidTag(t) })
+	n := Note{Date: e.Date, Title: e.Title, Tags: metadata.NormalizeTags(append(slices.Clone(tags), own...)), Body: e.Body}
+	if n.Title == "" {
+		n.Title = title(e.Date)
+	}
+	if n.Body != "" && !strings.HasSuffix(n.Body, "\n") {
+		n.Body += "\n"
+	}
+	return n
+}
+
+// ReadJournal reads the entries of a journal export in format, jrnl or
+// dayone. Times without a zone are in loc.
+func ReadJournal(r io.Reader, format string, loc *time.Location) ([]JournalEntry, error) {
+	switch format {
+	case "jrnl":
+		return ReadJrnl(r, loc)
+	case "dayone":
+		return ReadDayOne(r, loc)
+	}
+	return nil, fmt.Errorf("unknown journal format: %s", format)
+}
+
+// jrnlHeadRe matches the first line of an entry in jrnl's text export:
+// [2024-01-15 09:30] Title.
+var jrnlHeadRe = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2}[ T][^\]]+)\] ?(.*)$`)
+
+// jrnlTimeLayouts are the time formats jrnl writes, depending on its
+// timeformat setting.
+var jrnlTimeLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 03:04 PM",
+	"2006-01-02 03:04:05 PM",
+	"2006-01-02T15:04:05",
+}
+
+// jrnlTagRe matches a jrnl @tag.
+var jrnlTagRe = regexp.MustCompile(`(?:^|\s)@([\p{L}\p{Nd}]+)`)
+
+// ReadJrnl reads a jrnl export, either plain text (jrnl --export txt, or
+// the journal file itself) or JSON (jrnl --export json). @tags in the
+// text become tags.
+func ReadJrnl(r io.Reader, loc *time.Location) ([]JournalEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return readJrnlJSON(trimmed, loc)
+	}
+
+	var entries []JournalEntry
+	var cur *JournalEntry
+	var body []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			cur.Tags = jrnlTags(cur.Title + "\n" + cur.Body)
+			entries = append(entries, *cur)
+		}
+		body = nil
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if m := jrnlHeadRe.FindStringSubmatch(line); m != nil {
+			if t, ok := parseJrnlTime(m[1], loc); ok {
+				flush()
+				cur = &JournalEntry{Date: t, Title: strings.TrimSpace(m[2])}
+				continue
+			}
+		}
+		if cur == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("jrnl: text before the first entry: %q", line)
+		}
+		body = append(body, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+func parseJrnlTime(s string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range jrnlTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func jrnlTags(text string) []string {
+	var tags []string
+	for _, m := range jrnlTagRe.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, strings.ToLower(m[1]))
+	}
+	return metadata.NormalizeTags(tags)
+}
+
+func readJrnlJSON(data []byte, loc *time.Location) ([]JournalEntry, error) {
+	var export struct {
+		Entries []struct {
+			Date  string   `json:"date"`
+			Time  string   `json:"time"`
+			Title string   `json:"title"`
+			Body  string   `json:"body"`
+			Tags  []string `json:"tags"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("jrnl: %w", err)
+	}
+	var entries []JournalEntry
+	for _, e := range export.Entries {
+		t, ok := parseJrnlTime(e.Date+" "+e.Time, loc)
+		if !ok {
+			return nil, fmt.Errorf("jrnl: invalid entry time: %s %s", e.Date, e.Time)
+		}
+		var tags []string
+		for _, tag := range e.Tags {
+			tags = append(tags, strings.TrimPrefix(tag, "@"))
+		}
+		entries = append(entries, JournalEntry{Date: t, Title: strings.TrimSpace(e.Title), Tags: metadata.NormalizeTags(tags), Body: strings.TrimSpace(e.Body)})
+	}
+	return entries, nil
+}
+
+// dayOneEscapeRe matches the backslash escapes Day One adds to Markdown
+// punctuation in exported text.
+var dayOneEscapeRe = regexp.MustCompile(`\\([\\.!#*+\-_()\[\]{}>|` + "`" + `])`)
+
+// ReadDayOne reads a Day One JSON export (the Journal.json file of an
+// export archive). The first line of an entry, without a leading #,
+// becomes its title. Entries are dated in their own time zone if the
+// export records one, else in loc.
+func ReadDayOne(r io.Reader, loc *time.Location) ([]JournalEntry, error) {
+	var export struct {
+		Entries []struct {
+			CreationDate string   `json:"creationDate"`
+			TimeZone     string   `json:"timeZone"`
+			Text         string   `json:"text"`
+			Tags         []string `json:"tags"`
+		} `json:"entries"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("dayone: %w", err)
+	}
+	var entries []JournalEntry
+	for _, e := range export.Entries {
+		t, err := time.Parse(time.RFC3339, e.CreationDate)
+		if err != nil {
+			return nil, fmt.Errorf("dayone: invalid creationDate: %w", err)
+		}
+		tz := loc
+		if z, err := time.LoadLocation(e.TimeZone); e.TimeZone != "" && err == nil {
+			tz = z
+		}
+		text := strings.TrimSpace(dayOneEscapeRe.ReplaceAllString(e.Text, "$1"))
+		title, body, _ := strings.Cut(text, "\n")
+		var tags []string
+		for _, tag := range e.Tags {
+			tags = append(tags, strings.ReplaceAll(tag, " ", ""))
+		}
+		entries = append(entries, JournalEntry{
+			Date:  t.In(tz),
+			Title: strings.TrimSpace(strings.TrimLeft(title, "#")),
+			Tags:  metadata.NormalizeTags(tags),
+			Body:  strings.TrimSpace(body),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadJrnl(t *testing.T) {
+	input := `[2024-01-15 09:30] Morning pages. Slept well.
+Worked on the @garden plan with @Anna.
+
+[2024-01-16 07:05:00 PM] Evening
+Quiet day.
+`
+	entries, err := ReadJrnl(strings.NewReader(input), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if !e.Date.Equal(time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)) || e.Title != "Morning pages. Slept well." {
+		t.Errorf("entry 0 = %v %q", e.Date, e.Title)
+	}
+	if e.Body != "Worked on the @garden plan with @Anna." {
+		t.Errorf("entry 0 body = %q", e.Body)
+	}
+	if want := []string{"anna", "garden"}; !reflect.DeepEqual(e.Tags, want) && !reflect.DeepEqual(e.Tags, []string{"garden", "anna"}) {
+		t.Errorf("entry 0 tags = %q, want %q", e.Tags, want)
+	}
+	if e := entries[1]; !e.Date.Equal(time.Date(2024, 1, 16, 19, 5, 0, 0, time.UTC)) || e.Body != "Quiet day." {
+		t.Errorf("entry 1 = %v %q", e.Date, e.Body)
+	}
+
+	if _, err := ReadJrnl(strings.NewReader("no entry here\n"), time.UTC); err == nil {
+		t.Error("ReadJrnl() accepted text without entries")
+	}
+}
+
+func TestReadJrnlJSON(t *testing.T) {
+	input := `{"tags": {"@work": 1}, "entries": [{"title": "Standup", "body": "Notes.", "date": "2024-02-01", "time": "10:00", "tags": ["@work"], "starred": false}]}`
+	entries, err := ReadJrnl(strings.NewReader(input), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []JournalEntry{{Date: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC), Title: "Standup", Tags: []string{"work"}, Body: "Notes."}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadJrnl() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadDayOne(t *testing.T) {
+	input := `{"metadata": {"version": "1.0"}, "entries": [
+		{"uuid": "A1", "creationDate": "2024-03-05T08:15:00Z", "timeZone": "Europe/Berlin", "text": "# Trip to Rome\n\nArrived at 9\\. Lovely\\!", "tags": ["Travel", "city break"]},
+		{"uuid": "A2", "creationDate": "2024-03-06T20:00:00Z", "text": "Just one line"}
+	]}`
+	entries, err := ReadDayOne(strings.NewReader(input), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if e.Title != "Trip to Rome" || e.Body != "Arrived at 9. Lovely!" {
+		t.Errorf("entry 0 = %q %q", e.Title, e.Body)
+	}
+	if e.Date.Format("2006-01-02 15:04 MST") != "2024-03-05 09:15 CET" {
+		t.Errorf("entry 0 date = %v, want the entry's time zone", e.Date)
+	}
+	if !reflect.DeepEqual(e.Tags, []string{"travel", "citybreak"}) && !reflect.DeepEqual(e.Tags, []string{"citybreak", "travel"}) {
+		t.Errorf("entry 0 tags = %q", e.Tags)
+	}
+	if e := entries[1]; e.Title != "Just one line" || e.Body != "" {
+		t.Errorf("entry 1 = %q %q", e.Title, e.Body)
+	}
+}
+
+func TestJournalEntryNote(t *testing.T) {
+	e := JournalEntry{Date: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC), Tags: []string{"garden", "bad-tag"}, Body: "text"}
+	n := e.Note([]string{"journal"}, func(t time.Time) string { return t.Format("2006-01-02") })
+	if n.Title != "2024-01-15" || n.Body != "text\n" {
+		t.Errorf("Note() = %q %q", n.Title, n.Body)
+	}
+	if len(n.Tags) != 2 {
+		t.Errorf("Note().Tags = %q, want journal and garden", n.Tags)
+	}
+}
@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message is an email message reduced to what a note needs.
+type Message struct {
+	From    string
+	Subject string
+	Date    time.Time
+	Body    string
+}
+
+// Note converts m into a note tagged with tags. The sender and date are
+// kept at the top of the body.
+func (m Message) Note(tags []string) Note {
+	title := m.Subject
+	if title == "" {
+		title = "no subject"
+	}
+	body := fmt.Sprintf("From: %s  \nDate: %s\n\n%s", m.From, m.Date.Format(time.RFC1123Z), m.Body)
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return Note{Date: m.Date, Title: title, Tags: tags, Body: body}
+}
+
+// ReadMail reads every message from path, which may be a maildir (a
+// directory with cur/ and new/ subdirectories) or an mbox file. Messages
+// that cannot be parsed, e.g. for lack of a valid Date, are skipped; the
+// second result says why for each.
+func ReadMail(path string) ([]Message, []error, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return ReadMaildir(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadMbox(f)
+}
+
+// ReadMaildir reads every message in the cur/ and new/ subdirectories of
+// dir, skipping those that cannot be read or parsed as ReadMail does.
+func ReadMaildir(dir string) ([]Message, []error, error) {
+	var msgs []Message
+	var skipped []error
+	for _, sub := range []string{"cur", "new"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, sub, e.Name()))
+			if err != nil {
+				skipped = append(skipped, fmt.Errorf("%s: %w", e.Name(), err))
+				continue
+			}
+			m, err := ParseMessage(data)
+			if err != nil {
+				skipped = append(skipped, fmt.Errorf("%s: %w", e.Name(), err))
+				continue
+			}
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs, skipped, nil
+}
+
+// ReadMbox reads every message from an mbox stream, skipping those that
+// cannot be parsed as ReadMail does. Messages are separated by lines
+// starting with "From "; ">From " escapes are undone.
+func ReadMbox(r io.Reader) ([]Message, []error, error) {
+	var msgs []Message
+	var skipped []error
+	var cur bytes.Buffer
+	inMsg, n := false, 0
+	flush := func() {
+		if !inMsg {
+			return
+		}
+		n++
+		if m, err := ParseMessage(cur.Bytes()); err != nil {
+			skipped = append(skipped, fmt.Errorf("message %d: %w", n, err))
+		} else {
+			msgs = append(msgs, m)
+		}
+		cur.Reset()
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "From ") {
+			flush()
+			inMsg = true
+			continue
+		}
+		if strings.HasPrefix(line, ">") && strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	flush()
+	return msgs, skipped, nil
+}
+
+var decoder = &mime.WordDecoder{}
+
+// ParseMessage parses a single RFC 5322 message and converts its body to
+// markdown, preferring a text/plain part over text/html.
+func ParseMessage(data []byte) (Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return Message{}, err
+	}
+
+	var m Message
+	if m.Subject, err = decoder.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		m.Subject = msg.Header.Get("Subject")
+	}
+	m.Subject = strings.TrimSpace(m.Subject)
+	if m.From, err = decoder.DecodeHeader(msg.Header.Get("From")); err != nil {
+		m.From = msg.Header.Get("From")
+	}
+	if m.Date, err = msg.Header.Date(); err != nil {
+		return Message{}, fmt.Errorf("invalid date: %w", err)
+	}
+
+	body, err := textBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return Message{}, err
+	}
+	m.Body = body
+	return m, nil
+}
+
+// textBody extracts the most readable text from a (possibly multipart) body.
+func textBody(contentType, encoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		var plain, htmlText string
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := textBody(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p)
+			if err != nil {
+				return "", err
+			}
+			pt, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+			switch {
+			case pt == "text/html" && htmlText == "":
+				htmlText = text
+			case (pt == "text/plain" || strings.HasPrefix(pt, "multipart/")) && plain == "":
+				plain = text
+			}
+		}
+		if plain != "" {
+			return plain, nil
+		}
+		return htmlText, nil
+	}
+
+	if !strings.HasPrefix(mediaType, "text/") {
+		return "", nil
+	}
+
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if mediaType == "text/html" {
+		return htmlToMarkdown(text), nil
+	}
+	return text, nil
+}
+
+var (
+	htmlBreakRe  = regexp.MustCompile(`(?i)<br\s*/?>|</(li|tr)>`)
+	htmlBlockRe  = regexp.MustCompile(`(?i)</(p|div|h[1-6]|ul|ol|table)>`)
+	htmlItemRe   = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlTagRe    = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlDropRe   = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToMarkdown is a conservative conversion of an HTML mail body:
+// block elements become paragraphs, list items become "- " bullets,
+// and all other markup is dropped.
+func htmlToMarkdown(s string) string {
+	s = htmlDropRe.ReplaceAllString(s, "")
+	s = htmlItemRe.ReplaceAllString(s, "- ")
+	s = htmlBlockRe.ReplaceAllString(s, "\n\n")
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(s, "\n\n")) + "\n"
+}
@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantSubject string
+		wantBody    string
+	}{
+		{
+			name: "plain text",
+			input: "From: Alice <alice@example.com>\r\n" +
+				"Subject: Lunch plans\r\n" +
+				"Date: Tue, 05 Mar 2024 09:30:00 +0100\r\n" +
+				"\r\n" +
+				"See you at noon.\r\n",
+			wantSubject: "Lunch plans",
+			wantBody:    "See you at noon.\n",
+		},
+		{
+			name: "encoded subject and quoted-printable body",
+			input: "From: bob@example.com\n" +
+				"Subject: =?UTF-8?Q?Caf=C3=A9_notes?=\n" +
+				"Date: Tue, 05 Mar 2024 09:30:00 +0000\n" +
+				"Content-Type: text/plain; charset=utf-8\n" +
+				"Content-Transfer-Encoding: quoted-printable\n" +
+				"\n" +
+				"Caf=C3=A9 au lait\n",
+			wantSubject: "Café notes",
+			wantBody:    "Café au lait\n",
+		},
+		{
+			name: "multipart prefers plain text",
+			input: "From: bob@example.com\n" +
+				"Subject: Both\n" +
+				"Date: Tue, 05 Mar 2024 09:30:00 +0000\n" +
+				"Content-Type: multipart/alternative; boundary=XX\n" +
+				"\n" +
+				"--XX\n" +
+				"Content-Type: text/html\n" +
+				"\n" +
+				"<p>html</p>\n" +
+				"--XX\n" +
+				"Content-Type: text/plain\n" +
+				"\n" +
+				"plain\n" +
+				"--XX--\n",
+			wantSubject: "Both",
+			wantBody:    "plain",
+		},
+		{
+			name: "html only",
+			input: "From: bob@example.com\n" +
+				"Subject: Html\n" +
+				"Date: Tue, 05 Mar 2024 09:30:00 +0000\n" +
+				"Content-Type: text/html\n" +
+				"\n" +
+				"<html><head><style>p{}</style></head><body><p>One &amp; two</p><ul><li>a</li><li>b</li></ul></body></html>\n",
+			wantSubject: "Html",
+			wantBody:    "One & two\n\n- a\n- b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMessage([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", m.Subject, tt.wantSubject)
+			}
+			if m.Body != tt.wantBody {
+				t.Errorf("Body = %q, want %q", m.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReadMbox(t *testing.T) {
+	mbox := "From alice@example.com Tue Mar  5 09:30:00 2024\n" +
+		"From: alice@example.com\n" +
+		"Subject: First\n" +
+		"Date: Tue, 05 Mar 2024 09:30:00 +0000\n" +
+		"\n" +
+		">From the desk of Alice\n" +
+		"\n" +
+		"From bob@example.com Wed Mar  6 10:00:00 2024\n" +
+		"From: bob@example.com\n" +
+		"Subject: Second\n" +
+		"Date: Wed, 06 Mar 2024 10:00:00 +0000\n" +
+		"\n" +
+		"body two\n" +
+		"From carol@example.com Thu Mar  7 11:00:00 2024\n" +
+		"From: carol@example.com\n" +
+		"Subject: Undated\n" +
+		"\n" +
+		"body three\n"
+
+	msgs, skipped, err := ReadMbox(strings.NewReader(mbox))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("ReadMbox() returned %d messages, want 2", len(msgs))
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0].Error(), "message 3:") {
+		t.Errorf("skipped = %v, want message 3", skipped)
+	}
+	if !strings.HasPrefix(msgs[0].Body, "From the desk of Alice") {
+		t.Errorf("escaped From line not restored: %q", msgs[0].Body)
+	}
+	if msgs[1].Subject != "Second" {
+		t.Errorf("msgs[1].Subject = %q, want %q", msgs[1].Subject, "Second")
+	}
+}
+
+func TestReadMaildirSkipsUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	cur := filepath.Join(dir, "cur")
+	if err := os.Mkdir(cur, 0755); err != nil {
+		t.Fatal(err)
+	}
+	msg := "From: alice@example.com\nSubject: First\nDate: Tue, 05 Mar 2024 09:30:00 +0000\n\nbody\n"
+	if err := os.WriteFile(filepath.Join(cur, "1:2,S"), []byte(msg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(cur, "2:2,S")); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, skipped, err := ReadMaildir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].Subject != "First" {
+		t.Errorf("ReadMaildir() = %v, want the readable message", msgs)
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0].Error(), "2:2,S:") {
+		t.Errorf("skipped = %v, want 2:2,S", skipped)
+	}
+}
@@ -0,0 +1,68 @@
+// Package journal computes statistics over journal entries.
+package journal
+
+import (
+	"sort"
+	"time"
+)
+
+// Streak summarizes how regularly a journal is kept.
+type Streak struct {
+	Current int         // consecutive days with an entry, ending today or yesterday
+	Longest int         // longest run of consecutive days with an entry
+	Written int         // days of the current month with an entry
+	Days    int         // days of the current month so far
+	Missing []time.Time // days of the current month without an entry, oldest first
+}
+
+// Stats computes the streak of the journal whose entries were written at
+// times, as of now. A day counts once however many entries it has. The
+// current streak is not broken until today ends, so it counts back from
+// yesterday when there is no entry today yet.
+func Stats(times []time.Time, now time.Time) Streak {
+	written := make(map[time.Time]bool, len(times))
+	for _, t := range times {
+		written[day(t.In(now.Location()))] = true
+	}
+	var days []time.Time
+	for d := range written {
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var s Streak
+	run := 0
+	for i, d := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		s.Longest = max(s.Longest, run)
+	}
+
+	today := day(now)
+	d := today
+	if !written[d] {
+		d = d.AddDate(0, 0, -1)
+	}
+	for ; written[d]; d = d.AddDate(0, 0, -1) {
+		s.Current++
+	}
+
+	for d := today.AddDate(0, 0, 1-today.Day()); !d.After(today); d = d.AddDate(0, 0, 1) {
+		s.Days++
+		if written[d] {
+			s.Written++
+		} else {
+			s.Missing = append(s.Missing, d)
+		}
+	}
+	return s
+}
+
+// day returns the start of the day of t.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,54 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func date(d, hour int) time.Time {
+	return time.Date(2025, 11, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestStats(t *testing.T) {
+	times := []time.Time{
+		time.Date(2025, 10, 30, 8, 0, 0, 0, time.UTC),
+		time.Date(2025, 10, 31, 8, 0, 0, 0, time.UTC),
+		date(1, 9), date(2, 22), date(2, 23), date(3, 7),
+		date(6, 8),
+		date(8, 8), date(9, 8),
+	}
+	s := Stats(times, date(10, 12))
+	if s.Current != 2 {
+		t.Errorf("Current = %d, want 2 (no entry today yet)", s.Current)
+	}
+	if s.Longest != 5 {
+		t.Errorf("Longest = %d, want 5", s.Longest)
+	}
+	if s.Written != 6 || s.Days != 10 {
+		t.Errorf("Written, Days = %d, %d, want 6, 10", s.Written, s.Days)
+	}
+	want := []time.Time{date(4, 0), date(5, 0), date(7, 0), date(10, 0)}
+	if len(s.Missing) != len(want) {
+		t.Fatalf("Missing = %v, want %v", s.Missing, want)
+	}
+	for i := range want {
+		if !s.Missing[i].Equal(want[i]) {
+			t.Errorf("Missing = %v, want %v", s.Missing, want)
+			break
+		}
+	}
+
+	if s := Stats(times, date(11, 12)); s.Current != 0 {
+		t.Errorf("Current = %d after a missed day, want 0", s.Current)
+	}
+	if s := Stats(append(times, date(10, 6)), date(10, 12)); s.Current != 3 {
+		t.Errorf("Current = %d with an entry today, want 3", s.Current)
+	}
+}
+
+func TestStatsEmpty(t *testing.T) {
+	s := Stats(nil, date(3, 12))
+	if s.Current != 0 || s.Longest != 0 || s.Written != 0 || len(s.Missing) != 3 {
+		t.Errorf("Stats(nil) = %+v", s)
+	}
+}
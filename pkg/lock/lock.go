@@ -0,0 +1,132 @@
+// Package lock implements advisory note locks: small files in the
+// .denotelocks directory of a denote directory recording who has a note
+// open, so that other clients can see it. Locks do not prevent anything;
+// they are only reported.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the lock directory at the root of a denote directory.
+const DirName = ".denotelocks"
+
+// ErrHeld is returned by Acquire for a note locked by another holder.
+var ErrHeld = errors.New("note is locked")
+
+// Lock is the advisory lock on one note.
+type Lock struct {
+	Identifier string
+	Holder     string // e.g. user@host
+	Since      time.Time
+}
+
+func (l *Lock) String() string {
+	return fmt.Sprintf("%s | %s | %s", l.Identifier, l.Holder, l.Since.Format(time.RFC3339))
+}
+
+func path(dir, id string) string {
+	return filepath.Join(dir, DirName, id)
+}
+
+// Get returns the lock on the note with identifier id, or nil if it is
+// not locked.
+func Get(dir, id string) (*Lock, error) {
+	data, err := os.ReadFile(path(dir, id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	holder, since, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil || holder == "" {
+		return nil, fmt.Errorf("invalid lock %s: %q", id, data)
+	}
+	return &Lock{Identifier: id, Holder: holder, Since: t}, nil
+}
+
+// Acquire locks the note with identifier id for holder. A lock already
+// held by holder is kept. If another holder has the note locked, its
+// lock is returned with ErrHeld. The lock file is created exclusively, so
+// of two clients locking the note at once only one gets the lock.
+func Acquire(dir, id, holder string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Join(dir, DirName), 0755); err != nil {
+		return nil, err
+	}
+	l := &Lock{Identifier: id, Holder: holder, Since: time.Now().Truncate(time.Second)}
+	data := fmt.Sprintf("%s %s\n", holder, l.Since.Format(time.RFC3339))
+	for {
+		f, err := os.OpenFile(path(dir, id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if errors.Is(err, os.ErrExist) {
+			held, err := Get(dir, id)
+			if err != nil {
+				return nil, err
+			}
+			if held == nil {
+				continue // released since
+			}
+			if held.Holder != holder {
+				return held, ErrHeld
+			}
+			return held, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.WriteString(data); err != nil {
+			f.Close()
+			os.Remove(path(dir, id))
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(path(dir, id))
+			return nil, err
+		}
+		return l, nil
+	}
+}
+
+// Release removes holder's lock on the note with identifier id. A note
+// that is not locked, or locked by someone else, is left alone.
+func Release(dir, id, holder string) error {
+	l, err := Get(dir, id)
+	if err != nil || l == nil || l.Holder != holder {
+		return err
+	}
+	err = os.Remove(path(dir, id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List returns the locks in dir, ordered by identifier. Invalid lock
+// files are skipped.
+func List(dir string) ([]*Lock, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, DirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var locks []*Lock
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if l, err := Get(dir, e.Name()); err == nil && l != nil {
+			locks = append(locks, l)
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Identifier < locks[j].Identifier })
+	return locks, nil
+}
@@ -0,0 +1,93 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	const id = "20240101T120000"
+
+	if l, err := Get(dir, id); err != nil || l != nil {
+		t.Fatalf("Get(unlocked) = %v, %v", l, err)
+	}
+	l, err := Acquire(dir, id, "alice@home")
+	if err != nil || l.Holder != "alice@home" {
+		t.Fatalf("Acquire() = %v, %v", l, err)
+	}
+	if again, err := Acquire(dir, id, "alice@home"); err != nil || !again.Since.Equal(l.Since) {
+		t.Errorf("Acquire(same holder) = %v, %v; want %v", again, err, l)
+	}
+	if other, err := Acquire(dir, id, "bob@work"); !errors.Is(err, ErrHeld) || other.Holder != "alice@home" {
+		t.Errorf("Acquire(other holder) = %v, %v; want alice's lock and ErrHeld", other, err)
+	}
+
+	if err := Release(dir, id, "bob@work"); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := Get(dir, id); l == nil {
+		t.Fatal("Release by another holder removed the lock")
+	}
+	locks, err := List(dir)
+	if err != nil || len(locks) != 1 || locks[0].Identifier != id {
+		t.Errorf("List() = %v, %v", locks, err)
+	}
+	if err := Release(dir, id, "alice@home"); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := Get(dir, id); l != nil {
+		t.Errorf("lock survived Release: %v", l)
+	}
+}
+
+func TestAcquireConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	const id = "20240101T120000"
+	holders := []string{"alice@home", "bob@work", "carol@lab", "dave@cafe"}
+	errs := make(chan error, len(holders))
+	var wg sync.WaitGroup
+	for _, h := range holders {
+		wg.Add(1)
+		go func(h string) {
+			defer wg.Done()
+			_, err := Acquire(dir, id, h)
+			errs <- err
+		}(h)
+	}
+	wg.Wait()
+	close(errs)
+	won := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, ErrHeld) && !strings.HasPrefix(err.Error(), "invalid lock"):
+			t.Errorf("Acquire() = %v", err)
+		}
+	}
+	if won != 1 {
+		t.Errorf("%d holders got the lock, want 1", won)
+	}
+}
+
+func TestListSkipsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, DirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DirName, "junk"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(dir, "20240101T120000", "alice@home"); err != nil {
+		t.Fatal(err)
+	}
+	locks, err := List(dir)
+	if err != nil || len(locks) != 1 {
+		t.Errorf("List() = %v, %v; want one lock", locks, err)
+	}
+}
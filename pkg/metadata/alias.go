@@ -0,0 +1,87 @@
+package metadata
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// AliasFileName is the alias file read from the root of a denote
+// directory. Each line maps an identifier a note no longer has to the one
+// that replaced it: "old new".
+const AliasFileName = ".denotealiases"
+
+// Aliases maps old identifiers to the identifiers that replaced them, so
+// that denote: links to an old identifier still resolve.
+type Aliases map[string]string
+
+// LoadAliases reads dir/.denotealiases. A missing file yields no aliases.
+// Blank lines and lines starting with # are ignored.
+func LoadAliases(dir string) (Aliases, error) {
+	f, err := os.Open(filepath.Join(dir, AliasFileName))
+	if os.IsNotExist(err) {
+		return Aliases{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	a := Aliases{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !IsIdentifier(fields[0]) || !IsIdentifier(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: want \"old new\" identifiers", AliasFileName, n)
+		}
+		a[fields[0]] = fields[1]
+	}
+	return a, sc.Err()
+}
+
+// Resolve returns the identifier id currently stands for, following
+// chains of aliases. An identifier without an alias resolves to itself.
+func (a Aliases) Resolve(id string) string {
+	seen := map[string]bool{}
+	for !seen[id] {
+		seen[id] = true
+		next, ok := a[id]
+		if !ok {
+			return id
+		}
+		id = next
+	}
+	return id // cycle; stop where it closes
+}
+
+// AddAlias records in dir/.denotealiases that old has been replaced by
+// new. An alias for new is dropped, so that new resolves to itself again.
+func AddAlias(dir, old, new string) error {
+	a, err := LoadAliases(dir)
+	if err != nil {
+		return err
+	}
+	delete(a, new)
+	a[old] = new
+	return a.write(dir)
+}
+
+// write replaces dir/.denotealiases with a, sorted by old identifier.
+func (a Aliases) write(dir string) error {
+	olds := make([]string, 0, len(a))
+	for old := range a {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	var buf strings.Builder
+	for _, old := range olds {
+		fmt.Fprintf(&buf, "%s %s\n", old, a[old])
+	}
+	return os.WriteFile(filepath.Join(dir, AliasFileName), []byte(buf.String()), 0644)
+}
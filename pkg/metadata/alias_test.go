@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	dir := t.TempDir()
+	a, err := LoadAliases(dir)
+	if err != nil || len(a) != 0 {
+		t.Fatalf("LoadAliases(empty) = %v, %v", a, err)
+	}
+
+	steps := [][2]string{
+		{"20240101T120000", "20230101T120000"},
+		{"20230101T120000", "20220101T120000"},
+	}
+	for _, s := range steps {
+		if err := AddAlias(dir, s[0], s[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a, err = LoadAliases(dir); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"20240101T120000": "20220101T120000",
+		"20230101T120000": "20220101T120000",
+		"20220101T120000": "20220101T120000",
+		"20200101T000000": "20200101T000000",
+	}
+	for id, want := range tests {
+		if got := a.Resolve(id); got != want {
+			t.Errorf("Resolve(%s) = %s, want %s", id, got, want)
+		}
+	}
+
+	// Moving back to an old identifier drops its alias.
+	if err := AddAlias(dir, "20220101T120000", "20240101T120000"); err != nil {
+		t.Fatal(err)
+	}
+	if a, err = LoadAliases(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Resolve("20240101T120000"); got != "20240101T120000" {
+		t.Errorf("Resolve after moving back = %s", got)
+	}
+	if got := a.Resolve("20230101T120000"); got != "20240101T120000" {
+		t.Errorf("Resolve(chain) after moving back = %s", got)
+	}
+}
+
+func TestAliasesCycle(t *testing.T) {
+	a := Aliases{"20240101T120000": "20230101T120000", "20230101T120000": "20240101T120000"}
+	if got := a.Resolve("20240101T120000"); got != "20240101T120000" {
+		t.Errorf("Resolve(cycle) = %s", got)
+	}
+}
+
+func TestLoadAliasesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, AliasFileName), []byte("# comment\n\nnot-an-id 20240101T120000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAliases(dir); err == nil {
+		t.Error("LoadAliases() accepted an invalid line")
+	}
+}
@@ -0,0 +1,91 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// benchSizes are the note counts of the synthetic denote directories
+// used by the benchmarks.
+var benchSizes = []int{10_000, 100_000}
+
+var benchTags = []string{"work", "meeting", "golang", "journal", "reading", "project", "idea", "home"}
+
+// synthNotes returns n notes with varied titles and tags.
+func synthNotes(n int) Results {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := make(Results, n)
+	for i := range rs {
+		rs[i] = &Metadata{
+			Identifier: start.Add(time.Duration(i) * time.Minute).Format("20060102T150405"),
+			Title:      fmt.Sprintf("note %d about %s", i, benchTags[i%len(benchTags)]),
+			Tags:       []string{benchTags[i%len(benchTags)], benchTags[(i/3)%len(benchTags)]},
+		}
+	}
+	return rs
+}
+
+// synthDir creates an empty file for each of n synthetic notes in a
+// temporary directory, spread over a few subdirectories.
+func synthDir(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	for i, md := range synthNotes(n) {
+		fm := NewFrontMatter(md.Title, "", md.Tags, md.Identifier)
+		path := filepath.Join(dir, fmt.Sprintf("sub%d", i%10), BuildFilename(fm, ".md"))
+		if i < 10 {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func BenchmarkScanDir(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			dir := synthDir(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ScanDir(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	queries := []string{"tag:golang", "title:'about reading'", "meeting", "!tag:/work|home/"}
+	for _, n := range benchSizes {
+		rs := synthNotes(n)
+		for _, q := range queries {
+			b.Run(fmt.Sprintf("%d/%s", n, q), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					f, err := NewFilter(q)
+					if err != nil {
+						b.Fatal(err)
+					}
+					for _, md := range rs {
+						f.IsMatch(md)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkNewFilter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := NewFilter("title:iw/go(lang)?/"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+)
+
+// CompleteTags returns the distinct tags in md that start with prefix,
+// sorted. An empty prefix returns every tag.
+func CompleteTags(md Results, prefix string) []string {
+	var tags []string
+	for _, n := range md {
+		for _, tag := range n.Tags {
+			if strings.HasPrefix(tag, prefix) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// CompleteTitles returns the distinct titles in md that start with
+// prefix, ignoring case, sorted case-insensitively.
+func CompleteTitles(md Results, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var titles []string
+	for _, n := range md {
+		if n.Title != "" && strings.HasPrefix(strings.ToLower(n.Title), prefix) {
+			titles = append(titles, n.Title)
+		}
+	}
+	slices.SortFunc(titles, func(a, b string) int {
+		if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return slices.Compact(titles)
+}
@@ -0,0 +1,33 @@
+package metadata
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	rs := Results{
+		{Identifier: "20240101T120000", Title: "Go concurrency", Tags: []string{"go", "dev"}},
+		{Identifier: "20240102T120000", Title: "gardening", Tags: []string{"garden", "home"}},
+		{Identifier: "20240103T120000", Title: "Go concurrency", Tags: []string{"go"}},
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"tags by prefix", CompleteTags(rs, "g"), []string{"garden", "go"}},
+		{"all tags", CompleteTags(rs, ""), []string{"dev", "garden", "go", "home"}},
+		{"no tags", CompleteTags(rs, "x"), nil},
+		{"titles ignore case", CompleteTitles(rs, "G"), []string{"gardening", "Go concurrency"}},
+		{"titles by longer prefix", CompleteTitles(rs, "go "), []string{"Go concurrency"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.want) {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CryptRule requires the notes below Dir, or tagged Tag, to be encrypted.
+// Exactly one of the two is set.
+type CryptRule struct {
+	Dir string // relative to the denote directory
+	Tag string
+}
+
+func (r CryptRule) String() string {
+	if r.Dir != "" {
+		return "dir:" + r.Dir
+	}
+	return "tag:" + r.Tag
+}
+
+// ParseCryptRules parses encryption rules of the form dir:<dir> or
+// tag:<tag>, e.g. "dir:journal" or "tag:private".
+func ParseCryptRules(rules []string) ([]CryptRule, error) {
+	var rs []CryptRule
+	for _, rule := range rules {
+		kind, val, _ := strings.Cut(strings.TrimSpace(rule), ":")
+		val = strings.Trim(val, "/")
+		switch {
+		case val == "":
+		case kind == "dir":
+			rs = append(rs, CryptRule{Dir: filepath.Clean(val)})
+			continue
+		case kind == "tag":
+			rs = append(rs, CryptRule{Tag: val})
+			continue
+		}
+		return nil, fmt.Errorf("invalid encryption rule %q: want dir:<dir> or tag:<tag>", rule)
+	}
+	return rs, nil
+}
+
+// RequiredBy returns the first rule requiring a note at rel, its path
+// relative to the denote directory, with tags to be encrypted.
+func RequiredBy(rules []CryptRule, rel string, tags []string) (CryptRule, bool) {
+	rel = filepath.Clean(rel)
+	for _, r := range rules {
+		if r.Dir != "" && strings.HasPrefix(rel, r.Dir+string(filepath.Separator)) {
+			return r, true
+		}
+		if r.Tag != "" && slices.Contains(tags, r.Tag) {
+			return r, true
+		}
+	}
+	return CryptRule{}, false
+}
+
+// CryptRecipient selects the key notes below Dir are encrypted to. Dir is
+// absolute, e.g. a silo, or relative to the denote directory.
+type CryptRecipient struct {
+	Dir       string
+	Recipient string
+}
+
+// ParseCryptRecipients parses rules of the form dir=recipient, e.g.
+// "/home/u/work=work@example.org" or "journal=me@example.org".
+func ParseCryptRecipients(rules []string) ([]CryptRecipient, error) {
+	var rs []CryptRecipient
+	for _, rule := range rules {
+		dir, recipient, ok := strings.Cut(rule, "=")
+		dir, recipient = strings.TrimSpace(dir), strings.TrimSpace(recipient)
+		if !ok || dir == "" || recipient == "" {
+			return nil, fmt.Errorf("invalid recipient rule %q: want dir=recipient", rule)
+		}
+		rs = append(rs, CryptRecipient{Dir: filepath.Clean(dir), Recipient: recipient})
+	}
+	return rs, nil
+}
+
+// RecipientFor returns the recipient of the rule with the longest
+// directory containing path, a note in the denote directory dir, or ""
+// if none does.
+func RecipientFor(rules []CryptRecipient, dir, path string) string {
+	best, bestLen := "", -1
+	for _, r := range rules {
+		d := r.Dir
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(dir, d)
+		}
+		if strings.HasPrefix(path, d+string(filepath.Separator)) && len(d) > bestLen {
+			best, bestLen = r.Recipient, len(d)
+		}
+	}
+	return best
+}
@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCryptRules(t *testing.T) {
+	rules, err := ParseCryptRules([]string{"dir:journal/", "tag:private"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rel  string
+		tags []string
+		want string
+	}{
+		{filepath.Join("journal", "20240101T120000--today.md"), nil, "dir:journal"},
+		{filepath.Join("journal", "2024", "20240101T120000--today.md"), nil, "dir:journal"},
+		{"20240101T120000--secret__private.md", []string{"private"}, "tag:private"},
+		{filepath.Join("journalism", "20240101T120000--press.md"), nil, ""},
+		{"20240101T120000--plain__work.md", []string{"work"}, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if r, ok := RequiredBy(rules, tt.rel, tt.tags); ok {
+			got = r.String()
+		}
+		if got != tt.want {
+			t.Errorf("RequiredBy(%q, %q) = %q, want %q", tt.rel, tt.tags, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"journal", "dir:", "path:x"} {
+		if _, err := ParseCryptRules([]string{bad}); err == nil {
+			t.Errorf("ParseCryptRules(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRecipientFor(t *testing.T) {
+	rules, err := ParseCryptRecipients([]string{"/home/u/notes=me@home", "journal=diary@home", "/home/u/work=me@work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ dir, path, want string }{
+		{"/home/u/notes", "/home/u/notes/20240101T120000--a.md", "me@home"},
+		{"/home/u/notes", "/home/u/notes/journal/20240101T120000--a.md", "diary@home"},
+		{"/home/u/work", "/home/u/work/journal/20240101T120000--a.md", "diary@home"},
+		{"/home/u/work", "/home/u/work/20240101T120000--a.md", "me@work"},
+		{"/tmp/other", "/tmp/other/20240101T120000--a.md", ""},
+	}
+	for _, tt := range tests {
+		if got := RecipientFor(rules, tt.dir, tt.path); got != tt.want {
+			t.Errorf("RecipientFor(%q, %q) = %q, want %q", tt.dir, tt.path, got, tt.want)
+		}
+	}
+	if _, err := ParseCryptRecipients([]string{"journal"}); err == nil {
+		t.Error("ParseCryptRecipients() accepted a rule without a recipient")
+	}
+}
@@ -0,0 +1,82 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dueRe matches the date of a due field: 2024-06-01, optionally in org
+// timestamp brackets with a weekday and time, e.g. <2024-06-01 Sat 10:00>.
+var dueRe = regexp.MustCompile(`^[<\[]?(\d{4}-\d{2}-\d{2})(?:[ \t]+\p{L}+)?(?:[ \t]+(\d{1,2}:\d{2}))?[>\]]?$`)
+
+// ParseDue parses the value of a due front matter field in loc. A date
+// without a time is due at the start of the day.
+func ParseDue(s string, loc *time.Location) (time.Time, error) {
+	m := dueRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid due date: %q", s)
+	}
+	if m[2] == "" {
+		return time.ParseInLocation("2006-01-02", m[1], loc)
+	}
+	return time.ParseInLocation("2006-01-02 15:04", m[1]+" "+m[2], loc)
+}
+
+// DueFilter returns a predicate for the due:<spec> and overdue: search
+// arguments, evaluated against now:
+//
+//	due:            notes with a due date
+//	due:<n>         notes due within n days, including overdue ones
+//	due:<date>      notes due on or before date (YYYY-MM-DD)
+//	overdue:        notes due before today
+//
+// Notes without a due date never match.
+func DueFilter(arg string, now time.Time) (func(*Metadata) bool, error) {
+	today := startOfDay(now)
+	if arg == "overdue:" {
+		return func(n *Metadata) bool { return !n.Due.IsZero() && n.Due.Before(today) }, nil
+	}
+	spec, ok := strings.CutPrefix(arg, "due:")
+	if !ok {
+		return nil, fmt.Errorf("not a due filter: %q", arg)
+	}
+	var until time.Time
+	if spec == "" {
+		return func(n *Metadata) bool { return !n.Due.IsZero() }, nil
+	} else if days, err := strconv.Atoi(spec); err == nil && days >= 0 {
+		until = today.AddDate(0, 0, days+1)
+	} else if date, err := time.ParseInLocation("2006-01-02", spec, now.Location()); err == nil {
+		until = date.AddDate(0, 0, 1)
+	} else {
+		return nil, fmt.Errorf("invalid due filter: %q", arg)
+	}
+	return func(n *Metadata) bool { return !n.Due.IsZero() && n.Due.Before(until) }, nil
+}
+
+// Agenda returns the notes of rs that are overdue or due within days of
+// now, earliest first.
+func Agenda(rs Results, now time.Time, days int) Results {
+	until := startOfDay(now).AddDate(0, 0, days+1)
+	var agenda Results
+	for _, n := range rs {
+		if !n.Due.IsZero() && n.Due.Before(until) {
+			agenda = append(agenda, n)
+		}
+	}
+	sort.SliceStable(agenda, func(i, j int) bool {
+		if !agenda[i].Due.Equal(agenda[j].Due) {
+			return agenda[i].Due.Before(agenda[j].Due)
+		}
+		return agenda[i].Identifier < agenda[j].Identifier
+	})
+	return agenda
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"<2024-06-01 Sat>", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"[2024-06-01 Sat 09:30]", time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)},
+		{"2024-06-01 17:00", time.Date(2024, 6, 1, 17, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDue(tt.in, time.UTC)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseDue(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "tomorrow", "2024-13-01", "01/06/2024"} {
+		if _, err := ParseDue(in, time.UTC); err == nil {
+			t.Errorf("ParseDue(%q) succeeded", in)
+		}
+	}
+}
+
+func TestDueFilter(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+	notes := map[string]*Metadata{
+		"none":     {},
+		"overdue":  {Due: time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)},
+		"today":    {Due: time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)},
+		"in3days":  {Due: time.Date(2024, 6, 13, 0, 0, 0, 0, time.UTC)},
+		"nextyear": {Due: time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		arg  string
+		want []string
+	}{
+		{"due:", []string{"in3days", "nextyear", "overdue", "today"}},
+		{"overdue:", []string{"overdue"}},
+		{"due:0", []string{"overdue", "today"}},
+		{"due:3", []string{"in3days", "overdue", "today"}},
+		{"due:2024-06-12", []string{"overdue", "today"}},
+	}
+	for _, tt := range tests {
+		match, err := DueFilter(tt.arg, now)
+		if err != nil {
+			t.Fatalf("DueFilter(%q): %v", tt.arg, err)
+		}
+		var got []string
+		for _, name := range []string{"in3days", "nextyear", "none", "overdue", "today"} {
+			if match(notes[name]) {
+				got = append(got, name)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("DueFilter(%q) matched %v, want %v", tt.arg, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("DueFilter(%q) matched %v, want %v", tt.arg, got, tt.want)
+				break
+			}
+		}
+	}
+	for _, arg := range []string{"due:soon", "due:-1", "title:x"} {
+		if _, err := DueFilter(arg, now); err == nil {
+			t.Errorf("DueFilter(%q) succeeded", arg)
+		}
+	}
+}
+
+func TestAgenda(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+	rs := Results{
+		{Identifier: "20240104T000000", Due: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)},
+		{Identifier: "20240103T000000", Due: time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{Identifier: "20240102T000000"},
+		{Identifier: "20240101T000000", Due: time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{Identifier: "20240105T000000", Due: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	var got []string
+	for _, n := range Agenda(rs, now, 7) {
+		got = append(got, n.Identifier)
+	}
+	want := []string{"20240105T000000", "20240101T000000", "20240103T000000"}
+	if len(got) != len(want) {
+		t.Fatalf("Agenda() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Agenda() = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"slices"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // Filter matches a given field in a Result to a regular expression
@@ -12,6 +14,11 @@ type Filter struct {
 	field  FilterField
 	re     *regexp.Regexp
 	negate bool
+
+	// lit is set if the pattern is a plain string, which is matched
+	// without the regexp: exactly, or ignoring ASCII case if fold is set.
+	lit  string
+	fold bool
 }
 
 type Filters []*Filter
@@ -38,15 +45,72 @@ func (fs Filters) Parse(S []string) (Filters, error) {
 	return fs, nil
 }
 
+// FilterOptions controls how filter patterns are matched. The zero value
+// matches case-insensitively anywhere in a field.
+type FilterOptions struct {
+	MatchCase bool // match letter case exactly
+	WholeWord bool // match only at word boundaries
+}
+
+// DefaultFilterOptions applies to filters that do not set their own
+// modifiers. It is changed at runtime with SetFilterOption.
+var DefaultFilterOptions FilterOptions
+
+// SetFilterOption sets a global filter option from a ctl command such as
+// "set matchcase off". name is "matchcase" or "wholeword"; value is "on"
+// or "off".
+func SetFilterOption(name, value string) error {
+	var on bool
+	switch value {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		return fmt.Errorf("invalid value for %s: %s (want on or off)", name, value)
+	}
+	switch name {
+	case "matchcase":
+		DefaultFilterOptions.MatchCase = on
+	case "wholeword":
+		DefaultFilterOptions.WholeWord = on
+	default:
+		return fmt.Errorf("unknown filter option: %s", name)
+	}
+	return nil
+}
+
+var (
+	filterRe   = regexp.MustCompile(`^(?:(date|title|tag):)?(.+)$`)
+	modifierRe = regexp.MustCompile(`^([icw]*)/(.+)/$`)
+)
+
 // NewFilter constructs a Filter from a filter string. arg takes the form
-// field:criteria, e.g., tag:/dev|meeting/, date:20251101.
+// field:criteria, e.g., tag:/dev|meeting/, date:20251101. A regex may be
+// prefixed with modifiers that override DefaultFilterOptions: i ignores
+// case, c matches case, and w matches whole words, e.g., title:cw/Go/.
+// Recently constructed filters are reused, since every search parses its
+// filters again.
 func NewFilter(arg string) (*Filter, error) {
+	key := filterKey{arg, DefaultFilterOptions}
+	if f, ok := filterCache.get(key); ok {
+		return f, nil
+	}
+	f, err := newFilter(arg)
+	if err != nil {
+		return nil, err
+	}
+	filterCache.add(key, f)
+	return f, nil
+}
+
+func newFilter(arg string) (*Filter, error) {
 	negate := strings.HasPrefix(arg, "!")
 	if negate {
 		arg = strings.TrimPrefix(arg, "!")
 	}
 
-	m := regexp.MustCompile(`^(?:(date|title|tag):)?(.+)$`).FindStringSubmatch(arg)
+	m := filterRe.FindStringSubmatch(arg)
 	if m == nil {
 		return nil, fmt.Errorf("invalid filter syntax: %s", arg)
 	}
@@ -55,7 +119,11 @@ func NewFilter(arg string) (*Filter, error) {
 	value := m[2]
 
 	// Strip surrounding quotes (both single and double)
-	value = strings.Trim(value, `"'`)
+	if v, ok := Unquote(value); ok {
+		value = v
+	} else {
+		value = strings.Trim(value, `"'`)
+	}
 
 	// Validate: if field is title and value has spaces, original should have been quoted
 	if fieldStr == "title" && strings.Contains(value, " ") {
@@ -65,43 +133,140 @@ func NewFilter(arg string) (*Filter, error) {
 		}
 	}
 
+	opts := DefaultFilterOptions
 	pattern := value
-	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
-		pattern = strings.TrimPrefix(strings.TrimSuffix(pattern, "/"), "/")
+	literal := true
+	if mm := modifierRe.FindStringSubmatch(pattern); mm != nil {
+		for _, c := range mm[1] {
+			switch c {
+			case 'i':
+				opts.MatchCase = false
+			case 'c':
+				opts.MatchCase = true
+			case 'w':
+				opts.WholeWord = true
+			}
+		}
+		pattern = mm[2]
+		literal = false
 	} else {
 		pattern = regexp.QuoteMeta(pattern)
 	}
 
-	re, err := regexp.Compile("(?i)" + pattern)
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.MatchCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("invalid regex: %v", err)
 	}
 
-	return &Filter{field: FilterField(fieldStr), re: re, negate: negate}, nil
+	f := &Filter{field: FilterField(fieldStr), re: re, negate: negate}
+	if literal && !opts.WholeWord {
+		switch {
+		case opts.MatchCase:
+			f.lit = value
+		case isASCII(value):
+			f.lit, f.fold = strings.ToLower(value), true
+		}
+	}
+	return f, nil
 }
 
-// IsMatch checks if a note matches this filter
+// match reports whether s matches f's pattern.
+func (f *Filter) match(s string) bool {
+	switch {
+	case f.lit == "":
+		return f.re.MatchString(s)
+	case !f.fold:
+		return strings.Contains(s, f.lit)
+	case isASCII(s):
+		return containsFoldASCII(s, f.lit)
+	default:
+		// Some non-ASCII letters fold to ASCII ones, such as the
+		// Kelvin sign to k, which the regexp handles.
+		return f.re.MatchString(s)
+	}
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFoldASCII reports whether the ASCII string s contains lower,
+// ignoring case. lower must be lowercase.
+func containsFoldASCII(s, lower string) bool {
+	for i := 0; i+len(lower) <= len(s); i++ {
+		j := 0
+		for ; j < len(lower); j++ {
+			c := s[i+j]
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			if c != lower[j] {
+				break
+			}
+		}
+		if j == len(lower) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unquote removes the single or double quotes around s. Inside them the
+// quote character is escaped by doubling it or with a backslash, and a
+// backslash is written \\; for example, "say ""hi""" and "say \"hi\""
+// both unquote to say "hi". It reports false if s is not a complete
+// quoted string.
+func Unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') {
+		return "", false
+	}
+	q := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == q || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case c == q && i+1 < len(s) && s[i+1] == q:
+			i++
+			b.WriteByte(q)
+		case c == q:
+			if i != len(s)-1 {
+				return "", false
+			}
+			return b.String(), true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// IsMatch checks if a note matches this filter. A filter on any field
+// tries the cheapest fields first: the identifier, then the tags, then
+// the title.
 func (f *Filter) IsMatch(n *Metadata) bool {
 	result := false
 	switch f.field {
 	case FilterDate:
-		result = f.re.MatchString(n.Identifier)
+		result = f.match(n.Identifier)
 	case FilterTitle:
-		result = f.re.MatchString(n.Title)
+		result = f.match(n.Title)
 	case FilterTag:
-		result = slices.ContainsFunc(n.Tags, func(kw string) bool {
-			return f.re.MatchString(kw)
-		})
+		result = slices.ContainsFunc(n.Tags, f.match)
 	case FilterAny: // any field
-		if f.re.MatchString(n.Identifier) {
-			result = true
-		} else if f.re.MatchString(n.Title) {
-			result = true
-		} else {
-			result = slices.ContainsFunc(n.Tags, func(kw string) bool {
-				return f.re.MatchString(kw)
-			})
-		}
+		result = f.match(n.Identifier) || slices.ContainsFunc(n.Tags, f.match) || f.match(n.Title)
 	default:
 		return false
 	}
@@ -110,3 +275,47 @@ func (f *Filter) IsMatch(n *Metadata) bool {
 	}
 	return result
 }
+
+// Score weights for the field a filter matched. A title match outranks a
+// tag match, which outranks an identifier match.
+const (
+	scoreTitle      = 4
+	scoreTag        = 2
+	scoreIdentifier = 1
+)
+
+// Score rates how well n matches the filters. Each non-negated filter
+// adds the weight of every field it matches.
+func (fs Filters) Score(n *Metadata) float64 {
+	score := 0.0
+	for _, f := range fs {
+		if f.negate {
+			continue
+		}
+		if (f.field == FilterTitle || f.field == FilterAny) && f.match(n.Title) {
+			score += scoreTitle
+		}
+		if (f.field == FilterTag || f.field == FilterAny) && slices.ContainsFunc(n.Tags, f.match) {
+			score += scoreTag
+		}
+		if (f.field == FilterDate || f.field == FilterAny) && f.match(n.Identifier) {
+			score += scoreIdentifier
+		}
+	}
+	return score
+}
+
+// Rank sorts md by descending score, newest first among notes with equal
+// scores. Pass Filters.Score to rank by filter matches alone.
+func Rank(md Results, score func(*Metadata) float64) {
+	scores := make(map[*Metadata]float64, len(md))
+	for _, n := range md {
+		scores[n] = score(n)
+	}
+	sort.SliceStable(md, func(i, j int) bool {
+		if si, sj := scores[md[i]], scores[md[j]]; si != sj {
+			return si > sj
+		}
+		return md[i].Identifier > md[j].Identifier
+	})
+}
@@ -0,0 +1,161 @@
+package metadata
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterModifiers(t *testing.T) {
+	n := &Metadata{Identifier: "20251112T221141", Title: "Go Proverbs", Tags: []string{"golang", "reading"}}
+	tests := []struct {
+		arg  string
+		opts FilterOptions
+		want bool
+	}{
+		{"title:go", FilterOptions{}, true},
+		{"title:go", FilterOptions{MatchCase: true}, false},
+		{"title:i/go/", FilterOptions{MatchCase: true}, true},
+		{"title:c/go/", FilterOptions{}, false},
+		{"title:c/Go/", FilterOptions{}, true},
+		{"tag:go", FilterOptions{}, true},
+		{"tag:go", FilterOptions{WholeWord: true}, false},
+		{"tag:w/go/", FilterOptions{}, false},
+		{"tag:w/golang|rust/", FilterOptions{}, true},
+		{"title:cw/Go/", FilterOptions{}, true},
+		{"!tag:w/go/", FilterOptions{}, true},
+	}
+	defer func(o FilterOptions) { DefaultFilterOptions = o }(DefaultFilterOptions)
+	for _, tt := range tests {
+		DefaultFilterOptions = tt.opts
+		f, err := NewFilter(tt.arg)
+		if err != nil {
+			t.Fatalf("NewFilter(%q): %v", tt.arg, err)
+		}
+		if got := f.IsMatch(n); got != tt.want {
+			t.Errorf("NewFilter(%q) with %+v: IsMatch = %v, want %v", tt.arg, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSetFilterOption(t *testing.T) {
+	defer func(o FilterOptions) { DefaultFilterOptions = o }(DefaultFilterOptions)
+	DefaultFilterOptions = FilterOptions{}
+	if err := SetFilterOption("matchcase", "on"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFilterOption("wholeword", "on"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (FilterOptions{MatchCase: true, WholeWord: true}); DefaultFilterOptions != want {
+		t.Errorf("DefaultFilterOptions = %+v, want %+v", DefaultFilterOptions, want)
+	}
+	if err := SetFilterOption("matchcase", "maybe"); err == nil {
+		t.Error("expected error for invalid value")
+	}
+	if err := SetFilterOption("fuzzy", "on"); err == nil {
+		t.Error("expected error for unknown option")
+	}
+}
+
+func TestRank(t *testing.T) {
+	rs := Results{
+		{Identifier: "20250101T000000", Title: "meeting notes", Tags: []string{"go"}},
+		{Identifier: "20250301T000000", Title: "shopping", Tags: []string{"go"}},
+		{Identifier: "20250201T000000", Title: "go proverbs", Tags: []string{"reading"}},
+		{Identifier: "20250401T000000", Title: "go tour", Tags: []string{"go"}},
+	}
+	fs, err := Filters{}.Parse([]string{"go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Rank(rs, fs.Score)
+	want := []string{"20250401T000000", "20250201T000000", "20250301T000000", "20250101T000000"}
+	for i, n := range rs {
+		if n.Identifier != want[i] {
+			t.Errorf("Rank()[%d] = %s, want %s", i, n.Identifier, want[i])
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{`'go proverbs'`, "go proverbs", true},
+		{`'it''s'`, "it's", true},
+		{`'it\'s'`, "it's", true},
+		{`"say ""hi"""`, `say "hi"`, true},
+		{`"a \\ b"`, `a \ b`, true},
+		{`'C:\dir'`, `C:\dir`, true},
+		{`''`, "", true},
+		{`'unterminated`, "", false},
+		{`'a'b'`, "", false},
+		{`plain`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Unquote(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Unquote(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	f, err := NewFilter(`title:'it''s a | test'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.IsMatch(&Metadata{Title: "It's a | test"}) {
+		t.Errorf("title:'it''s a | test' does not match its title")
+	}
+}
+
+func TestFilterLiteralMatch(t *testing.T) {
+	defer func(o FilterOptions) { DefaultFilterOptions = o }(DefaultFilterOptions)
+	tests := []struct {
+		arg   string
+		opts  FilterOptions
+		title string
+		want  bool
+	}{
+		{"title:go", FilterOptions{}, "Learning GO", true},
+		{"title:go", FilterOptions{MatchCase: true}, "Learning GO", false},
+		{"title:go", FilterOptions{MatchCase: true}, "go on", true},
+		{"title:kelvin", FilterOptions{}, "Kelvin scale", true}, // Kelvin sign folds to k
+		{"title:'a.b'", FilterOptions{}, "axb", false},
+		{"title:'a.b'", FilterOptions{}, "A.B", true},
+		{"title:ünï", FilterOptions{}, "ÜNÏCODE", true},
+	}
+	for _, tt := range tests {
+		DefaultFilterOptions = tt.opts
+		f, err := NewFilter(tt.arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.IsMatch(&Metadata{Title: tt.title}); got != tt.want {
+			t.Errorf("%s %+v on %q = %v, want %v", tt.arg, tt.opts, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCache(t *testing.T) {
+	defer func(o FilterOptions) { DefaultFilterOptions = o }(DefaultFilterOptions)
+	DefaultFilterOptions = FilterOptions{}
+	a, _ := NewFilter("tag:cached")
+	b, _ := NewFilter("tag:cached")
+	if a != b {
+		t.Error("NewFilter did not reuse the cached filter")
+	}
+	DefaultFilterOptions.MatchCase = true
+	if c, _ := NewFilter("tag:cached"); c == a {
+		t.Error("NewFilter reused a filter compiled under other options")
+	}
+
+	DefaultFilterOptions = FilterOptions{}
+	for i := 0; i < filterCacheSize; i++ {
+		NewFilter(fmt.Sprintf("tag:evict%d", i))
+	}
+	if d, _ := NewFilter("tag:cached"); d == a {
+		t.Error("least recently used filter was not evicted")
+	}
+}
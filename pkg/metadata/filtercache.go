@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"container/list"
+	"sync"
+)
+
+// filterCacheSize is the number of compiled filters kept by NewFilter.
+const filterCacheSize = 64
+
+// filterKey identifies a compiled filter: the same filter string compiles
+// differently under different DefaultFilterOptions.
+type filterKey struct {
+	arg  string
+	opts FilterOptions
+}
+
+type filterEntry struct {
+	key filterKey
+	f   *Filter
+}
+
+// lru is a fixed-size cache of compiled filters, evicting the least
+// recently used one.
+type lru struct {
+	mu    sync.Mutex
+	order *list.List // of *filterEntry, most recently used first
+	items map[filterKey]*list.Element
+}
+
+var filterCache = &lru{order: list.New(), items: make(map[filterKey]*list.Element)}
+
+func (c *lru) get(key filterKey) (*Filter, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*filterEntry).f, true
+}
+
+func (c *lru) add(key filterKey, f *Filter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(&filterEntry{key, f})
+	if c.order.Len() > filterCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*filterEntry).key)
+	}
+}
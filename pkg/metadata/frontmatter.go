@@ -30,6 +30,8 @@ type FrontMatter struct {
 	Tags       []string
 	Identifier string
 	Signature  string
+	Bib        string // citation key of the work a reference note describes
+	Due        string // optional deadline or reminder date, see ParseDue
 }
 
 // NewFrontMatter creates a new FrontMatter struct from given parameters
@@ -0,0 +1,30 @@
+package metadata
+
+import "testing"
+
+func FuzzNewFilter(f *testing.F) {
+	for _, s := range []string{"tag:go", "!title:'go proverbs'", "date:2025", "title:cw/Go/", "tag:/dev|meeting/", "!", "title:", "w//", "title:\"a b", "/(/"} {
+		f.Add(s)
+	}
+	n := &Metadata{Identifier: "20251112T221141", Title: "Go Proverbs", Tags: []string{"golang", "reading"}}
+	f.Fuzz(func(t *testing.T, arg string) {
+		fl, err := NewFilter(arg)
+		if err != nil {
+			return
+		}
+		fl.IsMatch(n)
+		Filters{fl}.Score(n)
+
+		// A negated filter matches exactly the notes the filter does not.
+		if arg == "" || arg[0] == '!' {
+			return
+		}
+		neg, err := NewFilter("!" + arg)
+		if err != nil {
+			t.Fatalf("NewFilter(%q) failed after NewFilter(%q) succeeded: %v", "!"+arg, arg, err)
+		}
+		if fl.IsMatch(n) == neg.IsMatch(n) {
+			t.Errorf("NewFilter(%q) and its negation agree on %+v", arg, n)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ type Metadata struct {
 	Signature  string
 	Title      string
 	Tags       []string
+	Due        time.Time // from the due front matter field; zero if none
 }
 
 type Results []*Metadata
@@ -28,6 +30,15 @@ const (
 	SortById    SortBy = "id"
 	SortByDate  SortBy = "date"
 	SortByTitle SortBy = "title"
+	SortByTags  SortBy = "tags"
+
+	// SortByRelevance orders notes by how well they match a query; see Rank.
+	SortByRelevance SortBy = "relevance"
+
+	// SortByFrequency and SortByLastOpened order notes by how often and
+	// how recently they were opened.
+	SortByFrequency  SortBy = "frequency"
+	SortByLastOpened SortBy = "lastopened"
 )
 
 type SortOrder int
@@ -56,6 +67,15 @@ func Sort(md Results, sortType SortBy, order SortOrder) {
 				return strings.ToLower(md[i].Title) > strings.ToLower(md[j].Title)
 			}
 		})
+	case SortByTags:
+		sort.Slice(md, func(i, j int) bool {
+			a, b := strings.Join(md[i].Tags, ","), strings.Join(md[j].Tags, ",")
+			if order == SortOrderAsc {
+				return a < b
+			} else {
+				return a > b
+			}
+		})
 	default:
 		sort.Slice(md, func(i, j int) bool {
 			return md[i].Identifier > md[j].Identifier // Reverse chronological by default
@@ -69,21 +89,21 @@ func ParseFilename(path string) *Metadata {
 	fname := filepath.Base(path)
 	note := &Metadata{Path: path}
 
-	if m := regexp.MustCompile(`^(\d{8}T\d{6})`).FindStringSubmatch(fname); m != nil {
-		note.Identifier = m[1]
+	if len(fname) >= 15 && IsIdentifier(fname[:15]) {
+		note.Identifier = fname[:15]
 	}
 
 	// Extract signature (optional component between identifier and title)
-	if m := regexp.MustCompile(`==([^-]+?)--`).FindStringSubmatch(fname); m != nil {
+	if m := signatureRe.FindStringSubmatch(fname); m != nil {
 		note.Signature = m[1]
 	}
 
 	// Extract title from filename
-	if m := regexp.MustCompile(`--([^_\.]+)`).FindStringSubmatch(fname); m != nil {
+	if m := titleRe.FindStringSubmatch(fname); m != nil {
 		note.Title = strings.ReplaceAll(m[1], "-", " ")
 	}
 
-	if m := regexp.MustCompile(`__(.+?)(?:\.|$)`).FindStringSubmatch(fname); m != nil {
+	if m := tagsRe.FindStringSubmatch(fname); m != nil {
 		note.Tags = strings.Split(m[1], "_")
 	}
 
@@ -114,17 +134,51 @@ func ValidateTags(tags []string) []string {
 	return invalid
 }
 
+var (
+	identifierRe = regexp.MustCompile(`^\d{8}T\d{6}$`)
+
+	// File name components, compiled once since ParseFilename runs for
+	// every file of a scan.
+	signatureRe = regexp.MustCompile(`==([^-_.]+?)(?:--|__|\.|$)`)
+	titleRe     = regexp.MustCompile(`--([^_\.]+)`)
+	tagsRe      = regexp.MustCompile(`__(.+?)(?:\.|$)`)
+
+	slugRe             = regexp.MustCompile(`[^a-z0-9-]`)
+	signatureSpecialRe = regexp.MustCompile(`[{}!@#$%^&*()+'"?,.\\|;:~\x60''""/-]`)
+	equalsRunRe        = regexp.MustCompile(`={3,}`)
+
+	// LinkRe matches a denote: link; the submatch is the identifier.
+	LinkRe = regexp.MustCompile(`denote:(\d{8}T\d{6})`)
+)
+
+// IsIdentifier reports whether s is a Denote identifier.
+func IsIdentifier(s string) bool {
+	return identifierRe.MatchString(s)
+}
+
 // GenerateIdentifier creates a new identifier timestamp.
 func GenerateIdentifier() string {
 	return time.Now().Format("20060102T150405")
 }
 
+// FreeIdentifier returns the identifier of t, or of the first second
+// after t whose identifier is not taken.
+func FreeIdentifier(t time.Time, taken map[string]bool) string {
+	for {
+		id := t.Format("20060102T150405")
+		if !taken[id] {
+			return id
+		}
+		t = t.Add(time.Second)
+	}
+}
+
 // slugifyTitle converts a title to a filesystem-safe slug.
 func slugifyTitle(title string) string {
 	slug := strings.ToLower(title)
 	slug = strings.ReplaceAll(slug, " ", "-")
 	slug = strings.ReplaceAll(slug, "_", "-")
-	return regexp.MustCompile(`[^a-z0-9-]`).ReplaceAllString(slug, "")
+	return slugRe.ReplaceAllString(slug, "")
 }
 
 // slugifySignature converts a signature to Denote-compliant format.
@@ -135,16 +189,38 @@ func slugifySignature(sig string) string {
 	slug = strings.ReplaceAll(slug, " ", "==")
 	slug = strings.ReplaceAll(slug, "_", "==")
 	// Remove special characters per Denote spec
-	slug = regexp.MustCompile(`[{}!@#$%^&*()+'"?,.\\|;:~\x60''""/-]`).ReplaceAllString(slug, "")
+	slug = signatureSpecialRe.ReplaceAllString(slug, "")
 	// Normalize consecutive equals signs (3 or more) to double equals
-	slug = regexp.MustCompile(`={3,}`).ReplaceAllString(slug, "==")
+	slug = equalsRunRe.ReplaceAllString(slug, "==")
 	// Trim trailing equals
 	slug = strings.Trim(slug, "=")
 	return slug
 }
 
+// SortTags makes NormalizeTags sort tags alphabetically.
+var SortTags = false
+
+// NormalizeTags returns tags lowercased, trimmed, and without empty
+// tags or duplicates, in the order first given or, with SortTags,
+// sorted. Every file name and front matter Denote writes uses it, so a
+// set of tags is always written the same way.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	if SortTags {
+		slices.Sort(out)
+	}
+	return out
+}
+
 // formatKeywords formats keywords for a denote filename.
 func formatKeywords(keywords []string) string {
+	keywords = NormalizeTags(keywords)
 	if len(keywords) == 0 {
 		return ""
 	}
@@ -161,9 +237,14 @@ func formatSignature(sig string) string {
 }
 
 // BuildFilename constructs a denote filename from metadata components.
+// A note whose title has no characters left after slugifying is named
+// by its identifier alone, without the -- title separator.
 func BuildFilename(fm *FrontMatter, ext string) string {
-	titleSlug := slugifyTitle(fm.Title)
+	titlePart := ""
+	if slug := slugifyTitle(strings.TrimSpace(fm.Title)); strings.Trim(slug, "-") != "" {
+		titlePart = "--" + slug
+	}
 	signaturePart := formatSignature(fm.Signature)
 	keywordsPart := formatKeywords(fm.Tags)
-	return fmt.Sprintf("%s%s--%s%s%s", fm.Identifier, signaturePart, titleSlug, keywordsPart, ext)
+	return fmt.Sprintf("%s%s%s%s%s", fm.Identifier, signaturePart, titlePart, keywordsPart, ext)
 }
@@ -25,6 +25,17 @@ func TestGenerateIdentifier(t *testing.T) {
 	}
 }
 
+func TestFreeIdentifier(t *testing.T) {
+	at := time.Date(2023, 5, 5, 12, 0, 0, 0, time.Local)
+	taken := map[string]bool{"20230505T120000": true, "20230505T120001": true}
+	if got, want := FreeIdentifier(at, taken), "20230505T120002"; got != want {
+		t.Errorf("FreeIdentifier() = %s, want %s", got, want)
+	}
+	if got, want := FreeIdentifier(at, nil), "20230505T120000"; got != want {
+		t.Errorf("FreeIdentifier() with nothing taken = %s, want %s", got, want)
+	}
+}
+
 // TestSlugifyTitle validates title slugification
 // Maps to dt-denote-sluggify-title and dt-denote-sluggify from original tests
 func TestSlugifyTitle(t *testing.T) {
@@ -128,6 +139,11 @@ func TestFormatKeywords(t *testing.T) {
 			input: []string{"one", "two", "three"},
 			want:  "__one_two_three",
 		},
+		{
+			name:  "duplicate and uppercase keywords",
+			input: []string{"Go", "notes", "go", ""},
+			want:  "__go_notes",
+		},
 	}
 
 	for _, tt := range tests {
@@ -140,6 +156,20 @@ func TestFormatKeywords(t *testing.T) {
 	}
 }
 
+func TestNormalizeTags(t *testing.T) {
+	defer func(v bool) { SortTags = v }(SortTags)
+	tags := []string{"Zeta", " alpha ", "zeta", "", "beta"}
+
+	SortTags = false
+	if got, want := NormalizeTags(tags), []string{"zeta", "alpha", "beta"}; !slices.Equal(got, want) {
+		t.Errorf("NormalizeTags(%q) = %q, want %q", tags, got, want)
+	}
+	SortTags = true
+	if got, want := NormalizeTags(tags), []string{"alpha", "beta", "zeta"}; !slices.Equal(got, want) {
+		t.Errorf("NormalizeTags(%q) with SortTags = %q, want %q", tags, got, want)
+	}
+}
+
 // TestSlugifySignature validates signature slugification
 func TestSlugifySignature(t *testing.T) {
 	tests := []struct {
@@ -227,6 +257,16 @@ func TestBuildFilename(t *testing.T) {
 			want:       "20231225T120000--my-title__tag1_tag2.md",
 			ftype:      FileTypeMdYaml,
 		},
+		{
+			name:       "empty title",
+			identifier: "20231225T120000",
+			signature:  "1a",
+			title:      "  ",
+			keywords:   []string{"tag"},
+			ext:        ".md",
+			want:       "20231225T120000==1a__tag.md",
+			ftype:      FileTypeMdYaml,
+		},
 		{
 			name:       "filename without keywords",
 			identifier: "20231225T120000",
@@ -345,6 +385,22 @@ func TestParseFilename(t *testing.T) {
 			wantTitle:      "note",
 			wantTags:       []string{"work"},
 		},
+		{
+			name:           "untitled with signature",
+			path:           "20240101T000000==1a__work.org",
+			wantIdentifier: "20240101T000000",
+			wantSignature:  "1a",
+			wantTitle:      "",
+			wantTags:       []string{"work"},
+		},
+		{
+			name:           "identifier only",
+			path:           "20240101T000000.md",
+			wantIdentifier: "20240101T000000",
+			wantSignature:  "",
+			wantTitle:      "",
+			wantTags:       nil,
+		},
 		{
 			name:           "filename with signature and no tags",
 			path:           "20240101T000000==test--title.md",
@@ -483,6 +539,23 @@ func TestSort(t *testing.T) {
 		}
 	})
 
+	t.Run("sort by tags ascending", func(t *testing.T) {
+		testData := Results{
+			{Identifier: "1", Tags: []string{"work"}},
+			{Identifier: "2", Tags: []string{}},
+			{Identifier: "3", Tags: []string{"home", "todo"}},
+		}
+
+		Sort(testData, SortByTags, SortOrderAsc)
+
+		if testData[0].Identifier != "2" {
+			t.Errorf("First item identifier = %q, want %q", testData[0].Identifier, "2")
+		}
+		if testData[2].Identifier != "1" {
+			t.Errorf("Last item identifier = %q, want %q", testData[2].Identifier, "1")
+		}
+	})
+
 	t.Run("sort by title case insensitive", func(t *testing.T) {
 		testData := Results{
 			{Identifier: "1", Title: "zebra"},
@@ -503,4 +576,18 @@ func TestSort(t *testing.T) {
 			t.Errorf("Third item title = %q, want %q", testData[2].Title, "zebra")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestIsIdentifier(t *testing.T) {
+	tests := map[string]bool{
+		"20240101T120000":        true,
+		"20240101T1200":          false,
+		"denote:20240101T120000": false,
+		"20240101T120000--x":     false,
+	}
+	for s, want := range tests {
+		if got := IsIdentifier(s); got != want {
+			t.Errorf("IsIdentifier(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OrphanOptions tunes Orphans.
+type OrphanOptions struct {
+	MinAge      time.Duration // notes modified more recently are kept
+	ExcludeTags []string      // notes with any of these tags are kept
+}
+
+// Orphans returns the notes of rs that link to no other note of rs, are
+// linked to by none, and were last modified at least opts.MinAge before
+// now: forgotten notes to prune or integrate. Links are read from the
+// files of text notes (.md, .org, .txt); other notes have no outbound
+// links.
+func Orphans(rs Results, opts OrphanOptions, now time.Time) Results {
+	known := make(map[string]bool, len(rs))
+	for _, n := range rs {
+		known[n.Identifier] = true
+	}
+	linked := make(map[string]bool)
+	for _, n := range rs {
+		for _, id := range readLinks(n.Path) {
+			if id != n.Identifier && known[id] {
+				linked[n.Identifier] = true
+				linked[id] = true
+			}
+		}
+	}
+
+	var orphans Results
+	for _, n := range rs {
+		if linked[n.Identifier] || slices.ContainsFunc(n.Tags, func(t string) bool { return slices.Contains(opts.ExcludeTags, t) }) {
+			continue
+		}
+		info, err := os.Stat(n.Path)
+		if err != nil || now.Sub(info.ModTime()) < opts.MinAge {
+			continue
+		}
+		orphans = append(orphans, n)
+	}
+	return orphans
+}
+
+// readLinks returns the identifiers of the denote: links in the text note
+// at path.
+func readLinks(path string) []string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".org", ".txt":
+	default:
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, m := range LinkRe.FindAllSubmatch(content, -1) {
+		ids = append(ids, string(m[1]))
+	}
+	return ids
+}
@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOrphans(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.AddDate(0, 0, -100)
+	files := []struct {
+		name, content string
+		mtime         time.Time
+	}{
+		{"20240101T120000--links-out.md", "see denote:20240102T120000\n", old},
+		{"20240102T120000--linked-to.md", "", old},
+		{"20240103T120000--alone.md", "denote:20240103T120000 links to itself\n", old},
+		{"20240104T120000--recent.md", "", now},
+		{"20240105T120000--kept__inbox.md", "", old},
+		{"20240106T120000--dangling.org", "denote:20991231T000000\n", old},
+		{"20240107T120000--scan.pdf", "", old},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, f.mtime, f.mtime)
+	}
+	rs, err := ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphans := Orphans(rs, OrphanOptions{MinAge: 30 * 24 * time.Hour, ExcludeTags: []string{"inbox"}}, now)
+	var got []string
+	for _, n := range orphans {
+		got = append(got, n.Identifier)
+	}
+	want := []string{"20240103T120000", "20240106T120000", "20240107T120000"}
+	if len(got) != len(want) {
+		t.Fatalf("Orphans() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Orphans() = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Route sends new notes tagged Tag to the subdirectory Dir.
+type Route struct {
+	Tag string
+	Dir string // relative to the denote directory
+}
+
+// ParseRoutes parses routing rules of the form tag=dir, e.g.
+// "journal=journal" or "work=projects/work".
+func ParseRoutes(rules []string) ([]Route, error) {
+	var routes []Route
+	for _, rule := range rules {
+		tag, dir, ok := strings.Cut(rule, "=")
+		tag, dir = strings.TrimSpace(tag), strings.Trim(strings.TrimSpace(dir), "/")
+		if !ok || tag == "" || dir == "" {
+			return nil, fmt.Errorf("invalid route %q: want tag=dir", rule)
+		}
+		if filepath.IsAbs(dir) || strings.HasPrefix(filepath.Clean(dir), "..") {
+			return nil, fmt.Errorf("invalid route %q: dir must be inside the denote directory", rule)
+		}
+		routes = append(routes, Route{Tag: tag, Dir: dir})
+	}
+	return routes, nil
+}
+
+// RouteDir returns the directory of the first route whose tag is among
+// tags, or "" if none applies. Earlier routes take precedence.
+func RouteDir(routes []Route, tags []string) string {
+	for _, r := range routes {
+		for _, t := range tags {
+			if t == r.Tag {
+				return r.Dir
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package metadata
+
+import "testing"
+
+func TestRoutes(t *testing.T) {
+	routes, err := ParseRoutes([]string{"journal=journal", "work = /projects/work/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{[]string{"journal"}, "journal"},
+		{[]string{"meeting", "work"}, "projects/work"},
+		{[]string{"work", "journal"}, "journal"},
+		{[]string{"idea"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := RouteDir(routes, tt.tags); got != tt.want {
+			t.Errorf("RouteDir(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"journal", "=journal", "work=../elsewhere", "work="} {
+		if _, err := ParseRoutes([]string{bad}); err == nil {
+			t.Errorf("ParseRoutes(%q) succeeded", bad)
+		}
+	}
+}
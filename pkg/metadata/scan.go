@@ -0,0 +1,204 @@
+package metadata
+
+import (
+	"context"
+	"denote/pkg/ignore"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ScanOptions limits which files ScanDir considers notes.
+type ScanOptions struct {
+	MaxDepth     int      // deepest subdirectory level searched; 0 is unlimited, 1 is dir only
+	Include      []string // if set, files must match one of these globs
+	Exclude      []string // files and directories matching these globs are skipped
+	Strict       bool     // only accept names that follow the full Denote scheme
+	SkipSymlinks bool     // ignore symbolic links instead of following them
+}
+
+// DefaultScanOptions applies to ScanDir.
+var DefaultScanOptions ScanOptions
+
+var strictNameRe = regexp.MustCompile(`^\d{8}T\d{6}(==[^-]+)?(--[^_.]+)?(__[^.]+)?(\.[^.]+)+$`)
+
+// ScanDir walks dir and returns the filename metadata of every file
+// whose name starts with a denote identifier. Hidden files and
+// directories (.git, .stversions, ...) are skipped, as are paths listed
+// in dir/.denoteignore and files excluded by DefaultScanOptions.
+// Symbolic links are followed unless SkipSymlinks is set; a file reached
+// through several links is returned once, and directory cycles are cut.
+func ScanDir(dir string) (Results, error) {
+	return DefaultScanOptions.ScanDirContext(context.Background(), dir)
+}
+
+// ScanDirContext is like ScanDir but stops with ctx's error once ctx is
+// done, checked as each directory is read.
+func ScanDirContext(ctx context.Context, dir string) (Results, error) {
+	return DefaultScanOptions.ScanDirContext(ctx, dir)
+}
+
+// ScanDir is like the package-level ScanDir but uses opts.
+func (opts ScanOptions) ScanDir(dir string) (Results, error) {
+	return opts.ScanDirContext(context.Background(), dir)
+}
+
+// ScanDirContext is like the package-level ScanDirContext but uses opts.
+func (opts ScanOptions) ScanDirContext(ctx context.Context, dir string) (Results, error) {
+	ign, err := ignore.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &scanner{ctx: ctx, opts: opts, ign: ign, seen: make(map[string]bool)}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		s.seen[real] = true
+	}
+	if err := s.walk(dir, "", 0); err != nil {
+		return nil, err
+	}
+	return s.rs, nil
+}
+
+type scanner struct {
+	ctx  context.Context
+	opts ScanOptions
+	ign  *ignore.Matcher
+	seen map[string]bool // resolved paths already visited
+	rs   Results
+}
+
+func (s *scanner) walk(path, rel string, depth int) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(path, e.Name())
+		r := filepath.Join(rel, e.Name())
+		if strings.HasPrefix(e.Name(), ".") || matchAny(s.opts.Exclude, r) {
+			continue
+		}
+
+		isDir := e.IsDir()
+		if e.Type()&fs.ModeSymlink != 0 {
+			if s.opts.SkipSymlinks {
+				continue
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				continue // dangling link
+			}
+			isDir = info.IsDir()
+		}
+		if s.ign.Ignored(r, isDir) {
+			continue
+		}
+
+		if isDir {
+			if s.opts.MaxDepth > 0 && depth+1 >= s.opts.MaxDepth {
+				continue
+			}
+			if !s.visit(p) {
+				continue
+			}
+			if err := s.walk(p, r, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(s.opts.Include) > 0 && !matchAny(s.opts.Include, r) {
+			continue
+		}
+		if s.opts.Strict && !strictNameRe.MatchString(e.Name()) {
+			continue
+		}
+		md := ParseFilename(p)
+		if md.Identifier == "" || !s.visit(p) {
+			continue
+		}
+		s.rs = append(s.rs, md)
+	}
+	return nil
+}
+
+// visit records the resolved form of path and reports whether it is new.
+func (s *scanner) visit(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		real = path
+	}
+	if s.seen[real] {
+		return false
+	}
+	s.seen[real] = true
+	return true
+}
+
+// matchAny reports whether rel, a slash-separated path relative to the
+// scanned directory, matches one of globs. Globs containing a slash match
+// the whole relative path; others match any single path element.
+func matchAny(globs []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	return slices.ContainsFunc(globs, func(g string) bool {
+		if strings.Contains(g, "/") {
+			ok, _ := filepath.Match(g, rel)
+			return ok
+		}
+		for _, elem := range strings.Split(rel, "/") {
+			if ok, _ := filepath.Match(g, elem); ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FindNote returns the note with identifier id anywhere below dir,
+// including subdirectories, skipping what ScanDir skips. If several
+// files share the identifier, the first by path is returned.
+func FindNote(dir, id string) (*Metadata, bool) {
+	return DefaultScanOptions.FindNote(dir, id)
+}
+
+// FindNote is like the package-level FindNote but uses opts, e.g. to
+// bound the search with MaxDepth.
+func (opts ScanOptions) FindNote(dir, id string) (*Metadata, bool) {
+	rs, err := opts.ScanDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	var found *Metadata
+	for _, md := range rs {
+		if md.Identifier == id && (found == nil || md.Path < found.Path) {
+			found = md
+		}
+	}
+	return found, found != nil
+}
+
+// Duplicates groups the notes in rs that share an identifier. Groups are
+// ordered by identifier and their notes by path.
+func Duplicates(rs Results) []Results {
+	byID := make(map[string]Results)
+	for _, md := range rs {
+		byID[md.Identifier] = append(byID[md.Identifier], md)
+	}
+	var dups []Results
+	for _, group := range byID {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Path < group[j].Path })
+		dups = append(dups, group)
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i][0].Identifier < dups[j][0].Identifier })
+	return dups
+}
@@ -0,0 +1,195 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanDir(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"20240101T120000==1a--first__a.md",
+		"journal/20240102T120000--second.org",
+		"README.md",
+		".git/20240103T120000--hidden.md",
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rs, err := ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("ScanDir() returned %d notes, want 2", len(rs))
+	}
+	if rs[0].Identifier != "20240101T120000" || rs[0].Signature != "1a" {
+		t.Errorf("rs[0] = %+v", rs[0])
+	}
+	if rs[1].Path != filepath.Join(dir, "journal", "20240102T120000--second.org") {
+		t.Errorf("rs[1].Path = %q", rs[1].Path)
+	}
+}
+
+func TestScanDirContext(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "20240101T120000--first.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScanDirContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("ScanDirContext() with a cancelled context: err = %v", err)
+	}
+	if rs, err := ScanDirContext(context.Background(), dir); err != nil || len(rs) != 1 {
+		t.Errorf("ScanDirContext() = %v, %v", rs, err)
+	}
+}
+
+func TestScanOptions(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"20240101T120000--top__a.md",
+		"20240101T120001 notes.md",
+		"journal/20240102T120000--day.org",
+		"journal/2024/20240103T120000--deep.md",
+		"software/20240104T120000--build.log",
+		"attach/20240105T120000--scan.pdf",
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts ScanOptions
+		want int
+	}{
+		{"default", ScanOptions{}, 6},
+		{"top level only", ScanOptions{MaxDepth: 1}, 2},
+		{"two levels", ScanOptions{MaxDepth: 2}, 5},
+		{"exclude dir", ScanOptions{Exclude: []string{"software"}}, 5},
+		{"exclude path", ScanOptions{Exclude: []string{"journal/2024"}}, 5},
+		{"include ext", ScanOptions{Include: []string{"*.md", "*.org"}}, 4},
+		{"strict", ScanOptions{Strict: true}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := tt.opts.ScanDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != tt.want {
+				t.Errorf("ScanDir() returned %d notes, want %d", len(rs), tt.want)
+			}
+		})
+	}
+}
+
+func TestScanDirIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".denoteignore":                        "attach/\n*.log\n",
+		"20240101T120000--top.md":              "",
+		"20240101T120001--build.log":           "",
+		"attach/20240102T120000--scan.pdf":     "",
+		"journal/20240103T120000--day.org":     "",
+		"journal/attach/20240104T120000--x.md": "",
+	}
+	for f, content := range files {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rs, err := ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 {
+		t.Errorf("ScanDir() returned %d notes, want 2: %v", len(rs), rs)
+	}
+}
+
+func TestScanDirSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "20240101T120000--note.md")
+	if err := os.WriteFile(note, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "20240102T120000--linked.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"20240101T120000--alias.md": note,                    // same file twice
+		"shared":                    outside,                 // linked directory
+		"loop":                      dir,                     // cycle
+		"20240103T120000--gone.md":  filepath.Join(dir, "x"), // dangling
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skip(err)
+		}
+	}
+
+	rs, err := ScanOptions{}.ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 {
+		t.Errorf("ScanDir() following links returned %d notes, want 2: %v", len(rs), rs)
+	}
+	rs, err = ScanOptions{SkipSymlinks: true}.ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 1 {
+		t.Errorf("ScanDir() skipping links returned %d notes, want 1: %v", len(rs), rs)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	rs := Results{
+		{Identifier: "20240102T000000", Path: "b/20240102T000000--x.md"},
+		{Identifier: "20240101T000000", Path: "20240101T000000--a.md"},
+		{Identifier: "20240102T000000", Path: "a/20240102T000000--x.md"},
+	}
+	dups := Duplicates(rs)
+	if len(dups) != 1 || len(dups[0]) != 2 {
+		t.Fatalf("Duplicates() = %v, want one group of 2", dups)
+	}
+	if dups[0][0].Path != "a/20240102T000000--x.md" {
+		t.Errorf("Duplicates()[0][0].Path = %q", dups[0][0].Path)
+	}
+}
+
+func TestFindNote(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "projects", "go", "20240102T120000--deep__dev.md")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	md, ok := FindNote(dir, "20240102T120000")
+	if !ok || md.Path != path {
+		t.Errorf("FindNote() = %v, %v, want %q", md, ok, path)
+	}
+	if _, ok := FindNote(dir, "20240101T120000"); ok {
+		t.Error("FindNote() found a missing note")
+	}
+}
@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Signatures in a Folgezettel sequence alternate between numbers and
+// letters: 1, 1a, 1a1, 1a2, 1b, 2, ... Each letter or number run is one
+// level of the hierarchy.
+var sequencePartRe = regexp.MustCompile(`[0-9]+|[a-z]+`)
+
+// SequenceParts splits a Folgezettel signature into its levels, e.g.
+// "1a12b" into ["1" "a" "12" "b"]. It returns nil if sig does not start
+// with a number or contains anything other than digits and lowercase
+// letters.
+func SequenceParts(sig string) []string {
+	parts := sequencePartRe.FindAllString(sig, -1)
+	if len(parts) == 0 || strings.Join(parts, "") != sig || parts[0][0] > '9' {
+		return nil
+	}
+	return parts
+}
+
+// NextSibling returns the first signature after sig at the same level
+// that is not in taken, e.g. "1a" -> "1b", "1" -> "2".
+func NextSibling(sig string, taken []string) (string, error) {
+	parts := SequenceParts(sig)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("not a sequence signature: %q", sig)
+	}
+	prefix := strings.Join(parts[:len(parts)-1], "")
+	last := parts[len(parts)-1]
+	for {
+		last = incrementPart(last)
+		if next := prefix + last; !slices.Contains(taken, next) {
+			return next, nil
+		}
+	}
+}
+
+// NextChild returns the first signature one level below sig that is not
+// in taken, e.g. "1" -> "1a", "1a" -> "1a1" (or "1a2" if taken).
+func NextChild(sig string, taken []string) (string, error) {
+	parts := SequenceParts(sig)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("not a sequence signature: %q", sig)
+	}
+	child := "a"
+	if last := parts[len(parts)-1]; last[0] >= 'a' && last[0] <= 'z' {
+		child = "1"
+	}
+	for slices.Contains(taken, sig+child) {
+		child = incrementPart(child)
+	}
+	return sig + child, nil
+}
+
+// incrementPart increments a number ("9" -> "10") or a letter run in
+// base 26 ("z" -> "aa", "az" -> "ba").
+func incrementPart(p string) string {
+	if n, err := strconv.Atoi(p); err == nil {
+		return strconv.Itoa(n + 1)
+	}
+	b := []byte(p)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 'z' {
+			b[i]++
+			return string(b)
+		}
+		b[i] = 'a'
+	}
+	return "a" + string(b)
+}
+
+// CompareSequence orders Folgezettel signatures level by level, comparing
+// numbers numerically and letters alphabetically, with a parent before
+// its children: 1 < 1a < 1a1 < 1a2 < 1b < 2 < 10.
+func CompareSequence(a, b string) int {
+	pa, pb := SequenceParts(a), SequenceParts(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if c := comparePart(pa[i], pb[i]); c != 0 {
+			return c
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func comparePart(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na - nb
+	}
+	if len(a) != len(b) {
+		return len(a) - len(b) // "z" < "aa"
+	}
+	return strings.Compare(a, b)
+}
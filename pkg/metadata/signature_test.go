@@ -0,0 +1,91 @@
+package metadata
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSequenceParts(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"1", []string{"1"}},
+		{"1a12b", []string{"1", "a", "12", "b"}},
+		{"", nil},
+		{"1=a", nil},
+		{"A1", nil},
+		{"draft", nil},
+	}
+	for _, tt := range tests {
+		if got := SequenceParts(tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("SequenceParts(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNextSibling(t *testing.T) {
+	tests := []struct {
+		sig   string
+		taken []string
+		want  string
+	}{
+		{"1", nil, "2"},
+		{"1", []string{"2", "3"}, "4"},
+		{"1a", []string{"1b"}, "1c"},
+		{"1z", nil, "1aa"},
+		{"1a9", nil, "1a10"},
+	}
+	for _, tt := range tests {
+		got, err := NextSibling(tt.sig, tt.taken)
+		if err != nil {
+			t.Errorf("NextSibling(%q) error: %v", tt.sig, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NextSibling(%q, %v) = %q, want %q", tt.sig, tt.taken, got, tt.want)
+		}
+	}
+	if _, err := NextSibling("", nil); err == nil {
+		t.Error("NextSibling(\"\") succeeded, want error")
+	}
+}
+
+func TestNextChild(t *testing.T) {
+	tests := []struct {
+		sig   string
+		taken []string
+		want  string
+	}{
+		{"1", nil, "1a"},
+		{"1", []string{"1a", "1b"}, "1c"},
+		{"1a", nil, "1a1"},
+		{"1a", []string{"1a1"}, "1a2"},
+		{"2b3", nil, "2b3a"},
+	}
+	for _, tt := range tests {
+		got, err := NextChild(tt.sig, tt.taken)
+		if err != nil {
+			t.Errorf("NextChild(%q) error: %v", tt.sig, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NextChild(%q, %v) = %q, want %q", tt.sig, tt.taken, got, tt.want)
+		}
+	}
+	if _, err := NextChild("x-y", nil); err == nil {
+		t.Error("NextChild(\"x-y\") succeeded, want error")
+	}
+	if _, err := NextChild("draft", nil); err == nil {
+		t.Error("NextChild(\"draft\") succeeded, want error")
+	}
+}
+
+func TestCompareSequence(t *testing.T) {
+	sigs := []string{"10", "1a2", "2", "1", "1b", "1a", "1a10", "1a1", "1aa", "1z"}
+	slices.SortFunc(sigs, CompareSequence)
+	want := []string{"1", "1a", "1a1", "1a2", "1a10", "1b", "1z", "1aa", "2", "10"}
+	if !slices.Equal(sigs, want) {
+		t.Errorf("sorted = %v, want %v", sigs, want)
+	}
+}
@@ -0,0 +1,41 @@
+package metadata
+
+import (
+	"path"
+	"sort"
+	"time"
+)
+
+// ViewRoots are the top-level directories of the browsable views of a
+// collection: notes by tag and by the year and month of their identifier.
+var ViewRoots = []string{"by-tag", "by-date"}
+
+// Views groups the notes of rs into the directories of the browsable
+// views, by-tag/<tag> and by-date/<YYYY>/<MM>, slash-separated. A note is
+// in the directory of each of its tags; notes without a valid identifier
+// are in no by-date directory. Notes keep their order within each
+// directory.
+func Views(rs Results) map[string]Results {
+	views := make(map[string]Results)
+	for _, n := range rs {
+		for _, tag := range n.Tags {
+			dir := path.Join("by-tag", tag)
+			views[dir] = append(views[dir], n)
+		}
+		if t, err := time.Parse("20060102T150405", n.Identifier); err == nil {
+			dir := path.Join("by-date", t.Format("2006"), t.Format("01"))
+			views[dir] = append(views[dir], n)
+		}
+	}
+	return views
+}
+
+// ViewDirs returns the directories of views in order.
+func ViewDirs(views map[string]Results) []string {
+	dirs := make([]string, 0, len(views))
+	for dir := range views {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
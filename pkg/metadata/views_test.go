@@ -0,0 +1,36 @@
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestViews(t *testing.T) {
+	rs := Results{
+		{Identifier: "20240115T090000", Tags: []string{"work", "meeting"}},
+		{Identifier: "20240203T120000", Tags: []string{"work"}},
+		{Identifier: "20240120T080000"},
+		{Identifier: "notanid", Tags: []string{"misc"}},
+	}
+	views := Views(rs)
+	want := map[string][]string{
+		"by-tag/work":     {"20240115T090000", "20240203T120000"},
+		"by-tag/meeting":  {"20240115T090000"},
+		"by-tag/misc":     {"notanid"},
+		"by-date/2024/01": {"20240115T090000", "20240120T080000"},
+		"by-date/2024/02": {"20240203T120000"},
+	}
+	got := make(map[string][]string)
+	for dir, notes := range views {
+		for _, n := range notes {
+			got[dir] = append(got[dir], n.Identifier)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Views() = %v, want %v", got, want)
+	}
+	dirs := ViewDirs(views)
+	if dirs[0] != "by-date/2024/01" || dirs[len(dirs)-1] != "by-tag/work" {
+		t.Errorf("ViewDirs() = %v", dirs)
+	}
+}
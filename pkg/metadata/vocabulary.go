@@ -0,0 +1,118 @@
+package metadata
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TagPolicy is how a Vocabulary treats tags it does not know.
+type TagPolicy string
+
+const (
+	TagPolicyWarn    TagPolicy = "warn"    // keep the tag and warn
+	TagPolicyReject  TagPolicy = "reject"  // refuse the change
+	TagPolicySuggest TagPolicy = "suggest" // use the nearest known tag, if any
+)
+
+// Vocabulary is a controlled list of known tags. A Vocabulary without
+// known tags accepts every tag.
+type Vocabulary struct {
+	Known  []string
+	Policy TagPolicy
+}
+
+// Check applies v to tags, the tags being written for a note whose
+// current tags are old (nil for a new note). Only tags that are neither
+// known nor already on the note are checked. It returns the tags to
+// write and a warning for each unknown tag, or an error under
+// TagPolicyReject.
+func (v Vocabulary) Check(tags, old []string) ([]string, []string, error) {
+	if len(v.Known) == 0 {
+		return tags, nil, nil
+	}
+	var unknown []string
+	for _, tag := range tags {
+		if !slices.Contains(v.Known, tag) && !slices.Contains(old, tag) {
+			unknown = append(unknown, tag)
+		}
+	}
+	if len(unknown) == 0 {
+		return tags, nil, nil
+	}
+
+	switch v.Policy {
+	case TagPolicyReject:
+		var descs []string
+		for _, tag := range unknown {
+			descs = append(descs, v.describe(tag))
+		}
+		return nil, nil, fmt.Errorf("unknown tags: %s", strings.Join(descs, ", "))
+	case TagPolicySuggest:
+		out := slices.Clone(tags)
+		var warnings []string
+		for i, tag := range out {
+			if !slices.Contains(unknown, tag) {
+				continue
+			}
+			if near := v.Nearest(tag); near != "" {
+				out[i] = near
+				warnings = append(warnings, fmt.Sprintf("unknown tag %s replaced by %s", tag, near))
+			} else {
+				warnings = append(warnings, "unknown tag "+tag)
+			}
+		}
+		return out, warnings, nil
+	default:
+		var warnings []string
+		for _, tag := range unknown {
+			warnings = append(warnings, "unknown tag "+v.describe(tag))
+		}
+		return tags, warnings, nil
+	}
+}
+
+// describe names tag and, if there is one, the known tag it is closest to.
+func (v Vocabulary) describe(tag string) string {
+	if near := v.Nearest(tag); near != "" {
+		return fmt.Sprintf("%s (did you mean %s?)", tag, near)
+	}
+	return tag
+}
+
+// Nearest returns the known tag with the smallest edit distance to tag,
+// or "" if none is within a third of its length (at least one edit).
+// Ties go to the alphabetically first tag.
+func (v Vocabulary) Nearest(tag string) string {
+	limit := max(1, len([]rune(tag))/3)
+	best, bestDist := "", limit+1
+	for _, known := range v.Known {
+		d := editDistance(tag, known)
+		if d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
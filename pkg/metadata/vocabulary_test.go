@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVocabularyCheck(t *testing.T) {
+	known := []string{"golang", "meeting", "project"}
+	tests := []struct {
+		name     string
+		policy   TagPolicy
+		tags     []string
+		old      []string
+		want     []string
+		warnings int
+		wantErr  bool
+	}{
+		{"known", TagPolicyReject, []string{"golang", "project"}, nil, []string{"golang", "project"}, 0, false},
+		{"already on note", TagPolicyReject, []string{"legacy"}, []string{"legacy"}, []string{"legacy"}, 0, false},
+		{"warn", TagPolicyWarn, []string{"golnag", "misc"}, nil, []string{"golnag", "misc"}, 2, false},
+		{"reject", TagPolicyReject, []string{"golang", "misc"}, nil, nil, 0, true},
+		{"suggest", TagPolicySuggest, []string{"golnag", "misc"}, nil, []string{"golang", "misc"}, 2, false},
+		{"default is warn", "", []string{"misc"}, nil, []string{"misc"}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Vocabulary{Known: known, Policy: tt.policy}
+			got, warnings, err := v.Check(tt.tags, tt.old)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) || len(warnings) != tt.warnings {
+				t.Errorf("Check() = %q, %q; want %q and %d warnings", got, warnings, tt.want, tt.warnings)
+			}
+		})
+	}
+
+	if got, _, _ := (Vocabulary{Policy: TagPolicyReject}).Check([]string{"any"}, nil); !slices.Equal(got, []string{"any"}) {
+		t.Errorf("empty vocabulary changed tags to %q", got)
+	}
+}
+
+func TestVocabularyNearest(t *testing.T) {
+	v := Vocabulary{Known: []string{"golang", "meeting", "project"}}
+	for tag, want := range map[string]string{
+		"golnag":   "golang",
+		"meetings": "meeting",
+		"projet":   "project",
+		"misc":     "",
+		"go":       "",
+	} {
+		if got := v.Nearest(tag); got != want {
+			t.Errorf("Nearest(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package metrics keeps process-wide counters, gauges, and timings and
+// writes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the type of a metric.
+type Kind string
+
+const (
+	Counter Kind = "counter" // only ever increases
+	Gauge   Kind = "gauge"   // set to the current value
+	Summary Kind = "summary" // durations, reported as _sum and _count
+)
+
+type metric struct {
+	kind   Kind
+	help   string
+	values map[string]float64 // by formatted label set
+	counts map[string]uint64  // observations, for summaries
+}
+
+var (
+	mu      sync.Mutex
+	metrics = map[string]*metric{}
+)
+
+// Register declares the metric name. Recording a value for a name that
+// was not registered panics.
+func Register(name string, kind Kind, help string) {
+	mu.Lock()
+	defer mu.Unlock()
+	metrics[name] = &metric{kind: kind, help: help, values: map[string]float64{}, counts: map[string]uint64{}}
+}
+
+// labelSet formats label name/value pairs as {a="1",b="2"}.
+func labelSet(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var parts []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, labels[i], v))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func get(name string) *metric {
+	m, ok := metrics[name]
+	if !ok {
+		panic("metrics: unregistered metric " + name)
+	}
+	return m
+}
+
+// Add increases the counter name by v. labels are name/value pairs.
+func Add(name string, v float64, labels ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	get(name).values[labelSet(labels)] += v
+}
+
+// Set sets the gauge name to v.
+func Set(name string, v float64, labels ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	get(name).values[labelSet(labels)] = v
+}
+
+// Observe records a duration for the summary name.
+func Observe(name string, d time.Duration, labels ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	m := get(name)
+	ls := labelSet(labels)
+	m.values[ls] += d.Seconds()
+	m.counts[ls]++
+}
+
+// Since records the time elapsed since start for the summary name,
+// e.g. defer metrics.Since("op_seconds", time.Now()).
+func Since(name string, start time.Time, labels ...string) {
+	Observe(name, time.Since(start), labels...)
+}
+
+// Write writes every metric with a recorded value to w, sorted by name.
+func Write(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := metrics[name]
+		if len(m.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, m.help, name, m.kind)
+		sets := make([]string, 0, len(m.values))
+		for ls := range m.values {
+			sets = append(sets, ls)
+		}
+		sort.Strings(sets)
+		for _, ls := range sets {
+			if m.kind == Summary {
+				fmt.Fprintf(&b, "%s_sum%s %g\n%s_count%s %d\n", name, ls, m.values[ls], name, ls, m.counts[ls])
+			} else {
+				fmt.Fprintf(&b, "%s%s %g\n", name, ls, m.values[ls])
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics for Prometheus to scrape.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		Write(w)
+	})
+}
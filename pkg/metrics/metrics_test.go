@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	Register("test_requests_total", Counter, "Requests.")
+	Register("test_notes", Gauge, "Notes.")
+	Register("test_seconds", Summary, "Time.")
+	Register("test_unused", Counter, "Never recorded.")
+
+	Add("test_requests_total", 1, "op", "read")
+	Add("test_requests_total", 2, "op", "read")
+	Add("test_requests_total", 1, "op", `a"b`)
+	Set("test_notes", 10)
+	Set("test_notes", 7)
+	Observe("test_seconds", 500*time.Millisecond)
+	Observe("test_seconds", time.Second)
+
+	var b strings.Builder
+	if err := Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_notes Notes.
+# TYPE test_notes gauge
+test_notes 7
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{op="a\"b"} 1
+test_requests_total{op="read"} 3
+# HELP test_seconds Time.
+# TYPE test_seconds summary
+test_seconds_sum 1.5
+test_seconds_count 2
+`
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
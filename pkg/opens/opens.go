@@ -0,0 +1,83 @@
+// Package opens records when notes are opened, so that notes can be
+// sorted by how often or how recently they are used. Every open is a
+// line "identifier unix-seconds" appended to a log file. A single short
+// append is atomic, so Denote processes started by the plumber can record
+// opens concurrently without locking.
+package opens
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stat is how often and when last a note was opened.
+type Stat struct {
+	Count int
+	Last  time.Time
+}
+
+// Path returns the open log, $XDG_DATA_HOME/acme-denote/opens or
+// ~/.local/share/acme-denote/opens if XDG_DATA_HOME is not set.
+func Path() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "acme-denote", "opens"), nil
+}
+
+// Record appends an open of the note with identifier id at t to the log
+// at path.
+func Record(path, id string, t time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s %d\n", id, t.Unix()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the log at path and returns the stats of every note opened,
+// by identifier. A missing log yields no stats; malformed lines are
+// skipped.
+func Load(path string) (map[string]Stat, error) {
+	stats := make(map[string]Stat)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		id, secs, ok := strings.Cut(sc.Text(), " ")
+		n, err := strconv.ParseInt(secs, 10, 64)
+		if !ok || err != nil || id == "" {
+			continue
+		}
+		s := stats[id]
+		s.Count++
+		if t := time.Unix(n, 0); t.After(s.Last) {
+			s.Last = t
+		}
+		stats[id] = s
+	}
+	return stats, sc.Err()
+}
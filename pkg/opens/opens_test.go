@@ -0,0 +1,46 @@
+package opens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acme-denote", "opens")
+	if stats, err := Load(path); err != nil || len(stats) != 0 {
+		t.Fatalf("Load() of a missing log = %v, %v", stats, err)
+	}
+	t0 := time.Unix(1700000000, 0)
+	for _, open := range []struct {
+		id string
+		t  time.Time
+	}{
+		{"20240101T120000", t0},
+		{"20240102T120000", t0.Add(time.Hour)},
+		{"20240101T120000", t0.Add(2 * time.Hour)},
+		{"20240101T120000", t0.Add(time.Minute)},
+	} {
+		if err := Record(path, open.id, open.t); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString("garbage\n20240103T120000 x\n")
+	f.Close()
+
+	stats, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("Load() = %v, want 2 notes", stats)
+	}
+	if s := stats["20240101T120000"]; s.Count != 3 || !s.Last.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("stats[20240101T120000] = %+v", s)
+	}
+	if s := stats["20240102T120000"]; s.Count != 1 || !s.Last.Equal(t0.Add(time.Hour)) {
+		t.Errorf("stats[20240102T120000] = %+v", s)
+	}
+}
@@ -0,0 +1,370 @@
+package render
+
+import (
+	"denote/pkg/metadata"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Warning is a construct Convert could not convert and left as written.
+type Warning struct {
+	Line int // 1-based line of the construct in the converted body
+	Text string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Text)
+}
+
+var (
+	orgDeepHeadingRe = regexp.MustCompile(`^\*{7,}\s`)
+	orgHeadingTagsRe = regexp.MustCompile(`\s+:[\w@#%:]+:$`)
+	orgDrawerRe      = regexp.MustCompile(`^:[A-Za-z_]+:$`)
+	orgRuleRe        = regexp.MustCompile(`^-{5,}$`)
+	orgCommentRe     = regexp.MustCompile(`^#(?:\s+(.*))?$`)
+	orgFootnoteRe    = regexp.MustCompile(`\[fn:[^\]]*\]`)
+	orgListRe        = regexp.MustCompile(`^(\s*)\+(\s+.*)$`)
+	mdBulletRe       = regexp.MustCompile(`^(\s*)[*+](\s+.*)$`)
+	mdRuleRe         = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdSetextRe       = regexp.MustCompile(`^(?:=+|-+)$`)
+	mdCommentRe      = regexp.MustCompile(`^<!--\s*(.*?)\s*-->$`)
+	mdImageRe        = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdAutolinkRe     = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]*:[^\s<>]+)>`)
+	mdFootnoteRe     = regexp.MustCompile(`\[\^[^\]]+\]`)
+	mdRefLinkRe      = regexp.MustCompile(`\[[^\]]+\]\[[^\]]*\]`)
+)
+
+// Convert rewrites a note body written as one file type in the markup of
+// another: headings, lists, links, code spans and blocks, quotes, and
+// emphasis. Text notes are read and written as markdown, as in parse, so
+// only conversions to or from org change the body. The conversion is
+// conservative: whatever has no clear counterpart, such as tables,
+// drawers, or footnotes, is left as written and reported as a warning.
+// Front matter is not converted; see frontmatter.Convert.
+func Convert(body string, from, to metadata.FileType) (string, []Warning) {
+	fromOrg, toOrg := from == metadata.FileTypeOrg, to == metadata.FileTypeOrg
+	switch {
+	case fromOrg && !toOrg:
+		return orgToMarkdown(body)
+	case !fromOrg && toOrg:
+		return markdownToOrg(body)
+	}
+	return body, nil
+}
+
+// converter collects the converted lines and the warnings about them.
+type converter struct {
+	out   []string
+	warns []Warning
+}
+
+// warn reports a construct on the next line written.
+func (c *converter) warn(format string, args ...any) {
+	c.warns = append(c.warns, Warning{Line: len(c.out) + 1, Text: fmt.Sprintf(format, args...)})
+}
+
+func (c *converter) write(lines ...string) {
+	c.out = append(c.out, lines...)
+}
+
+func (c *converter) result() (string, []Warning) {
+	return strings.Join(c.out, "\n"), c.warns
+}
+
+func orgToMarkdown(body string) (string, []Warning) {
+	var c converter
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if m := orgBeginRe.FindStringSubmatch(trimmed); m != nil {
+			end := i + 1
+			for end < len(lines) && !orgEndRe.MatchString(strings.TrimSpace(lines[end])) {
+				end++
+			}
+			if end == len(lines) {
+				c.warn("unterminated %s left as written", trimmed)
+				c.write(line)
+				continue
+			}
+			if strings.EqualFold(m[1], "quote") {
+				for _, l := range lines[i+1 : end] {
+					c.write(strings.TrimRight("> "+c.orgInline(l), " "))
+				}
+			} else {
+				var lang string
+				if fields := strings.Fields(trimmed); strings.EqualFold(m[1], "src") && len(fields) > 1 {
+					lang = fields[1]
+				}
+				c.write("```" + lang)
+				c.write(lines[i+1 : end]...)
+				c.write("```")
+			}
+			i = end
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "#+"):
+			c.warn("org directive %s left as written", strings.Fields(trimmed)[0])
+			c.write(line)
+		case orgCommentRe.MatchString(trimmed):
+			// A markdown line starting with # is a heading.
+			c.write("<!-- " + orgCommentRe.FindStringSubmatch(trimmed)[1] + " -->")
+		case orgDrawerRe.MatchString(trimmed):
+			c.warn("drawer %s left as written", trimmed)
+			c.write(line)
+		case strings.HasPrefix(trimmed, "|"):
+			if i == 0 || !strings.HasPrefix(strings.TrimSpace(lines[i-1]), "|") {
+				c.warn("table left as written")
+			}
+			c.write(line)
+		case orgRuleRe.MatchString(trimmed):
+			c.write("---")
+		default:
+			if m := orgHeadingRe.FindStringSubmatch(line); m != nil {
+				text := m[2]
+				if tags := orgHeadingTagsRe.FindString(text); tags != "" {
+					c.warn("heading tags %s left as written", strings.TrimSpace(tags))
+				}
+				c.write(strings.Repeat("#", len(m[1])) + " " + c.orgInline(text))
+				continue
+			}
+			if orgDeepHeadingRe.MatchString(line) {
+				c.warn("heading deeper than 6 levels left as written")
+				c.write(line)
+				continue
+			}
+			if m := orgListRe.FindStringSubmatch(line); m != nil {
+				line = m[1] + "-" + m[2]
+			}
+			if bulletRe.MatchString(line) && strings.Contains(line, " :: ") {
+				c.warn("description list item left as written")
+			}
+			c.write(c.orgInline(line))
+		}
+	}
+	return c.result()
+}
+
+func markdownToOrg(body string) (string, []Warning) {
+	var c converter
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence := trimmed[:3]
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), fence) {
+				end++
+			}
+			if end == len(lines) {
+				c.warn("unterminated code block left as written")
+				c.write(line)
+				continue
+			}
+			kind, lang := "example", ""
+			if fields := strings.Fields(trimmed[3:]); len(fields) > 0 {
+				kind, lang = "src", " "+fields[0]
+			}
+			c.write("#+begin_" + kind + lang)
+			c.write(lines[i+1 : end]...)
+			c.write("#+end_" + kind)
+			i = end
+			continue
+		}
+		if mdQuoteRe.MatchString(line) {
+			c.write("#+begin_quote")
+			for ; i < len(lines) && mdQuoteRe.MatchString(lines[i]); i++ {
+				text := mdQuoteRe.FindStringSubmatch(lines[i])[1]
+				if strings.HasPrefix(text, ">") {
+					c.warn("nested quote left as written")
+				}
+				c.write(c.markdownInline(text))
+			}
+			c.write("#+end_quote")
+			i--
+			continue
+		}
+
+		switch {
+		case mdCommentRe.MatchString(trimmed):
+			c.write("# " + mdCommentRe.FindStringSubmatch(trimmed)[1])
+		case strings.HasPrefix(trimmed, "|"):
+			if i == 0 || !strings.HasPrefix(strings.TrimSpace(lines[i-1]), "|") {
+				c.warn("table left as written")
+			}
+			c.write(line)
+		case strings.HasPrefix(trimmed, "<") && !mdAutolinkRe.MatchString(trimmed):
+			c.warn("HTML left as written")
+			c.write(line)
+		case mdSetextRe.MatchString(trimmed) && i > 0 && strings.TrimSpace(lines[i-1]) != "":
+			c.warn("underlined heading left as written")
+			c.write(line)
+		case mdRuleRe.MatchString(trimmed):
+			c.write("-----")
+		default:
+			if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+				text := strings.TrimRight(strings.TrimRight(m[2], "#"), " ")
+				c.write(strings.Repeat("*", len(m[1])) + " " + c.markdownInline(text))
+				continue
+			}
+			// An org line starting with * is a heading.
+			if m := mdBulletRe.FindStringSubmatch(line); m != nil {
+				line = m[1] + "-" + m[2]
+			}
+			c.write(c.markdownInline(line))
+		}
+	}
+	return c.result()
+}
+
+// orgInline converts the links, code spans, and emphasis of an org line
+// to markdown.
+func (c *converter) orgInline(s string) string {
+	var saved []string
+	save := func(t string) string {
+		saved = append(saved, t)
+		return "\x00" + strconv.Itoa(len(saved)-1) + "\x00"
+	}
+	s = replaceSpans(s, "=~", func(delim byte, text string) string {
+		if strings.Contains(text, "`") {
+			c.warn("code span %c%s%c left as written", delim, text, delim)
+			return save(string(delim) + text + string(delim))
+		}
+		return save("`" + text + "`")
+	})
+	s = orgLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := orgLinkRe.FindStringSubmatch(m)
+		target, text := sm[1], sm[2]
+		switch {
+		case strings.HasPrefix(target, "*") || strings.HasPrefix(target, "#"):
+			c.warn("internal link %s left as written", m)
+			return save(m)
+		case strings.ContainsAny(target, " \t"):
+			target = "<" + target + ">"
+		case text == "" && strings.Contains(target, ":"):
+			return save("<" + target + ">")
+		}
+		if text == "" {
+			text = sm[1]
+		}
+		return save("[" + text + "](" + target + ")")
+	})
+	if m := orgFootnoteRe.FindString(s); m != "" {
+		c.warn("footnote %s left as written", m)
+	}
+	s = replaceSpans(s, "*/_", func(delim byte, text string) string {
+		switch delim {
+		case '*':
+			return "**" + text + "**"
+		case '/':
+			return "*" + text + "*"
+		}
+		c.warn("underlined %s left as written", text)
+		return "_" + text + "_"
+	})
+	return restore(s, saved)
+}
+
+// markdownInline converts the links, code spans, and emphasis of a
+// markdown line to org.
+func (c *converter) markdownInline(s string) string {
+	var saved []string
+	save := func(t string) string {
+		saved = append(saved, t)
+		return "\x00" + strconv.Itoa(len(saved)-1) + "\x00"
+	}
+	s = mdCodeRe.ReplaceAllStringFunc(s, func(m string) string {
+		code := mdCodeRe.FindStringSubmatch(m)[1]
+		switch {
+		case !strings.Contains(code, "~"):
+			return save("~" + code + "~")
+		case !strings.Contains(code, "="):
+			return save("=" + code + "=")
+		}
+		c.warn("code span %s left as written", m)
+		return save(m)
+	})
+	s = mdImageRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mdImageRe.FindStringSubmatch(m)
+		if sm[1] != "" {
+			c.warn("image description %q dropped", sm[1])
+		}
+		return save("[[" + sm[2] + "]]")
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := mdLinkRe.FindStringSubmatch(m)
+		text, target := sm[1], sm[2]
+		if strings.ContainsAny(text, "[]") {
+			c.warn("link %s left as written", m)
+			return save(m)
+		}
+		if text == target {
+			return save("[[" + target + "]]")
+		}
+		return save("[[" + target + "][" + text + "]]")
+	})
+	s = mdAutolinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		return save("[[" + mdAutolinkRe.FindStringSubmatch(m)[1] + "]]")
+	})
+	if m := mdFootnoteRe.FindString(s); m != "" {
+		c.warn("footnote %s left as written", m)
+	} else if m := mdRefLinkRe.FindString(s); m != "" {
+		c.warn("reference link %s left as written", m)
+	} else if strings.Contains(s, "](") {
+		c.warn("link left as written")
+	}
+	s = mdStrongRe.ReplaceAllStringFunc(s, func(m string) string {
+		return save("*" + mdStrongRe.FindStringSubmatch(m)[1] + "*")
+	})
+	s = replaceSpans(s, "*_", func(_ byte, text string) string {
+		return "/" + text + "/"
+	})
+	return restore(s, saved)
+}
+
+// restore puts the text saved by placeholders back into s, last first,
+// since saved text may itself hold earlier placeholders.
+func restore(s string, saved []string) string {
+	for i := len(saved) - 1; i >= 0; i-- {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", saved[i], 1)
+	}
+	return s
+}
+
+const (
+	spanBefore = " \t(\"'{-"
+	spanAfter  = " \t.,;:!?)\"'}-"
+)
+
+// replaceSpans replaces the spans of s marked, as in org, by one of the
+// delimiters in delims with conv of the delimiter and the text between.
+// A span opens at the start of s or after a space or opening punctuation
+// and closes with the same delimiter before the end of s, a space, or
+// closing punctuation; its text neither starts nor ends with a space.
+// Markdown emphasis follows the same rules closely enough.
+func replaceSpans(s, delims string, conv func(delim byte, text string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		d := s[i]
+		if strings.IndexByte(delims, d) >= 0 && (i == 0 || strings.IndexByte(spanBefore, s[i-1]) >= 0) &&
+			i+1 < len(s) && s[i+1] != d && s[i+1] != ' ' && s[i+1] != '\t' {
+			if j := strings.IndexByte(s[i+1:], d); j >= 0 {
+				j += i + 1
+				if s[j-1] != ' ' && s[j-1] != '\t' && (j+1 == len(s) || strings.IndexByte(spanAfter, s[j+1]) >= 0) {
+					b.WriteString(conv(d, s[i+1:j]))
+					i = j
+					continue
+				}
+			}
+		}
+		b.WriteByte(d)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"denote/pkg/metadata"
+	"reflect"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		from, to metadata.FileType
+		want     string
+		warnings []string
+	}{
+		{
+			name: "org to markdown",
+			body: "* Heading\n** Sub\n\nText with *bold*, /italic/ and =code=.\n" +
+				"+ one\n- [[denote:20240101T120000][A note]] and [[https://example.com]]\n" +
+				"# a comment\n-----\n#+begin_quote\nwise /words/\n#+end_quote\n\n#+begin_src go\nx := *p\n#+end_src\n",
+			from: metadata.FileTypeOrg,
+			to:   metadata.FileTypeMdYaml,
+			want: "# Heading\n## Sub\n\nText with **bold**, *italic* and `code`.\n" +
+				"- one\n- [A note](denote:20240101T120000) and <https://example.com>\n" +
+				"<!-- a comment -->\n---\n> wise *words*\n\n```go\nx := *p\n```\n",
+		},
+		{
+			name: "org warnings",
+			body: "* Heading :work:\n:PROPERTIES:\n:END:\n#+options: toc:nil\n| a | b |\n|---+---|\nSee [[*Heading]] and _this_.",
+			from: metadata.FileTypeOrg,
+			to:   metadata.FileTypeTxt,
+			want: "# Heading :work:\n:PROPERTIES:\n:END:\n#+options: toc:nil\n| a | b |\n|---+---|\nSee [[*Heading]] and _this_.",
+			warnings: []string{
+				"line 1: heading tags :work: left as written",
+				"line 2: drawer :PROPERTIES: left as written",
+				"line 3: drawer :END: left as written",
+				"line 4: org directive #+options: left as written",
+				"line 5: table left as written",
+				"line 7: internal link [[*Heading]] left as written",
+				"line 7: underlined this left as written",
+			},
+		},
+		{
+			name: "markdown to org",
+			body: "# Heading ##\n\nSome **strong**, *em*, _em_ and `code`.\n* one\n  + two\n1. [docs](https://example.com) and <denote:20240101T120000>\n" +
+				"<!-- note -->\n\n---\n> quoted `x`\n> more\n\n```go\nx := *p\n```\n~~~\nplain\n~~~\n",
+			from: metadata.FileTypeMdToml,
+			to:   metadata.FileTypeOrg,
+			want: "* Heading\n\nSome *strong*, /em/, /em/ and ~code~.\n- one\n  - two\n1. [[https://example.com][docs]] and [[denote:20240101T120000]]\n" +
+				"# note\n\n-----\n#+begin_quote\nquoted ~x~\nmore\n#+end_quote\n\n#+begin_src go\nx := *p\n#+end_src\n#+begin_example\nplain\n#+end_example\n",
+		},
+		{
+			name: "markdown warnings",
+			body: "Title\n=====\n| a |\n<div>\n![a cat](cat.png) [x][ref]\n```\nopen",
+			from: metadata.FileTypeTxt,
+			to:   metadata.FileTypeOrg,
+			want: "Title\n=====\n| a |\n<div>\n[[cat.png]] [x][ref]\n```\nopen",
+			warnings: []string{
+				"line 2: underlined heading left as written",
+				"line 3: table left as written",
+				"line 4: HTML left as written",
+				`line 5: image description "a cat" dropped`,
+				"line 5: reference link [x][ref] left as written",
+				"line 6: unterminated code block left as written",
+			},
+		},
+		{
+			name: "same syntax",
+			body: "# Heading\n| a |\n",
+			from: metadata.FileTypeMdYaml,
+			to:   metadata.FileTypeTxt,
+			want: "# Heading\n| a |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warns := Convert(tt.body, tt.from, tt.to)
+			if got != tt.want {
+				t.Errorf("Convert() =\n%q\nwant\n%q", got, tt.want)
+			}
+			var texts []string
+			for _, w := range warns {
+				texts = append(texts, w.String())
+			}
+			if !reflect.DeepEqual(texts, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", texts, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+// Package render converts note bodies (a markdown or org subset) to HTML
+// or formatted plain text.
+// It understands headings, paragraphs, lists, quotes, code blocks, links
+// (including denote: links), and basic emphasis; anything else is passed
+// through as text.
+package render
+
+import (
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Resolver maps the identifier of a denote: link to a URL. Returning ""
+// leaves the link as written.
+type Resolver func(identifier string) string
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockList
+	blockOrderedList
+	blockQuote
+	blockCode
+)
+
+// block is a run of lines that render as one HTML element.
+type block struct {
+	kind  blockKind
+	level int // heading level
+	lines []string
+}
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	orgHeadingRe = regexp.MustCompile(`^(\*{1,6})\s+(.*)$`)
+	bulletRe     = regexp.MustCompile(`^\s*[-+*]\s+(.*)$`)
+	orderedRe    = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuoteRe    = regexp.MustCompile(`^>\s?(.*)$`)
+	orgBeginRe   = regexp.MustCompile(`(?i)^#\+begin_(src|example|quote)\b`)
+	orgEndRe     = regexp.MustCompile(`(?i)^#\+end_(src|example|quote)\b`)
+)
+
+// parse splits a note body into blocks. fileType selects org or markdown
+// syntax; text notes are treated as markdown.
+func parse(body string, fileType metadata.FileType) []block {
+	org := fileType == metadata.FileTypeOrg
+	var blocks []block
+	var cur *block
+	flush := func() {
+		if cur != nil {
+			blocks = append(blocks, *cur)
+			cur = nil
+		}
+	}
+	add := func(kind blockKind, line string) {
+		if cur == nil || cur.kind != kind {
+			flush()
+			cur = &block{kind: kind}
+		}
+		cur.lines = append(cur.lines, line)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// Fenced and delimited blocks consume lines up to their end marker.
+		if !org && strings.HasPrefix(trimmed, "```") {
+			flush()
+			b := block{kind: blockCode}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.lines = append(b.lines, lines[i])
+			}
+			blocks = append(blocks, b)
+			continue
+		}
+		if org {
+			if m := orgBeginRe.FindStringSubmatch(trimmed); m != nil {
+				flush()
+				kind := blockCode
+				if strings.EqualFold(m[1], "quote") {
+					kind = blockQuote
+				}
+				b := block{kind: kind}
+				for i++; i < len(lines) && !orgEndRe.MatchString(strings.TrimSpace(lines[i])); i++ {
+					b.lines = append(b.lines, lines[i])
+				}
+				blocks = append(blocks, b)
+				continue
+			}
+			if strings.HasPrefix(trimmed, "#+") {
+				continue // keywords and other directives
+			}
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		headingRe := mdHeadingRe
+		if org {
+			headingRe = orgHeadingRe
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, block{kind: blockHeading, level: len(m[1]), lines: []string{m[2]}})
+			continue
+		}
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			add(blockList, m[1])
+			continue
+		}
+		if m := orderedRe.FindStringSubmatch(line); m != nil {
+			add(blockOrderedList, m[1])
+			continue
+		}
+		if m := mdQuoteRe.FindStringSubmatch(line); !org && m != nil {
+			add(blockQuote, m[1])
+			continue
+		}
+		if cur != nil && (cur.kind == blockList || cur.kind == blockOrderedList) && (line[0] == ' ' || line[0] == '\t') {
+			// continuation of the previous list item
+			cur.lines[len(cur.lines)-1] += " " + trimmed
+			continue
+		}
+		add(blockParagraph, trimmed)
+	}
+	flush()
+	return blocks
+}
+
+// HTML renders body to an HTML fragment. Front matter is removed first;
+// denote: links are resolved with resolve, which may be nil.
+func HTML(body string, fileType metadata.FileType, resolve Resolver) string {
+	body = util.StripFrontMatter(body, fileType)
+	var buf strings.Builder
+	for _, b := range parse(body, fileType) {
+		switch b.kind {
+		case blockHeading:
+			tag := "h" + string(rune('0'+b.level))
+			buf.WriteString("<" + tag + ">" + inlineHTML(b.lines[0], fileType, resolve) + "</" + tag + ">\n")
+		case blockList, blockOrderedList:
+			tag := "ul"
+			if b.kind == blockOrderedList {
+				tag = "ol"
+			}
+			buf.WriteString("<" + tag + ">\n")
+			for _, item := range b.lines {
+				buf.WriteString("<li>" + inlineHTML(item, fileType, resolve) + "</li>\n")
+			}
+			buf.WriteString("</" + tag + ">\n")
+		case blockQuote:
+			buf.WriteString("<blockquote><p>" + inlineHTML(strings.Join(b.lines, " "), fileType, resolve) + "</p></blockquote>\n")
+		case blockCode:
+			buf.WriteString("<pre><code>" + html.EscapeString(strings.Join(b.lines, "\n")) + "</code></pre>\n")
+		default:
+			buf.WriteString("<p>" + inlineHTML(strings.Join(b.lines, " "), fileType, resolve) + "</p>\n")
+		}
+	}
+	return buf.String()
+}
+
+var (
+	mdLinkRe    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	orgLinkRe   = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	mdCodeRe    = regexp.MustCompile("`([^`]+)`")
+	orgCodeRe   = regexp.MustCompile(`[=~]([^=~\s][^=~]*)[=~]`)
+	mdStrongRe  = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdEmRe      = regexp.MustCompile(`(?:^|[^\w*])\*([^*\s][^*]*)\*`)
+	orgStrongRe = regexp.MustCompile(`(?:^|\s)\*([^*\s][^*]*)\*`)
+	orgEmRe     = regexp.MustCompile(`(?:^|\s)/([^/\s][^/]*)/`)
+)
+
+// inlineHTML renders links, code spans, and emphasis within a line.
+// Links and code spans are rendered first and shielded from the emphasis
+// rules by placeholders.
+func inlineHTML(s string, fileType metadata.FileType, resolve Resolver) string {
+	var saved []string
+	save := func(h string) string {
+		saved = append(saved, h)
+		return "\x00" + strconv.Itoa(len(saved)-1) + "\x00"
+	}
+	link := func(target, text string) string {
+		href := target
+		if id, ok := strings.CutPrefix(target, "denote:"); ok && resolve != nil {
+			if u := resolve(id); u != "" {
+				href = u
+			}
+		}
+		return save(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(text) + `</a>`)
+	}
+
+	org := fileType == metadata.FileTypeOrg
+	codeRe := mdCodeRe
+	if org {
+		codeRe = orgCodeRe
+	}
+	s = codeRe.ReplaceAllStringFunc(s, func(m string) string {
+		return save("<code>" + html.EscapeString(codeRe.FindStringSubmatch(m)[1]) + "</code>")
+	})
+	if org {
+		s = orgLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+			sm := orgLinkRe.FindStringSubmatch(m)
+			text := sm[2]
+			if text == "" {
+				text = sm[1]
+			}
+			return link(sm[1], text)
+		})
+	} else {
+		s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+			sm := mdLinkRe.FindStringSubmatch(m)
+			return link(sm[2], sm[1])
+		})
+	}
+	s = metadata.LinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		return link(m, m)
+	})
+
+	s = html.EscapeString(s)
+	if org {
+		s = replaceEmphasis(s, orgStrongRe, "strong")
+		s = replaceEmphasis(s, orgEmRe, "em")
+	} else {
+		s = mdStrongRe.ReplaceAllString(s, "<strong>$1</strong>")
+		s = replaceEmphasis(s, mdEmRe, "em")
+	}
+
+	for i, h := range saved {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", h, 1)
+	}
+	return s
+}
+
+// replaceEmphasis wraps the text of each match of re in tag, keeping any
+// leading character re consumed and dropping the emphasis delimiters.
+// An empty tag drops the delimiters only.
+func replaceEmphasis(s string, re *regexp.Regexp, tag string) string {
+	var buf strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		// loc[2]:loc[3] is the emphasized text; the delimiters surround it.
+		buf.WriteString(s[last : loc[2]-1])
+		if tag == "" {
+			buf.WriteString(s[loc[2]:loc[3]])
+		} else {
+			buf.WriteString("<" + tag + ">" + s[loc[2]:loc[3]] + "</" + tag + ">")
+		}
+		last = loc[3] + 1
+	}
+	buf.WriteString(s[last:])
+	return buf.String()
+}
+
+// Text renders body as formatted plain text suitable for an acme window:
+// headings are underlined, list items are bulleted, quotes and code are
+// indented, emphasis markers are dropped, and links are written as
+// "text (target)". Front matter is removed first; denote: links are
+// resolved with resolve, which may be nil.
+func Text(body string, fileType metadata.FileType, resolve Resolver) string {
+	body = util.StripFrontMatter(body, fileType)
+	var paras []string
+	for _, b := range parse(body, fileType) {
+		var buf strings.Builder
+		switch b.kind {
+		case blockHeading:
+			text := inlineText(b.lines[0], fileType, resolve)
+			underline := "-"
+			if b.level == 1 {
+				underline = "="
+			}
+			buf.WriteString(text + "\n" + strings.Repeat(underline, utf8.RuneCountInString(text)))
+		case blockList, blockOrderedList:
+			for i, item := range b.lines {
+				if i > 0 {
+					buf.WriteString("\n")
+				}
+				bullet := "  • "
+				if b.kind == blockOrderedList {
+					bullet = fmt.Sprintf("  %d. ", i+1)
+				}
+				buf.WriteString(bullet + inlineText(item, fileType, resolve))
+			}
+		case blockQuote:
+			buf.WriteString("  │ " + inlineText(strings.Join(b.lines, " "), fileType, resolve))
+		case blockCode:
+			for i, line := range b.lines {
+				if i > 0 {
+					buf.WriteString("\n")
+				}
+				buf.WriteString("    " + line)
+			}
+		default:
+			buf.WriteString(inlineText(strings.Join(b.lines, " "), fileType, resolve))
+		}
+		paras = append(paras, buf.String())
+	}
+	if len(paras) == 0 {
+		return ""
+	}
+	return strings.Join(paras, "\n\n") + "\n"
+}
+
+// inlineText is the plain text counterpart of inlineHTML.
+func inlineText(s string, fileType metadata.FileType, resolve Resolver) string {
+	var saved []string
+	save := func(t string) string {
+		saved = append(saved, t)
+		return "\x00" + strconv.Itoa(len(saved)-1) + "\x00"
+	}
+	link := func(target, text string) string {
+		if id, ok := strings.CutPrefix(target, "denote:"); ok && resolve != nil {
+			if u := resolve(id); u != "" {
+				target = u
+			}
+		}
+		if text == "" || text == target || strings.HasPrefix(text, "denote:") {
+			return save(target)
+		}
+		return save(text + " (" + target + ")")
+	}
+
+	org := fileType == metadata.FileTypeOrg
+	codeRe := mdCodeRe
+	if org {
+		codeRe = orgCodeRe
+	}
+	s = codeRe.ReplaceAllStringFunc(s, func(m string) string {
+		return save(codeRe.FindStringSubmatch(m)[1])
+	})
+	if org {
+		s = orgLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+			sm := orgLinkRe.FindStringSubmatch(m)
+			return link(sm[1], sm[2])
+		})
+	} else {
+		s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+			sm := mdLinkRe.FindStringSubmatch(m)
+			return link(sm[2], sm[1])
+		})
+	}
+	s = metadata.LinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		return link(m, "")
+	})
+
+	if org {
+		s = replaceEmphasis(s, orgStrongRe, "")
+		s = replaceEmphasis(s, orgEmRe, "")
+	} else {
+		s = mdStrongRe.ReplaceAllString(s, "$1")
+		s = replaceEmphasis(s, mdEmRe, "")
+	}
+
+	for i, t := range saved {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", t, 1)
+	}
+	return s
+}
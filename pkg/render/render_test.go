@@ -0,0 +1,121 @@
+package render
+
+import (
+	"denote/pkg/metadata"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	resolve := func(id string) string { return "/notes/" + id + ".html" }
+
+	tests := []struct {
+		name     string
+		body     string
+		fileType metadata.FileType
+		want     string
+	}{
+		{
+			name:     "markdown blocks",
+			body:     "---\ntitle: x\n---\n\n# Title\n\nSome *em* and **strong** text\ncontinued.\n\n- one\n- two\n\n1. first\n\n> quoted\n\n```\na < b\n```\n",
+			fileType: metadata.FileTypeMdYaml,
+			want: "<h1>Title</h1>\n" +
+				"<p>Some <em>em</em> and <strong>strong</strong> text continued.</p>\n" +
+				"<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n" +
+				"<ol>\n<li>first</li>\n</ol>\n" +
+				"<blockquote><p>quoted</p></blockquote>\n" +
+				"<pre><code>a &lt; b</code></pre>\n",
+		},
+		{
+			name:     "markdown links",
+			body:     "See [docs](https://example.com/?a=1&b=2), [other](denote:20240101T120000) and denote:20240102T120000.",
+			fileType: metadata.FileTypeMdYaml,
+			want: `<p>See <a href="https://example.com/?a=1&amp;b=2">docs</a>, ` +
+				`<a href="/notes/20240101T120000.html">other</a> and ` +
+				`<a href="/notes/20240102T120000.html">denote:20240102T120000</a>.</p>` + "\n",
+		},
+		{
+			name:     "markdown code span shields emphasis",
+			body:     "use `a*b*c` here",
+			fileType: metadata.FileTypeMdYaml,
+			want:     "<p>use <code>a*b*c</code> here</p>\n",
+		},
+		{
+			name:     "org blocks",
+			body:     "#+title: x\n#+filetags: :a:\n\n* Heading\n** Sub\n\nText with *bold*, /italic/ and =code=.\n\n#+begin_quote\nwise words\n#+end_quote\n\n#+begin_src go\nfmt.Println(\"hi\")\n#+end_src\n",
+			fileType: metadata.FileTypeOrg,
+			want: "<h1>Heading</h1>\n<h2>Sub</h2>\n" +
+				"<p>Text with <strong>bold</strong>, <em>italic</em> and <code>code</code>.</p>\n" +
+				"<blockquote><p>wise words</p></blockquote>\n" +
+				"<pre><code>fmt.Println(&#34;hi&#34;)</code></pre>\n",
+		},
+		{
+			name:     "org links",
+			body:     "[[denote:20240101T120000][A note]] and [[https://example.com]]",
+			fileType: metadata.FileTypeOrg,
+			want:     `<p><a href="/notes/20240101T120000.html">A note</a> and <a href="https://example.com">https://example.com</a></p>` + "\n",
+		},
+		{
+			name:     "unresolved denote link",
+			body:     "denote:20240101T120000",
+			fileType: metadata.FileTypeTxt,
+			want:     `<p><a href="denote:20240101T120000">denote:20240101T120000</a></p>` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolve
+			if tt.name == "unresolved denote link" {
+				r = nil
+			}
+			if got := HTML(tt.body, tt.fileType, r); got != tt.want {
+				t.Errorf("HTML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	resolve := func(id string) string { return "/doc/" + id + "--note.md" }
+
+	tests := []struct {
+		name     string
+		body     string
+		fileType metadata.FileType
+		want     string
+	}{
+		{
+			name:     "markdown",
+			body:     "---\ntitle: x\n---\n\n# Title\n\n## Part\n\nSome *em* and **strong** `code`.\n\n- one\n- two\n\n1. first\n2. second\n\n> quoted\n\n```\ncode line\n```\n",
+			fileType: metadata.FileTypeMdYaml,
+			want: "Title\n=====\n\nPart\n----\n\nSome em and strong code.\n\n" +
+				"  • one\n  • two\n\n  1. first\n  2. second\n\n  │ quoted\n\n    code line\n",
+		},
+		{
+			name:     "links resolved to local paths",
+			body:     "See [docs](https://example.com), [other](denote:20240101T120000) and denote:20240102T120000.",
+			fileType: metadata.FileTypeMdYaml,
+			want:     "See docs (https://example.com), other (/doc/20240101T120000--note.md) and /doc/20240102T120000--note.md.\n",
+		},
+		{
+			name:     "org",
+			body:     "#+title: x\n\n* Heading\n\n*bold* /it/ [[denote:20240101T120000][Other]]\n",
+			fileType: metadata.FileTypeOrg,
+			want:     "Heading\n=======\n\nbold it Other (/doc/20240101T120000--note.md)\n",
+		},
+		{
+			name:     "empty",
+			body:     "",
+			fileType: metadata.FileTypeTxt,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.body, tt.fileType, resolve); got != tt.want {
+				t.Errorf("Text() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
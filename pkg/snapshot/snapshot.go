@@ -0,0 +1,156 @@
+// Package snapshot keeps earlier versions of notes, independently of any
+// version control, so that a rewrite of a note can be rolled back. File
+// contents are stored once each, as blobs named by their SHA-256 hash,
+// and every note has a log of its snapshots: one line per snapshot with
+// the time, the blob's hash, why it was taken, and the note's file name.
+package snapshot
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"denote/pkg/util"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Snapshot is a version of a note.
+type Snapshot struct {
+	Hash   string // SHA-256 of the content, in hex
+	Time   time.Time
+	Reason string // what was about to change the note, e.g. "update"
+	Name   string // the note's file name
+}
+
+// ID returns the short form of s's hash by which it is named.
+func (s *Snapshot) ID() string {
+	return s.Hash[:12]
+}
+
+func (s *Snapshot) String() string {
+	return fmt.Sprintf("%s | %s | %s | %s", s.ID(), s.Time.Format(time.RFC3339), s.Reason, s.Name)
+}
+
+// Dir returns the snapshot store, acme-denote/snapshots in the user cache
+// directory (~/.cache on Linux).
+func Dir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "acme-denote", "snapshots"), nil
+}
+
+func blobPath(dir, hash string) string {
+	return filepath.Join(dir, "blobs", hash[:2], hash)
+}
+
+func logPath(dir, id string) string {
+	return filepath.Join(dir, "notes", id)
+}
+
+// Save records the current content of the file at path of the note with
+// identifier id in the store in dir, with reason, a word. A file
+// unchanged since the note's last snapshot is not recorded again, and nil
+// is returned.
+func Save(dir, id, path, reason string, now time.Time) (*Snapshot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	reason = strings.Join(strings.Fields(reason), "-")
+	if reason == "" {
+		reason = "manual"
+	}
+	s := &Snapshot{Hash: hex.EncodeToString(sum[:]), Time: now.Truncate(time.Second), Reason: reason, Name: filepath.Base(path)}
+	snaps, err := List(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) > 0 && snaps[0].Hash == s.Hash {
+		return nil, nil
+	}
+
+	blob := blobPath(dir, s.Hash)
+	if _, err := os.Stat(blob); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blob), 0700); err != nil {
+			return nil, err
+		}
+		if err := util.WriteFile(blob, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath(dir, id)), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(logPath(dir, id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s %s %s\n", s.Time.Format(time.RFC3339), s.Hash, s.Reason, s.Name); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, f.Close()
+}
+
+// List returns the snapshots of the note with identifier id, newest
+// first. Malformed log lines are skipped.
+func List(dir, id string) ([]*Snapshot, error) {
+	f, err := os.Open(logPath(dir, id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var snaps []*Snapshot
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), " ", 4)
+		if len(fields) != 4 || len(fields[1]) != sha256.Size*2 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, &Snapshot{Hash: fields[1], Time: t, Reason: fields[2], Name: fields[3]})
+	}
+	for i, j := 0, len(snaps)-1; i < j; i, j = i+1, j-1 {
+		snaps[i], snaps[j] = snaps[j], snaps[i]
+	}
+	return snaps, sc.Err()
+}
+
+// Get returns the snapshot of the note with identifier id whose hash
+// starts with ref, which must name one snapshot.
+func Get(dir, id, ref string) (*Snapshot, error) {
+	snaps, err := List(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	var found *Snapshot
+	for _, s := range snaps {
+		if ref != "" && strings.HasPrefix(s.Hash, ref) {
+			if found != nil && found.Hash != s.Hash {
+				return nil, fmt.Errorf("snapshot %s of %s is ambiguous", ref, id)
+			}
+			found = s
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no snapshot %s of %s", ref, id)
+	}
+	return found, nil
+}
+
+// Content returns the content of the note as of s.
+func Content(dir string, s *Snapshot) ([]byte, error) {
+	return os.ReadFile(blobPath(dir, s.Hash))
+}
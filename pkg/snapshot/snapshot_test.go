@@ -0,0 +1,53 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveListGet(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(t.TempDir(), "20240101T120000--note.md")
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if snaps, err := List(dir, "20240101T120000"); err != nil || snaps != nil {
+		t.Fatalf("List() of a note without snapshots = %v, %v", snaps, err)
+	}
+	os.WriteFile(note, []byte("first\n"), 0644)
+	first, err := Save(dir, "20240101T120000", note, "update", t0)
+	if err != nil || first == nil {
+		t.Fatalf("Save() = %v, %v", first, err)
+	}
+	if s, err := Save(dir, "20240101T120000", note, "update", t0.Add(time.Minute)); err != nil || s != nil {
+		t.Errorf("Save() of unchanged content = %v, %v, want nothing saved", s, err)
+	}
+	os.WriteFile(note, []byte("second\n"), 0644)
+	second, err := Save(dir, "20240101T120000", note, "bulk merge", t0.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snaps, err := List(dir, "20240101T120000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].Hash != second.Hash || snaps[1].Hash != first.Hash {
+		t.Fatalf("List() = %v, want newest first", snaps)
+	}
+	if snaps[0].Reason != "bulk-merge" || snaps[0].Name != filepath.Base(note) || !snaps[0].Time.Equal(t0.Add(time.Hour)) {
+		t.Errorf("List()[0] = %+v", snaps[0])
+	}
+
+	s, err := Get(dir, "20240101T120000", first.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := Content(dir, s); err != nil || string(content) != "first\n" {
+		t.Errorf("Content() = %q, %v", content, err)
+	}
+	if _, err := Get(dir, "20240101T120000", "nope"); err == nil {
+		t.Error("Get() of an unknown snapshot succeeded")
+	}
+}
@@ -0,0 +1,177 @@
+// Package spell checks the spelling of notes with an external checker
+// speaking the ispell pipe protocol, such as "aspell -a" or "hunspell -a".
+// Front matter, code blocks, links, and URLs are not checked.
+package spell
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Misspelling is a word the checker does not know.
+type Misspelling struct {
+	Line        int // 1-based
+	Col         int // 1-based, in runes
+	Word        string
+	Suggestions []string
+}
+
+// skipRe matches the parts of a line that are not prose.
+var skipRe = regexp.MustCompile(`denote:\S+|[a-z][a-z0-9+.-]*://\S+|\x60[^\x60]*\x60|\[\[[^\]]*\]\[|\]\(\S*\)`)
+
+// Prepare returns the lines of text to check: front matter, fenced and
+// org source blocks, and org keyword lines are blanked, and inline code,
+// links, and URLs replaced by spaces, so that line and column numbers
+// still refer to text.
+func Prepare(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(lines); i++ {
+			lines[i] = ""
+		}
+	}
+
+	// Front matter.
+	switch {
+	case len(lines) > 0 && (lines[0] == "---" || lines[0] == "+++"):
+		for i := 1; i < len(lines); i++ {
+			if lines[i] == lines[0] {
+				blank(0, i+1)
+				break
+			}
+		}
+	case len(lines) > 0 && strings.HasPrefix(lines[0], "title:"):
+		for i := 1; i < len(lines) && i < 12; i++ {
+			if strings.HasPrefix(lines[i], "-----") {
+				blank(0, i+1)
+				break
+			}
+		}
+	}
+
+	var fence string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(lower, fence) {
+				fence = ""
+			}
+			lines[i] = ""
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+			lines[i] = ""
+		case strings.HasPrefix(lower, "#+begin_src") || strings.HasPrefix(lower, "#+begin_example"):
+			fence = "#+end_"
+			lines[i] = ""
+		case strings.HasPrefix(trimmed, "#+"):
+			lines[i] = ""
+		default:
+			lines[i] = skipRe.ReplaceAllStringFunc(line, func(s string) string {
+				return strings.Repeat(" ", len([]rune(s)))
+			})
+		}
+	}
+	return lines
+}
+
+// Check runs the checker command, e.g. []string{"aspell", "-a"}, on text
+// and returns its misspellings in order.
+func Check(command []string, text string) ([]Misspelling, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no spell checker configured")
+	}
+	lines := Prepare(text)
+	var in bytes.Buffer
+	for _, line := range lines {
+		// A leading ^ keeps lines from being read as checker commands.
+		in.WriteString("^" + line + "\n")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = &in
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %v: %s", command[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %v", command[0], err)
+	}
+	return Parse(bytes.NewReader(out), lines)
+}
+
+// Parse reads the checker's answers to lines from r: after a banner line,
+// one result line per word, each line's results ended by an empty line.
+// "& word n offset: s1, s2" reports a word with suggestions, "# word
+// offset" one without; other results are correct words. Columns are found
+// in lines rather than taken from the offsets, which checkers count
+// differently.
+func Parse(r io.Reader, lines []string) ([]Misspelling, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !sc.Scan() {
+		return nil, sc.Err()
+	}
+	var ms []Misspelling
+	line, from := 0, 0 // input line and rune column searched from
+	for sc.Scan() {
+		res := sc.Text()
+		if res == "" {
+			line++
+			from = 0
+			continue
+		}
+		if res[0] != '&' && res[0] != '#' {
+			continue
+		}
+		head, sugg, _ := strings.Cut(res, ": ")
+		fields := strings.Fields(head)
+		if len(fields) < 2 {
+			continue
+		}
+		m := Misspelling{Line: line + 1, Col: from + 1, Word: fields[1]}
+		if res[0] == '&' {
+			for _, s := range strings.Split(sugg, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					m.Suggestions = append(m.Suggestions, s)
+				}
+			}
+		}
+		if line < len(lines) {
+			if col := WordIndex(lines[line], m.Word, from); col >= 0 {
+				m.Col = col + 1
+				from = col + len([]rune(m.Word))
+			}
+		}
+		ms = append(ms, m)
+	}
+	return ms, sc.Err()
+}
+
+// WordIndex returns the rune column of the first whole-word occurrence of
+// word in line at or after column from, or -1.
+func WordIndex(line, word string, from int) int {
+	runes := []rune(line)
+	w := []rune(word)
+	for i := from; i+len(w) <= len(runes); i++ {
+		if string(runes[i:i+len(w)]) != word {
+			continue
+		}
+		if (i == 0 || !isWordRune(runes[i-1])) && (i+len(w) == len(runes) || !isWordRune(runes[i+len(w)])) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return r == '\'' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,60 @@
+package spell
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrepare(t *testing.T) {
+	text := strings.Join([]string{
+		"---",
+		"title:      speling",
+		"---",
+		"",
+		"See denote:20240101T120000 and https://exmaple.org for `fmtt`.",
+		"```",
+		"func mian() {}",
+		"```",
+		"Teh end.",
+	}, "\n")
+	got := Prepare(text)
+	want := []string{
+		"", "", "", "",
+		"See " + strings.Repeat(" ", 22) + " and " + strings.Repeat(" ", 19) + " for " + strings.Repeat(" ", 6) + ".",
+		"", "", "",
+		"Teh end.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Prepare() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	lines := []string{"teh cat and teh dog", "", "naïve wrd"}
+	out := strings.Join([]string{
+		"@(#) International Ispell Version 3.1.20 (but really Aspell 0.60.8)",
+		"& teh 3 0: the, tech, ten",
+		"*",
+		"*",
+		"& teh 3 12: the, tech, ten",
+		"*",
+		"",
+		"",
+		"*",
+		"# wrd 7",
+		"",
+	}, "\n")
+	got, err := Parse(strings.NewReader(out), lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Misspelling{
+		{Line: 1, Col: 1, Word: "teh", Suggestions: []string{"the", "tech", "ten"}},
+		{Line: 1, Col: 13, Word: "teh", Suggestions: []string{"the", "tech", "ten"}},
+		{Line: 3, Col: 7, Word: "wrd"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
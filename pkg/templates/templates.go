@@ -0,0 +1,116 @@
+// Package templates loads note templates for quick capture. A template
+// is a file in the template directory named after the template, with or
+// without an extension. It may start with settings in the config file
+// format, key=value lines ended by a blank line:
+//
+//	dir=meetings
+//	tags=meeting,agenda
+//
+//	## Attendees
+//
+//	## Notes for {{.Title}}
+//
+// dir is the subdirectory new notes go to and tags are added to the
+// note's own. The rest is the note body, a text/template executed with
+// Vars.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template is a parsed note template.
+type Template struct {
+	Name string
+	Dir  string   // subdirectory of the denote directory, or ""
+	Tags []string // default tags
+	body *template.Template
+}
+
+// Vars are the values available to a template body.
+type Vars struct {
+	Identifier string
+	Title      string
+	Tags       []string
+	Date       time.Time
+}
+
+// Load reads the template called name from dir.
+func Load(dir, name string) (*Template, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no template directory configured")
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name: %q", name)
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, name+".*"))
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no template %s in %s", name, dir)
+		}
+		path = matches[0]
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(name, data)
+}
+
+// Parse parses a template called name from data.
+func Parse(name string, data []byte) (*Template, error) {
+	t := &Template{Name: name}
+	text := string(data)
+	if header, body, ok := strings.Cut(text, "\n\n"); ok && isHeader(header) {
+		for _, line := range strings.Split(header, "\n") {
+			key, val, _ := strings.Cut(line, "=")
+			key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+			switch key {
+			case "dir":
+				t.Dir = strings.Trim(val, "/")
+			case "tags":
+				for _, tag := range strings.Split(val, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						t.Tags = append(t.Tags, tag)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("template %s: unknown setting %q", name, key)
+			}
+		}
+		text = body
+	}
+	body, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	t.body = body
+	return t, nil
+}
+
+// isHeader reports whether every line of s is a key=value setting.
+func isHeader(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.ContainsAny(strings.TrimSpace(key), " \t") {
+			return false
+		}
+	}
+	return true
+}
+
+// Execute returns the note body for vars.
+func (t *Template) Execute(vars Vars) (string, error) {
+	var buf bytes.Buffer
+	if err := t.body.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return buf.String(), nil
+}
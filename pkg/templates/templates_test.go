@@ -0,0 +1,59 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tmpl, err := Parse("meeting", []byte("dir=/meetings/\ntags=meeting, agenda\n\n## {{.Title}}\n\n{{.Date.Format \"2006-01-02\"}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Dir != "meetings" || !reflect.DeepEqual(tmpl.Tags, []string{"meeting", "agenda"}) {
+		t.Errorf("settings = %q %q", tmpl.Dir, tmpl.Tags)
+	}
+	body, err := tmpl.Execute(Vars{Title: "Weekly sync", Date: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Weekly sync\n\n2024-01-15\n"; body != want {
+		t.Errorf("Execute() = %q, want %q", body, want)
+	}
+}
+
+func TestParseWithoutHeader(t *testing.T) {
+	tmpl, err := Parse("plain", []byte("Agenda: a=b\n\nmore\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Dir != "" || tmpl.Tags != nil {
+		t.Errorf("settings = %q %q, want none", tmpl.Dir, tmpl.Tags)
+	}
+	if body, _ := tmpl.Execute(Vars{}); body != "Agenda: a=b\n\nmore\n" {
+		t.Errorf("Execute() = %q", body)
+	}
+
+	if _, err := Parse("bad", []byte("folder=x\n\nbody")); err == nil {
+		t.Error("Parse() accepted an unknown setting")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "meeting.md"), []byte("tags=meeting\n\nbody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := Load(dir, "meeting")
+	if err != nil || tmpl.Tags[0] != "meeting" {
+		t.Fatalf("Load() = %v, %v", tmpl, err)
+	}
+	for _, name := range []string{"missing", "../meeting", ""} {
+		if _, err := Load(dir, name); err == nil {
+			t.Errorf("Load(%q) succeeded", name)
+		}
+	}
+}
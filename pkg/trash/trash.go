@@ -0,0 +1,202 @@
+// Package trash keeps deleted notes for a while so that they can be
+// restored. Every trashed note is a directory named by its identifier,
+// holding a copy of the note's file and an info file recording where the
+// file was and when it was deleted.
+package trash
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const infoName = "info"
+
+// Entry is one trashed note.
+type Entry struct {
+	Identifier string
+	Path       string // where the note was
+	File       string // the note's file in the trash
+	Deleted    time.Time
+}
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("%s | %s | %s", e.Identifier, e.Deleted.Format(time.RFC3339), e.Path)
+}
+
+// Dir returns the trash directory, $XDG_DATA_HOME/acme-denote/trash or
+// ~/.local/share/acme-denote/trash if XDG_DATA_HOME is not set.
+func Dir() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "acme-denote", "trash"), nil
+}
+
+// Save copies the file at path of the note with identifier id into the
+// trash in dir, replacing any earlier copy of the same note. The caller
+// then deletes the original.
+func Save(dir, id, path string, now time.Time) (*Entry, error) {
+	entryDir := filepath.Join(dir, id)
+	if err := os.RemoveAll(entryDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(entryDir, 0700); err != nil {
+		return nil, err
+	}
+	e := &Entry{Identifier: id, Path: path, File: filepath.Join(entryDir, filepath.Base(path)), Deleted: now.Truncate(time.Second)}
+	if err := copyFile(path, e.File); err != nil {
+		os.RemoveAll(entryDir)
+		return nil, err
+	}
+	info := fmt.Sprintf("path %s\ndeleted %s\n", e.Path, e.Deleted.Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(entryDir, infoName), []byte(info), 0600); err != nil {
+		os.RemoveAll(entryDir)
+		return nil, err
+	}
+	return e, nil
+}
+
+// Get returns the trashed note with identifier id, or nil if it is not in
+// the trash.
+func Get(dir, id string) (*Entry, error) {
+	entryDir := filepath.Join(dir, id)
+	f, err := os.Open(filepath.Join(entryDir, infoName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	e := &Entry{Identifier: id}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, val, _ := strings.Cut(sc.Text(), " ")
+		switch key {
+		case "path":
+			e.Path = val
+		case "deleted":
+			if e.Deleted, err = time.Parse(time.RFC3339, val); err != nil {
+				return nil, fmt.Errorf("invalid trash entry %s: %v", id, err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if e.Path == "" || e.Deleted.IsZero() {
+		return nil, fmt.Errorf("invalid trash entry %s", id)
+	}
+	e.File = filepath.Join(entryDir, filepath.Base(e.Path))
+	return e, nil
+}
+
+// List returns the notes in the trash, ordered by identifier. Invalid
+// entries are skipped.
+func List(dir string) ([]*Entry, error) {
+	dirents, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, d := range dirents {
+		if !d.IsDir() {
+			continue
+		}
+		if e, err := Get(dir, d.Name()); err == nil && e != nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Identifier < entries[j].Identifier })
+	return entries, nil
+}
+
+// Restore moves the trashed note with identifier id back to where it
+// was and removes it from the trash. An existing file at that path is
+// not overwritten.
+func Restore(dir, id string) (*Entry, error) {
+	e, err := Get(dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("not in trash: %s", id)
+	}
+	if _, err := os.Stat(e.Path); err == nil {
+		return nil, fmt.Errorf("%s already exists", e.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(e.File, e.Path); err != nil {
+		// The trash may be on another file system.
+		if err := copyFile(e.File, e.Path); err != nil {
+			return nil, err
+		}
+	}
+	return e, Remove(dir, id)
+}
+
+// Remove removes the note with identifier id from the trash, e.g. when
+// its deletion failed after Save.
+func Remove(dir, id string) error {
+	return os.RemoveAll(filepath.Join(dir, id))
+}
+
+// Purge removes the notes deleted before t from the trash and returns
+// them.
+func Purge(dir string, t time.Time) ([]*Entry, error) {
+	entries, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var purged []*Entry
+	for _, e := range entries {
+		if !e.Deleted.Before(t) {
+			continue
+		}
+		if err := Remove(dir, e.Identifier); err != nil {
+			return purged, err
+		}
+		purged = append(purged, e)
+	}
+	return purged, nil
+}
+
+// copyFile copies src to dst, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,85 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	notes, dir := t.TempDir(), t.TempDir()
+	const id = "20240101T120000"
+	path := filepath.Join(notes, "journal", id+"--first.md")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := Save(dir, id, path, now); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+
+	entries, err := List(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("List() = %v, %v", entries, err)
+	}
+	if e := entries[0]; e.Identifier != id || e.Path != path || !e.Deleted.Equal(now) {
+		t.Errorf("List()[0] = %v", e)
+	}
+
+	if _, err := Restore(dir, id); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello\n" {
+		t.Errorf("restored file = %q, %v", data, err)
+	}
+	if e, err := Get(dir, id); err != nil || e != nil {
+		t.Errorf("Get(restored) = %v, %v; want nil", e, err)
+	}
+	if _, err := Restore(dir, id); err == nil {
+		t.Error("Restore() of a note not in the trash succeeded")
+	}
+}
+
+func TestRestoreExisting(t *testing.T) {
+	notes, dir := t.TempDir(), t.TempDir()
+	const id = "20240101T120000"
+	path := filepath.Join(notes, id+"--first.md")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Save(dir, id, path, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(dir, id); err == nil {
+		t.Error("Restore() overwrote an existing file")
+	}
+	if e, _ := Get(dir, id); e == nil {
+		t.Error("failed Restore() removed the note from the trash")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	notes, dir := t.TempDir(), t.TempDir()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"20240101T120000", "20240102T120000"} {
+		path := filepath.Join(notes, id+"--note.md")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Save(dir, id, path, now.AddDate(0, 0, -10*(i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := Purge(dir, now.AddDate(0, 0, -15))
+	if err != nil || len(purged) != 1 || purged[0].Identifier != "20240102T120000" {
+		t.Fatalf("Purge() = %v, %v; want the note deleted 20 days ago", purged, err)
+	}
+	if entries, _ := List(dir); len(entries) != 1 || entries[0].Identifier != "20240101T120000" {
+		t.Errorf("List() after Purge() = %v", entries)
+	}
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Rename moves oldpath to newpath like os.Rename. When they are on
+// different devices, e.g. a note moved to or from mounted media, it
+// copies the file next to newpath, renames the copy into place, and
+// removes oldpath. The copy keeps the original's mode, modification
+// time, and extended attributes.
+func Rename(oldpath, newpath string) error {
+	err := os.Rename(oldpath, newpath)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	info, err := os.Stat(oldpath)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(oldpath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := replace(newpath, oldpath, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := os.Chtimes(newpath, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(oldpath)
+}
+
+// WriteFile replaces the contents of path with data. Unlike
+// os.WriteFile, an existing file keeps its mode and extended attributes,
+// and the new contents are written to a temporary file renamed into
+// place, so readers never see a partial note. A new file is created with
+// mode 0644.
+func WriteFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return os.WriteFile(path, data, 0644)
+	} else if err != nil {
+		return err
+	}
+	return replace(path, path, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// replace writes a temporary file in the directory of path with write,
+// gives it perm and the extended attributes of src, and renames it to
+// path.
+func replace(path, src string, perm os.FileMode, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if err == nil {
+		// Before the chmod, which may make the file read-only.
+		err = copyXattrs(src, tmp)
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
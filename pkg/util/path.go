@@ -0,0 +1,59 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Within reports whether path names dir or a file below it. Unlike a
+// string prefix test, it does not accept siblings that share a prefix,
+// such as /home/me/docs-evil for /home/me/docs. When both exist, their
+// symlinks are resolved as well, so a link inside dir that points
+// outside it is not within dir.
+func Within(dir, path string) bool {
+	if !within(dir, path) {
+		return false
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return true
+	}
+	real, err := resolve(path)
+	if err != nil {
+		return true
+	}
+	return within(realDir, real)
+}
+
+// within is the lexical test of Within.
+func within(dir, path string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve resolves the symlinks in path. A path that does not exist yet,
+// e.g. the target of a rename, is resolved through its nearest existing
+// parent.
+func resolve(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if !os.IsNotExist(err) {
+		return real, err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return "", err
+	}
+	real, err = resolve(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(real, filepath.Base(path)), nil
+}
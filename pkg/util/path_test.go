@@ -0,0 +1,41 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithin(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "docs")
+	outside := filepath.Join(root, "docs-evil")
+	for _, d := range []string{dir, outside} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{dir, true},
+		{filepath.Join(dir, "note.md"), true},
+		{filepath.Join(dir, "sub", "note.md"), true},
+		{filepath.Join(dir, "..note.md"), true},
+		{outside, false},
+		{filepath.Join(outside, "note.md"), false},
+		{filepath.Join(dir, "..", "docs-evil", "note.md"), false},
+		{root, false},
+		{filepath.Join(dir, "link", "note.md"), false},
+	}
+	for _, tt := range tests {
+		if got := Within(dir, tt.path); got != tt.want {
+			t.Errorf("Within(%q, %q) = %v, want %v", dir, tt.path, got, tt.want)
+		}
+	}
+}
@@ -6,13 +6,20 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Apply applies front matter to file content, replacing existing front matter if present.
 // originalContent is the current file content, fm is the new front matter to apply.
 func Apply(originalContent string, fm *metadata.FrontMatter, fileType metadata.FileType) (string, error) {
+	return ApplyAt(originalContent, fm, fileType, time.Now())
+}
+
+// ApplyAt is like Apply but records date as the note's date instead of
+// the current time.
+func ApplyAt(originalContent string, fm *metadata.FrontMatter, fileType metadata.FileType, date time.Time) (string, error) {
 	text := originalContent
-	newFrontMatter := string(frontmatter.Marshal(fm, fileType))
+	newFrontMatter := string(frontmatter.MarshalAt(fm, fileType, date))
 
 	var newText string
 	switch fileType {
@@ -68,3 +75,23 @@ func Apply(originalContent string, fm *metadata.FrontMatter, fileType metadata.F
 
 	return newText, nil
 }
+
+// StripFrontMatter returns content with its front matter and the blank
+// lines following it removed. Content without front matter is returned
+// unchanged.
+func StripFrontMatter(content string, fileType metadata.FileType) string {
+	var re *regexp.Regexp
+	switch fileType {
+	case metadata.FileTypeOrg:
+		re = regexp.MustCompile(`^(?:#\+[^\n]*\n)+\n*`)
+	case metadata.FileTypeMdYaml:
+		re = regexp.MustCompile(`(?s)^---\n.*?\n---\n\n*`)
+	case metadata.FileTypeMdToml:
+		re = regexp.MustCompile(`(?s)^\+\+\+\n.*?\n\+\+\+\n\n*`)
+	case metadata.FileTypeTxt:
+		re = regexp.MustCompile(`(?s)^title:.*?\n-+\n\n*`)
+	default:
+		return content
+	}
+	return re.ReplaceAllString(content, "")
+}
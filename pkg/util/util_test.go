@@ -213,3 +213,51 @@ func TestApplyUnsupportedType(t *testing.T) {
 		t.Errorf("Apply() error = %v, want 'unsupported file type'", err)
 	}
 }
+
+func TestStripFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		fileType metadata.FileType
+		want     string
+	}{
+		{
+			name:     "org",
+			content:  "#+title:      Note\n#+identifier: 20240101T120000\n\n* Heading\n",
+			fileType: metadata.FileTypeOrg,
+			want:     "* Heading\n",
+		},
+		{
+			name:     "markdown yaml",
+			content:  "---\ntitle: Note\n---\n\n# Heading\n",
+			fileType: metadata.FileTypeMdYaml,
+			want:     "# Heading\n",
+		},
+		{
+			name:     "markdown toml",
+			content:  "+++\ntitle = Note\n+++\n\nbody\n",
+			fileType: metadata.FileTypeMdToml,
+			want:     "body\n",
+		},
+		{
+			name:     "text",
+			content:  "title:      Note\n---------------------------\n\nbody\n",
+			fileType: metadata.FileTypeTxt,
+			want:     "body\n",
+		},
+		{
+			name:     "no front matter",
+			content:  "just a body\n",
+			fileType: metadata.FileTypeMdYaml,
+			want:     "just a body\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripFrontMatter(tt.content, tt.fileType); got != tt.want {
+				t.Errorf("StripFrontMatter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
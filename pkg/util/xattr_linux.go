@@ -0,0 +1,50 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+)
+
+// copyXattrs copies the user extended attributes of src to dst.
+// Filesystems without extended attribute support are not an error.
+func copyXattrs(src, dst string) error {
+	names, err := xattr(func(buf []byte) (int, error) { return syscall.Listxattr(src, buf) })
+	if errors.Is(err, syscall.ENOTSUP) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if !bytes.HasPrefix(name, []byte("user.")) {
+			continue
+		}
+		value, err := xattr(func(buf []byte) (int, error) { return syscall.Getxattr(src, string(name), buf) })
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setxattr(dst, string(name), value, 0); err != nil && !errors.Is(err, syscall.ENOTSUP) {
+			return err
+		}
+	}
+	return nil
+}
+
+// xattr calls get with a buffer large enough for its result.
+func xattr(get func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		n, err := get(nil)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		n, err = get(buf)
+		if errors.Is(err, syscall.ERANGE) {
+			continue // grew between calls
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestReplaceCopiesXattrs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.md")
+	if err := os.WriteFile(src, []byte("note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(src, "user.denote.test", []byte("kept"), 0); err != nil {
+		t.Skipf("extended attributes unsupported: %v", err)
+	}
+	dst := filepath.Join(dir, "dst.md")
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if err := replace(dst, src, 0400, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	n, err := syscall.Getxattr(dst, "user.denote.test", buf)
+	if err != nil || string(buf[:n]) != "kept" {
+		t.Errorf("Getxattr() = %q, %v; want %q", buf[:n], err, "kept")
+	}
+	if info, _ := os.Stat(dst); info.Mode().Perm() != 0400 {
+		t.Errorf("mode = %v, want 0400", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package util
+
+// copyXattrs is a no-op where extended attributes are not supported.
+func copyXattrs(src, dst string) error {
+	return nil
+}
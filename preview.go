@@ -0,0 +1,161 @@
+package main
+
+import (
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"denote/pkg/render"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const previewWname = "+Preview"
+
+// cmdPreview renders a note as plain text in the +Preview window, as an
+// HTML document on standard output (-html), or in a browser (-web). The
+// note is named by identifier or path, or read from standard input, in
+// which case -name supplies its file name so the format can be detected.
+func cmdPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	asHTML := fs.Bool("html", false, "write HTML to standard output")
+	web := fs.Bool("web", false, "open the rendered HTML in a browser")
+	name := fs.String("name", "", "file name of a note read from standard input")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path := *name
+	var content []byte
+	var err error
+	switch fs.NArg() {
+	case 0:
+		if path == "" {
+			return fmt.Errorf("usage: Denote preview [-html|-web] [-name file] [identifier|file]")
+		}
+		content, err = io.ReadAll(os.Stdin)
+	case 1:
+		path = fs.Arg(0)
+		if id := strings.TrimPrefix(path, "denote:"); metadata.IsIdentifier(id) {
+			if path = resolveNotePath(id); path == "" {
+				return fmt.Errorf("note not found: %s", id)
+			}
+		}
+		content, err = os.ReadFile(path)
+	default:
+		return fmt.Errorf("usage: Denote preview [-html|-web] [-name file] [identifier|file]")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, fileType, _ := frontmatter.Unmarshal(content, filepath.Ext(path))
+	if fileType == "" {
+		fileType = metadata.FileTypeMdYaml
+	}
+
+	if *asHTML || *web {
+		page := previewPage(filepath.Base(path), render.HTML(string(content), fileType, func(id string) string {
+			if p := resolveNotePath(id); p != "" {
+				return "file://" + p
+			}
+			return ""
+		}))
+		if !*web {
+			_, err := io.WriteString(os.Stdout, page)
+			return err
+		}
+		return openInBrowser(page)
+	}
+
+	w := acme.Show(wname + previewWname)
+	if w == nil {
+		if w, err = acme.New(); err != nil {
+			return err
+		}
+		w.Name(wname + previewWname)
+	}
+	defer w.CloseFiles()
+	w.Addr(",")
+	w.Write("data", []byte(render.Text(string(content), fileType, resolveNotePath)))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+	return nil
+}
+
+// resolveNotePath returns the path of the note with identifier id, or of
+// the note an alias id stands for, or "" if the server does not know it.
+func resolveNotePath(id string) string {
+	var path string
+	p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		path, err = p9client.ReadFile(f, "n/"+id+"/path")
+		if err == nil {
+			return nil
+		}
+		dir, derr := readDenoteDir(f)
+		if derr != nil {
+			return err
+		}
+		if aliases, derr := metadata.LoadAliases(dir); derr == nil && aliases.Resolve(id) != id {
+			id = aliases.Resolve(id)
+			if path, err = p9client.ReadFile(f, "n/"+id+"/path"); err == nil {
+				return nil
+			}
+		}
+		// The server may not know the note yet, e.g. one just moved into
+		// a subdirectory, so look for it in the whole tree.
+		if md, ok := metadata.FindNote(dir, id); ok {
+			path = md.Path
+			return nil
+		}
+		return err
+	})
+	return path
+}
+
+func previewPage(title, body string) string {
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" +
+		html.EscapeString(title) + "</title>\n</head>\n<body>\n" + body + "</body>\n</html>\n"
+}
+
+// openInBrowser writes page to a temporary file and opens it with
+// config.Browser, $BROWSER, or the platform opener. The file is left in
+// place since the browser may load it after this process exits.
+func openInBrowser(page string) error {
+	f, err := os.CreateTemp("", "denote-preview-*.html")
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(page); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	browser := config.Browser
+	if browser == "" {
+		browser = os.Getenv("BROWSER")
+	}
+	if browser == "" {
+		browser = "xdg-open"
+		if runtime.GOOS == "darwin" {
+			browser = "open"
+		}
+	}
+	return exec.Command(browser, "file://"+f.Name()).Start()
+}
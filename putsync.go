@@ -0,0 +1,170 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// putDebounce is how long the watcher waits after a Put before handling
+// it, so that a burst of Puts of the same file is handled once.
+const putDebounce = 500 * time.Millisecond
+
+// startPutWatcher follows the acme log in the background and syncs notes
+// Put in the denote directory with the index. Text notes Put without an
+// identifier are handled as config.AdoptOnPut says.
+func startPutWatcher() {
+	lr, err := acme.Log()
+	if err != nil {
+		logging.Warnf("failed to read the acme log: %v", err)
+		return
+	}
+	w := &putWatcher{
+		pending:  make(map[string]*time.Timer),
+		offered:  make(map[string]bool),
+		adopting: make(map[string]bool),
+	}
+	go func() {
+		defer lr.Close()
+		for {
+			e, err := lr.Read()
+			if err != nil {
+				logging.Infof("acme log closed: %v", err)
+				return
+			}
+			if e.Op == "put" {
+				w.put(e.Name)
+			}
+		}
+	}()
+}
+
+// putWatcher coalesces the Puts of each file.
+type putWatcher struct {
+	mu       sync.Mutex
+	pending  map[string]*time.Timer // by path, until the Put is handled
+	offered  map[string]bool        // files offered for adoption
+	adopting map[string]bool        // files being adopted
+}
+
+// put handles a Put of path once no further Put of it follows within
+// putDebounce.
+func (w *putWatcher) put(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t, ok := w.pending[path]; ok {
+		t.Reset(putDebounce)
+		return
+	}
+	w.pending[path] = time.AfterFunc(putDebounce, func() { w.handle(path) })
+}
+
+// handle syncs or adopts path once its Puts have settled.
+func (w *putWatcher) handle(path string) {
+	w.mu.Lock()
+	delete(w.pending, path)
+	w.mu.Unlock()
+	config.RLock()
+	defer config.RUnlock()
+	if metadata.ParseFilename(path).Identifier == "" {
+		w.adoptPutFile(path)
+		return
+	}
+	if err := syncPut(path); err != nil {
+		notifyError("failed to sync %s: %v", path, err)
+	}
+}
+
+// syncPut commits the note Put at path, as commitPut does, and has the
+// server reload it when its front matter no longer matches the note's
+// index entry, refreshing the index windows. Puts that leave the title,
+// signature, tags, and due date as they were are not synced, and files
+// that are not notes of the denote directory are skipped.
+func syncPut(path string) error {
+	id := metadata.ParseFilename(path).Identifier
+	if id == "" {
+		return nil
+	}
+	dir, err := denoteDir()
+	if err != nil || !util.Within(dir, path) {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil // renamed or removed since
+	}
+	commitPut(id, path)
+	fulltextPut(path)
+	fm, _, err := frontmatter.Unmarshal(content, filepath.Ext(path))
+	if err != nil || fm == nil {
+		return nil // no front matter to sync, e.g. an encrypted note
+	}
+	rs, err := readUnfilteredIndex()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(rs, func(n *metadata.Metadata) bool { return n.Identifier == id })
+	if i >= 0 && sameMetadata(rs[i], fm) {
+		return nil
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		if err := reloadNote(f, dir, id); err != nil {
+			return err
+		}
+		windowsMu.Lock()
+		defer windowsMu.Unlock()
+		for _, iw := range windows {
+			iw.refreshInBackground()
+		}
+		return nil
+	})
+}
+
+// commitPut commits the note with identifier id Put at path, if
+// config.GitAutoCommit is set: as a new note while git does not track
+// it, as when a note made with New is first Put, and as an update
+// otherwise.
+func commitPut(id, path string) {
+	if !config.GitAutoCommit {
+		return
+	}
+	err := p9client.With9P(func(f *client.Fsys) error {
+		repo, err := openRepo(f)
+		if err != nil {
+			return err
+		}
+		msg := vcs.UpdateMessage(id, path)
+		if tracked, err := repo.Tracked(path); err == nil && !tracked {
+			msg = vcs.CreateMessage(id, path)
+		}
+		return repo.Commit(msg, path)
+	})
+	if err != nil {
+		notifyError("git auto-commit failed: %v", err)
+	}
+}
+
+// sameMetadata reports whether the index entry n already has the
+// metadata of the front matter fm.
+func sameMetadata(n *metadata.Metadata, fm *metadata.FrontMatter) bool {
+	if n.Title != fm.Title || n.Signature != fm.Signature || !slices.Equal(n.Tags, metadata.NormalizeTags(fm.Tags)) {
+		return false
+	}
+	if fm.Due == "" {
+		return n.Due.IsZero()
+	}
+	due, err := metadata.ParseDue(fm.Due, time.Local)
+	return err == nil && due.Equal(n.Due)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/encoding/frontmatter"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// cmdRedate changes the identifier, and so the date, of a note, e.g. to
+// correct a wrongly dated import. The new identifier is the given date,
+// keeping the note's time of day if only a day is given, moved forward a
+// second at a time past identifiers already in use. The note is renamed,
+// its front matter identifier and date are updated, and denote: links to
+// the old identifier in other notes are rewritten. The old identifier is
+// kept as an alias of the new one, so links from elsewhere still resolve.
+func cmdRedate(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: Denote redate <identifier> <YYYYMMDD[Thhmmss]>")
+	}
+	oldID := strings.TrimPrefix(args[0], "denote:")
+	if !metadata.IsIdentifier(oldID) {
+		return fmt.Errorf("invalid identifier: %s", args[0])
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		notes, err := metadata.ScanDir(dir)
+		if err != nil {
+			return err
+		}
+		var note *metadata.Metadata
+		taken := make(map[string]bool, len(notes))
+		for _, n := range notes {
+			if n.Identifier == oldID {
+				note = n
+			}
+			taken[n.Identifier] = true
+		}
+		aliases, err := metadata.LoadAliases(dir)
+		if err != nil {
+			return err
+		}
+		for old := range aliases {
+			taken[old] = true // keep old links unambiguous
+		}
+		if note == nil {
+			return fmt.Errorf("note not found: %s", oldID)
+		}
+		date, err := parseRedate(args[1], oldID)
+		if err != nil {
+			return err
+		}
+		newID := metadata.FreeIdentifier(date, taken)
+
+		warnLocked(dir, oldID, note.Path)
+		if err := checkUnsaved(note.Path); err != nil {
+			return err
+		}
+		newPath := filepath.Join(filepath.Dir(note.Path), newID+filepath.Base(note.Path)[len(oldID):])
+		if err := util.Rename(note.Path, newPath); err != nil {
+			return err
+		}
+		changed := []string{note.Path, newPath}
+		snapshotNote(newID, newPath, "redate")
+		if err := setFrontMatterIdentifier(newPath, newID, date); err != nil {
+			return fmt.Errorf("renamed to %s, but failed to update front matter: %w", newPath, err)
+		}
+		reloadNoteWindow(note.Path, newPath)
+		fmt.Printf("%s -> %s\n", note.Path, filepath.Base(newPath))
+		if err := metadata.AddAlias(dir, oldID, newID); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to record alias %s: %v\n", oldID, err)
+		} else {
+			changed = append(changed, filepath.Join(dir, metadata.AliasFileName))
+		}
+
+		for _, n := range notes {
+			if n == note {
+				continue
+			}
+			if err := checkUnsaved(n.Path); err != nil {
+				fmt.Fprintf(os.Stderr, "not relinked: %v\n", err)
+				continue
+			}
+			ok, err := relink(n.Path, oldID, newID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", n.Path, err)
+				continue
+			}
+			if ok {
+				fmt.Printf("relinked %s\n", n.Path)
+				reloadNoteWindow(n.Path, n.Path)
+				changed = append(changed, n.Path)
+			}
+		}
+
+		autoCommit(f, vcs.RenameMessage(newID, note.Path, newPath), changed...)
+		runHook(hookRename, newID, note.Path, newPath)
+		return reloadIndex(f, dir)
+	})
+}
+
+// parseRedate parses a YYYYMMDD or YYYYMMDDThhmmss date in local time.
+// A date without a time takes the time of day of oldID.
+func parseRedate(s, oldID string) (time.Time, error) {
+	if len(s) == len("20060102") {
+		s += oldID[8:]
+	}
+	t, err := time.ParseInLocation("20060102T150405", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s: want YYYYMMDD or YYYYMMDDThhmmss", s)
+	}
+	return t, nil
+}
+
+// setFrontMatterIdentifier records identifier and date in the front
+// matter of the text note at path. Other files are left alone.
+func setFrontMatterIdentifier(path, identifier string, date time.Time) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".md" && ext != ".org" && ext != ".txt" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fm, fileType, err := frontmatter.Unmarshal(content, ext)
+	if err != nil || fileType == "" || fm.Identifier == "" {
+		return err // no front matter to update
+	}
+	fm.Identifier = identifier
+	updated, err := util.ApplyAt(string(content), fm, fileType, date)
+	if err != nil {
+		return err
+	}
+	return util.WriteFile(path, []byte(updated))
+}
+
+// relink rewrites denote: links to oldID in the text note at path to
+// newID, reporting whether there were any.
+func relink(path, oldID, newID string) (bool, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".md" && ext != ".org" && ext != ".txt" {
+		return false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	old := []byte("denote:" + oldID)
+	if !bytes.Contains(content, old) {
+		return false, nil
+	}
+	snapshotNote(metadata.ParseFilename(path).Identifier, path, "relink")
+	return true, util.WriteFile(path, bytes.ReplaceAll(content, old, []byte("denote:"+newID)))
+}
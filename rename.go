@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/extract"
+	"denote/pkg/metadata"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"9fans.net/go/plan9/client"
+)
+
+// cmdRenameFromFrontMatter renames each named note, or every note under
+// a named directory, to match the title, signature, and tags in its
+// front matter, e.g. after the front matter was edited by hand. Note
+// contents are not changed. Hidden entries are skipped.
+func cmdRenameFromFrontMatter(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rename-from-frontmatter", flag.ContinueOnError)
+	dryRun := fs.Bool("n", false, "print the renames without performing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: Denote rename-from-frontmatter [-n] <file|dir>...")
+	}
+	var notes metadata.Results
+	for _, arg := range fs.Args() {
+		found, err := frontMatterNotes(ctx, arg)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, found...)
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		renamed, failed := 0, 0
+		for _, md := range notes {
+			if ctx.Err() != nil {
+				break
+			}
+			newPath := extract.AdoptedPath(md)
+			if newPath == md.Path {
+				continue
+			}
+			fmt.Printf("%s -> %s\n", md.Path, filepath.Base(newPath))
+			if *dryRun {
+				continue
+			}
+			if _, err := os.Stat(newPath); err == nil {
+				fmt.Fprintf(os.Stderr, "%s: %s already exists\n", md.Path, newPath)
+				failed++
+				continue
+			}
+			warnLocked(dir, md.Identifier, md.Path)
+			if err := checkUnsaved(md.Path); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed++
+				continue
+			}
+			if err := util.Rename(md.Path, newPath); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", md.Path, err)
+				failed++
+				continue
+			}
+			reloadNoteWindow(md.Path, newPath)
+			autoCommit(f, vcs.RenameMessage(md.Identifier, md.Path, newPath), md.Path, newPath)
+			runHook(hookRename, md.Identifier, md.Path, newPath)
+			renamed++
+		}
+		if ctx.Err() != nil {
+			if renamed > 0 {
+				if err := reloadIndex(f, dir); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("interrupted: renamed %d, failed %d", renamed, failed)
+		}
+		if failed > 0 {
+			return fmt.Errorf("renamed %d, failed %d", renamed, failed)
+		}
+		if renamed == 0 {
+			return nil
+		}
+		return reloadIndex(f, dir)
+	})
+}
+
+// cmdSet changes the title, keywords, or signature of a note, e.g.
+// "Denote set 20240101T120000 title='new title' keywords=a,b". Fields are
+// given as field=value, with an optionally quoted value. Only changed
+// fields are written, all of them in one update where the server allows.
+func cmdSet(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: Denote set <identifier> field=value...")
+	}
+	id := strings.TrimPrefix(args[0], "denote:")
+	fields := map[string]string{}
+	for _, arg := range args[1:] {
+		name, val, ok := strings.Cut(arg, "=")
+		switch name {
+		case "title", "keywords", "signature":
+		default:
+			ok = false
+		}
+		if !ok {
+			return fmt.Errorf("invalid field %q: want title=, keywords=, or signature=", arg)
+		}
+		if v, ok := metadata.Unquote(val); ok {
+			val = v
+		}
+		fields[name] = val
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		_, err := setFields(f, id, fields)
+		return err
+	})
+}
+
+// setFields writes the changed fields of the note with identifier id,
+// reporting whether there were any, and then reloads its acme window,
+// commits, and runs hooks as for any rename.
+func setFields(f *client.Fsys, id string, fields map[string]string) (bool, error) {
+	old, err := p9client.ReadFields(f, id, "path", "title", "keywords", "signature")
+	if err != nil {
+		return false, fmt.Errorf("note not found: %s", id)
+	}
+	if kw, ok := fields["keywords"]; ok {
+		tags, err := checkTags(strings.Split(kw, ","), strings.Split(old["keywords"], ","), warnStderr)
+		if err != nil {
+			return false, err
+		}
+		fields["keywords"] = strings.Join(tags, ",")
+	}
+	if err := checkUnsaved(old["path"]); err != nil {
+		return false, err
+	}
+	snapshotNote(id, old["path"], "update")
+	wrote, err := p9client.WriteFields(f, id, old, fields)
+	if err != nil || !wrote {
+		return false, err
+	}
+	newPath, err := p9client.ReadFile(f, "n/"+id+"/path")
+	if err != nil {
+		// Keep reloading and committing the note, which the server
+		// leaves in its subdirectory, even if it cannot report the path.
+		md, ok := metadata.FindNote(filepath.Dir(old["path"]), id)
+		if !ok {
+			return true, nil
+		}
+		newPath = md.Path
+	}
+	reloadNoteWindow(old["path"], newPath)
+	if newPath != old["path"] {
+		autoCommit(f, vcs.RenameMessage(id, old["path"], newPath), old["path"], newPath)
+		runHook(hookRename, id, old["path"], newPath)
+	} else {
+		runHook(hookUpdate, id, "", newPath)
+	}
+	return true, nil
+}
+
+// cmdRenameBatch applies a prepared plan of renames from a file, one
+// note per line in the form Drn takes:
+//
+//	<identifier> 'Title' [==signature] [tags]
+//	<identifier> ==signature 'Title' [tags]
+//
+// Titles are quoted as in rc, with a doubled quote for a quote. As with
+// Drn, a line without a signature or tags clears them. Blank lines and
+// lines starting with # are skipped. Every line is tried; a summary is
+// printed at the end.
+func cmdRenameBatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rename-batch", flag.ContinueOnError)
+	dryRun := fs.Bool("n", false, "check the plan and print it without renaming")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: Denote rename-batch [-n] <file>")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	type rename struct {
+		line   int
+		id     string
+		fields map[string]string
+	}
+	var plan []rename
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, fields, err := parseRenameLine(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", fs.Arg(0), i+1, err)
+		}
+		plan = append(plan, rename{i + 1, id, fields})
+	}
+	if *dryRun {
+		for _, r := range plan {
+			fmt.Printf("%s: %s\n", r.id, p9client.SetCommand([]string{"keywords", "signature", "title"}, r.fields))
+		}
+		return nil
+	}
+
+	return p9client.With9P(func(f *client.Fsys) error {
+		renamed, unchanged, failed := 0, 0, 0
+		for _, r := range plan {
+			if ctx.Err() != nil {
+				break
+			}
+			wrote, err := setFields(f, r.id, r.fields)
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "%s:%d: %s: %v\n", fs.Arg(0), r.line, r.id, err)
+				failed++
+			case wrote:
+				renamed++
+			default:
+				unchanged++
+			}
+		}
+		fmt.Printf("renamed %d, unchanged %d, failed %d\n", renamed, unchanged, failed)
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d of %d lines", renamed+unchanged+failed, len(plan))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d renames failed", failed)
+		}
+		return nil
+	})
+}
+
+// parseRenameLine parses a line of a rename plan into the identifier and
+// the title, signature, and keywords to set.
+func parseRenameLine(line string) (string, map[string]string, error) {
+	words, err := rcFields(line)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(words) < 2 {
+		return "", nil, fmt.Errorf("want <identifier> 'title' [==signature] [tags]")
+	}
+	id := strings.TrimPrefix(words[0], "denote:")
+	if !metadata.IsIdentifier(id) {
+		return "", nil, fmt.Errorf("invalid identifier: %s", words[0])
+	}
+	var title, signature string
+	var tags []string
+	for i, w := range words[1:] {
+		sig, isSig := strings.CutPrefix(w, "==")
+		switch {
+		case isSig:
+			signature = sig
+		case title == "" && len(tags) == 0 && (i == 0 || strings.HasPrefix(words[i], "==")):
+			title = w
+		default:
+			tags = append(tags, w)
+		}
+	}
+	if title == "" {
+		return "", nil, fmt.Errorf("no title")
+	}
+	return id, map[string]string{"title": title, "signature": signature, "keywords": strings.Join(tags, ",")}, nil
+}
+
+// rcFields splits s into words as rc does: at blanks, except inside
+// single quotes, where a doubled quote stands for one.
+func rcFields(s string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+	inWord, quoted := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			w.WriteByte('\'')
+			i++
+		case c == '\'':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inWord {
+				words = append(words, w.String())
+				w.Reset()
+				inWord = false
+			}
+		default:
+			w.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, w.String())
+	}
+	return words, nil
+}
+
+// frontMatterNotes returns the front matter metadata of the note at path,
+// or of every note below it if it is a directory. The walk stops once ctx
+// is done.
+func frontMatterNotes(ctx context.Context, path string) (metadata.Results, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var rs metadata.Results
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		md, err := extract.FromFrontMatter(p)
+		if md != nil {
+			rs = append(rs, md)
+		}
+		return err
+	})
+	return rs, err
+}
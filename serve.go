@@ -0,0 +1,130 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/capture"
+	"denote/pkg/config"
+	"denote/pkg/importer"
+	"denote/pkg/metadata"
+	"denote/pkg/metrics"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"9fans.net/go/plan9/client"
+)
+
+// defaultFileType is the file type of notes created outside of acme.
+func defaultFileType() metadata.FileType {
+	return metadata.FileType(config.DefaultFileType)
+}
+
+// createNote writes n into the current denote directory and reloads the
+// index so the new note is visible to every client. Its tags pass the tag
+// vocabulary and it is placed in the subdirectory config.Routes gives for
+// them, as with New.
+func createNote(n importer.Note) (string, error) {
+	tags, err := checkTags(n.Tags, nil, logging.Warnf)
+	if err != nil {
+		return "", err
+	}
+	n.Tags = tags
+	routes, err := metadata.ParseRoutes(config.Routes)
+	if err != nil {
+		return "", err
+	}
+	ids, err := existingIdentifiers()
+	if err != nil {
+		return "", err
+	}
+	var path string
+	err = p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		target := dir
+		if sub := metadata.RouteDir(routes, n.Tags); sub != "" {
+			target = filepath.Join(dir, sub)
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		}
+		if path, err = importer.WriteEncrypted(target, n, defaultFileType(), ids, encryptNew(dir, false)); err != nil {
+			return err
+		}
+		runNewHook(f, path)
+		return reloadIndex(f, dir)
+	})
+	return path, err
+}
+
+// httpHandler returns the handler for every HTTP endpoint.
+func httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/capture", capture.Handler(createNote, func() ([]string, string) {
+		return config.CaptureTags, config.CaptureToken
+	}))
+	mux.HandleFunc("/feed", serveFeed)
+	mux.Handle("/metrics", metrics.Handler())
+	// A Get in the /Denote/ window reloads the configuration; requests
+	// see it either before or after.
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config.RLock()
+		defer config.RUnlock()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// listenHTTP listens on addr, refusing anything but a loopback address
+// since the endpoint creates files without further authentication.
+func listenHTTP(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("refusing to listen on non-loopback address %s", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// startHTTP serves the HTTP endpoint in the background when
+// config.HTTPAddr is set.
+func startHTTP() {
+	if config.HTTPAddr == "" {
+		return
+	}
+	l, err := listenHTTP(config.HTTPAddr)
+	if err != nil {
+		logging.Errorf("failed to start HTTP endpoint: %v", err)
+		return
+	}
+	go func() {
+		if err := http.Serve(l, httpHandler()); err != nil {
+			logging.Infof("HTTP endpoint stopped: %v", err)
+		}
+	}()
+}
+
+// cmdServe runs the HTTP endpoint in the foreground.
+func cmdServe(args []string) error {
+	addr := config.HTTPAddr
+	if len(args) == 1 {
+		addr = args[0]
+	} else if len(args) > 1 {
+		return fmt.Errorf("usage: Denote serve [addr]")
+	}
+	if addr == "" {
+		return fmt.Errorf("no address: set HTTPAddr or pass one, e.g. Denote serve 127.0.0.1:8737")
+	}
+	l, err := listenHTTP(addr)
+	if err != nil {
+		return err
+	}
+	logging.Infof("serving on http://%s", l.Addr())
+	return http.Serve(l, httpHandler())
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"denote/pkg/metadata"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// cmdSignature prints the next free Folgezettel signature relative to the
+// note with the given identifier: its next sibling or its next child.
+func cmdSignature(args []string) error {
+	fs := flag.NewFlagSet("signature", flag.ContinueOnError)
+	sibling := fs.Bool("next-sibling", false, "next signature at the same level")
+	child := fs.Bool("child", false, "next signature one level below")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *sibling == *child {
+		return fmt.Errorf("usage: Denote signature -next-sibling|-child <identifier>")
+	}
+	identifier := strings.TrimPrefix(fs.Arg(0), "denote:")
+
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var sig string
+	found := false
+	var taken []string
+	for _, n := range notes {
+		if n.Identifier == identifier {
+			sig, found = n.Signature, true
+		}
+		if n.Signature != "" {
+			taken = append(taken, n.Signature)
+		}
+	}
+	if !found {
+		return fmt.Errorf("note not found: %s", identifier)
+	}
+	if sig == "" {
+		return fmt.Errorf("note %s has no signature", identifier)
+	}
+
+	next := metadata.NextChild
+	if *sibling {
+		next = metadata.NextSibling
+	}
+	s, err := next(sig, taken)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s)
+	return nil
+}
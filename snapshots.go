@@ -0,0 +1,136 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/snapshot"
+	"denote/pkg/util"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// snapshotNote saves the current version of the note with identifier id
+// at path to the snapshot store before Denote rewrites it, if
+// config.Snapshots is set. Encrypted notes are never saved, since the
+// store would keep their content where they are not expected. Failures
+// are only logged: they must not stop the rewrite.
+func snapshotNote(id, path, reason string) {
+	if !config.Snapshots || id == "" || path == "" {
+		return
+	}
+	if config.CryptExt != "" && strings.HasSuffix(path, config.CryptExt) {
+		return
+	}
+	dir, err := snapshot.Dir()
+	if err == nil {
+		_, err = snapshot.Save(dir, id, path, reason, time.Now())
+	}
+	if err != nil {
+		logging.Warnf("failed to snapshot %s: %v", id, err)
+	}
+}
+
+// cmdSnapshot saves the current version of a note to the snapshot store,
+// whether or not config.Snapshots is set, e.g. from a script about to
+// change it.
+func cmdSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	reason := fs.String("r", "manual", "record `reason` as why the snapshot was taken")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: Denote snapshot [-r reason] <identifier>")
+	}
+	id := resolveAlias(strings.TrimPrefix(fs.Arg(0), "denote:"))
+	dir, err := snapshot.Dir()
+	if err != nil {
+		return err
+	}
+	path := resolveNotePath(id)
+	if path == "" {
+		return fmt.Errorf("note not found: %s", id)
+	}
+	s, err := snapshot.Save(dir, id, path, *reason, time.Now())
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		fmt.Printf("%s unchanged since its last snapshot\n", id)
+		return nil
+	}
+	fmt.Println(s)
+	return nil
+}
+
+// cmdSnapshots lists the snapshots of a note, newest first.
+func cmdSnapshots(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote snapshots <identifier>")
+	}
+	id := resolveAlias(strings.TrimPrefix(args[0], "denote:"))
+	dir, err := snapshot.Dir()
+	if err != nil {
+		return err
+	}
+	snaps, err := snapshot.List(dir, id)
+	if err != nil {
+		return err
+	}
+	for _, s := range snaps {
+		fmt.Println(s)
+	}
+	return nil
+}
+
+// cmdRestore rolls a note back to one of its snapshots. The note keeps
+// its current file name; the version being replaced is saved as a
+// snapshot first, so that a restore can itself be undone.
+func cmdRestore(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: Denote restore <identifier> <snapshot>")
+	}
+	id := resolveAlias(strings.TrimPrefix(args[0], "denote:"))
+	dir, err := snapshot.Dir()
+	if err != nil {
+		return err
+	}
+	s, err := snapshot.Get(dir, id, args[1])
+	if err != nil {
+		return err
+	}
+	content, err := snapshot.Content(dir, s)
+	if err != nil {
+		return err
+	}
+	path := resolveNotePath(id)
+	if path == "" {
+		return fmt.Errorf("note not found: %s", id)
+	}
+	return p9client.With9P(func(f *client.Fsys) error {
+		if err := checkUnsaved(path); err != nil {
+			return err
+		}
+		if _, err := snapshot.Save(dir, id, path, "restore", time.Now()); err != nil {
+			return fmt.Errorf("failed to snapshot %s before restoring: %w", id, err)
+		}
+		if err := util.WriteFile(path, content); err != nil {
+			return err
+		}
+		reloadNoteWindow(path, path)
+		fmt.Printf("restored %s to %s\n", id, s.ID())
+		autoCommit(f, vcs.UpdateMessage(id, path), path)
+		runHook(hookUpdate, id, "", path)
+		denoteDir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		return reloadIndex(f, denoteDir)
+	})
+}
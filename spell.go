@@ -0,0 +1,176 @@
+package main
+
+import (
+	"denote/pkg/config"
+	"denote/pkg/spell"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"9fans.net/go/acme"
+)
+
+// spellWname follows wname in the name of a note's +Spell window, which
+// ends in the note's file name.
+const spellWname = "+Spell/"
+
+// cmdSpell checks the spelling of the note in the calling acme window
+// ($winid) and lists the misspellings in a +Spell window, one per line as
+// path:line word: suggestions. Right-clicking the address shows the word
+// in the note. Middle-clicking a suggestion, or any word typed on the
+// line, replaces the misspelled word in the note with it and drops the
+// line. Get checks the note again. cmdSpell returns when the +Spell
+// window is deleted.
+func cmdSpell(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote spell")
+	}
+	winid, err := strconv.Atoi(os.Getenv("winid"))
+	if err != nil {
+		return fmt.Errorf("spell: $winid not set")
+	}
+	note, err := acme.Open(winid, nil)
+	if err != nil {
+		return err
+	}
+	defer note.CloseFiles()
+	tag, err := note.ReadAll("tag")
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(tag))
+	if len(fields) == 0 {
+		return fmt.Errorf("spell: window %d has no name", winid)
+	}
+	path := fields[0]
+
+	// A +Spell window left by an earlier check of the note is replaced;
+	// deleting it ends the process serving it.
+	name := wname + spellWname + filepath.Base(path)
+	if old := acme.Show(name); old != nil {
+		old.Ctl("delete")
+		old.CloseFiles()
+	}
+	w, err := acme.New()
+	if err != nil {
+		return err
+	}
+	defer w.CloseFiles()
+	w.Name(name)
+	w.Write("tag", []byte("Get"))
+	if err := refreshSpell(w, note, path); err != nil {
+		w.Ctl("delete")
+		return err
+	}
+
+	for e := range w.EventChan() {
+		switch e.C2 {
+		case 'X':
+			if string(e.Text) == "Get" {
+				if err := refreshSpell(w, note, path); err != nil {
+					notifyError("spell: %v", err)
+				}
+				break
+			}
+			w.WriteEvent(e)
+		case 'x':
+			if !replaceMisspelling(w, note, e.Q0, string(e.Text)) {
+				w.WriteEvent(e)
+			}
+		default:
+			w.WriteEvent(e)
+		}
+	}
+	return nil
+}
+
+// refreshSpell checks the body of the note window and lists its
+// misspellings in w.
+func refreshSpell(w, note *acme.Win, path string) error {
+	body, err := note.ReadAll("body")
+	if err != nil {
+		return err
+	}
+	ms, err := spell.Check(strings.Fields(config.SpellCommand), string(body))
+	if err != nil {
+		return err
+	}
+	var buf strings.Builder
+	for _, m := range ms {
+		fmt.Fprintf(&buf, "%s:%d %s:", path, m.Line, m.Word)
+		for _, s := range m.Suggestions {
+			buf.WriteString(" " + s)
+		}
+		buf.WriteString("\n")
+	}
+	w.Addr(",")
+	w.Write("data", []byte(buf.String()))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+	return nil
+}
+
+// replaceMisspelling replaces, in the note window, the misspelled word of
+// the +Spell line holding offset q with word, and deletes the line. It
+// reports whether q was on a misspelling line and word a replacement.
+func replaceMisspelling(w, note *acme.Win, q int, word string) bool {
+	body, err := w.ReadAll("body")
+	if err != nil {
+		return false
+	}
+	runes := []rune(string(body))
+	start, end := lineBounds(runes, q)
+	addr, rest, ok := strings.Cut(string(runes[start:end]), " ")
+	i := strings.LastIndex(addr, ":")
+	bad, _, _ := strings.Cut(rest, ":")
+	if !ok || i < 0 || word == "" || word == bad || strings.ContainsAny(word, " :") {
+		return false
+	}
+	line, err := strconv.Atoi(addr[i+1:])
+	if err != nil {
+		return false
+	}
+
+	text, err := note.ReadAll("body")
+	if err != nil {
+		notifyError("spell: %v", err)
+		return true
+	}
+	nr := []rune(string(text))
+	lstart := 0
+	for l := 1; l < line && lstart < len(nr); lstart++ {
+		if nr[lstart] == '\n' {
+			l++
+		}
+	}
+	lend := lstart
+	for lend < len(nr) && nr[lend] != '\n' {
+		lend++
+	}
+	col := spell.WordIndex(string(nr[lstart:lend]), bad, 0)
+	if col < 0 {
+		notifyError("spell: %s is no longer on line %d", bad, line)
+		return true
+	}
+	q0 := lstart + col
+	if err := note.Addr("#%d,#%d", q0, q0+len([]rune(bad))); err != nil {
+		notifyError("spell: %v", err)
+		return true
+	}
+	note.Write("data", []byte(word))
+	note.Ctl("dot=addr")
+	note.Ctl("show")
+
+	if end < len(runes) {
+		end++
+	}
+	if w.Addr("#%d,#%d", start, end) == nil {
+		w.Write("data", nil)
+		w.Ctl("clean")
+	}
+	return true
+}
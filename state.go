@@ -0,0 +1,78 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// viewState is the /Denote/ window's working view, restored when Denote
+// starts again.
+type viewState struct {
+	Query   string   `json:"query"`   // last Look query, including sort:
+	Dot     int      `json:"dot"`     // character offset of dot
+	History []string `json:"history"` // recent queries, newest first
+}
+
+// statePath returns the file the view state is kept in. Each service
+// has its own.
+func statePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acme-denote", p9client.ServiceFile("state")+".json"), nil
+}
+
+// loadState returns the saved view, or the zero view if there is none.
+func loadState() viewState {
+	var st viewState
+	path, err := statePath()
+	if err != nil {
+		return st
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warnf("failed to read state: %v", err)
+		}
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		logging.Warnf("failed to parse state %s: %v", path, err)
+		return viewState{}
+	}
+	return st
+}
+
+// save records the query, dot, and query history of the main window.
+// Other index windows are not restored.
+func (iw *indexWindow) save() {
+	if !iw.main {
+		return
+	}
+	iw.mu.Lock()
+	st := viewState{Query: iw.query, History: history.list()}
+	if err := iw.win.Ctl("addr=dot"); err == nil {
+		if q0, _, err := iw.win.ReadAddr(); err == nil {
+			st.Dot = q0
+		}
+	}
+	iw.mu.Unlock()
+
+	path, err := statePath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(st, "", "\t"); err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+	}
+	if err != nil {
+		logging.Errorf("failed to save state: %v", err)
+	}
+}
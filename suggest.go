@@ -0,0 +1,189 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/metadata"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+// suggestWname follows wname in the name of a note's tag suggestion
+// window, which ends in the note's identifier.
+const suggestWname = "+Suggest/"
+
+const (
+	suggestMax        = 8  // tags proposed for a note
+	suggestCandidates = 40 // keywords considered
+)
+
+// suggestTags proposes tags for the text note with identifier id from the
+// keywords that set its contents apart from the rest of the collection.
+// Keywords already used as tags elsewhere come first, so that the
+// collection's vocabulary is reused; with the reject tag policy only
+// known tags are proposed. The note's own tags are left out.
+func suggestTags(id string) ([]string, error) {
+	dir, err := denoteDir()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var note *metadata.Metadata
+	for _, n := range notes {
+		if n.Identifier == id {
+			note = n
+			break
+		}
+	}
+	if note == nil {
+		return nil, fmt.Errorf("note not found: %s", id)
+	}
+	switch strings.ToLower(filepath.Ext(note.Path)) {
+	case ".md", ".org", ".txt":
+	default:
+		return nil, fmt.Errorf("not a text note: %s", note.Path)
+	}
+	ix, err := loadFulltext(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	voc := vocabulary()
+	onlyKnown := len(voc.Known) > 0 && voc.Policy == metadata.TagPolicyReject
+	tags := completeTags(notes, "")
+	if onlyKnown {
+		tags = voc.Known
+	}
+	known := make(map[string]bool)
+	for _, tag := range tags {
+		known[tag] = true
+	}
+	var used, fresh []string
+	for _, kw := range ix.Keywords(note.Path, suggestCandidates) {
+		switch {
+		case slices.Contains(note.Tags, kw.Term):
+		case known[kw.Term]:
+			used = append(used, kw.Term)
+		case !onlyKnown:
+			fresh = append(fresh, kw.Term)
+		}
+	}
+	tags = append(used, fresh...)
+	return tags[:min(suggestMax, len(tags))], nil
+}
+
+// applyTags adds tags to the keywords of the note with identifier id,
+// through the same checks and hooks as any change of keywords.
+func applyTags(id string, tags []string) error {
+	return p9client.With9P(func(f *client.Fsys) error {
+		old, err := p9client.ReadFields(f, id, "keywords")
+		if err != nil {
+			return fmt.Errorf("note not found: %s", id)
+		}
+		keywords := metadata.NormalizeTags(append(strings.Split(old["keywords"], ","), tags...))
+		_, err = setFields(f, id, map[string]string{"keywords": strings.Join(keywords, ",")})
+		return err
+	})
+}
+
+// cmdSuggest prints the tags proposed for a note, one per line.
+func cmdSuggest(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote suggest <identifier>")
+	}
+	tags, err := suggestTags(resolveAlias(strings.TrimPrefix(args[0], "denote:")))
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		fmt.Println(tag)
+	}
+	return nil
+}
+
+// openSuggestWindow proposes tags for the note with identifier id in a
+// window listing one tag per line. Deleting lines rejects tags; Apply adds
+// the remaining ones to the note, closes the window, and calls applied.
+// Get proposes again.
+func openSuggestWindow(id string, applied func()) {
+	name := wname + suggestWname + id
+	w := acme.Show(name)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open %s: %v", name, err)
+			return
+		}
+		w.Name(name)
+		w.Write("tag", []byte("Apply Get"))
+		go suggestLoop(w, id, applied)
+	}
+	refreshSuggest(w, id)
+}
+
+func suggestLoop(w *acme.Win, id string, applied func()) {
+	defer w.CloseFiles()
+	for e := range w.EventChan() {
+		switch e.C2 {
+		case 'x', 'X':
+			switch string(e.Text) {
+			case "Apply":
+				body, err := w.ReadAll("body")
+				if err != nil {
+					notifyError("failed to read suggestions: %v", err)
+					break
+				}
+				tags := strings.Fields(string(body))
+				if len(tags) == 0 {
+					notifyError("no tags to apply to %s", id)
+					break
+				}
+				if err := applyTags(id, tags); err != nil {
+					notifyError("failed to tag %s: %v", id, err)
+					break
+				}
+				w.Ctl("delete")
+				applied()
+			case "Get":
+				refreshSuggest(w, id)
+			default:
+				w.WriteEvent(e)
+			}
+		case 'l', 'L':
+			if !plumbIdentifier(string(e.Text)) {
+				w.WriteEvent(e)
+			}
+		default:
+			w.WriteEvent(e)
+		}
+	}
+}
+
+func refreshSuggest(w *acme.Win, id string) {
+	tags, err := suggestTags(id)
+	if err != nil {
+		notifyError("failed to suggest tags: %v", err)
+		return
+	}
+	if len(tags) == 0 {
+		notifyError("no tags to suggest for %s", id)
+	}
+	var body string
+	for _, tag := range tags {
+		body += tag + "\n"
+	}
+	w.Addr(",")
+	w.Write("data", []byte(body))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
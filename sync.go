@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/config"
+	"denote/pkg/encoding/results"
+	"denote/pkg/metadata"
+	"denote/pkg/metrics"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const changesWname = "+Changes"
+
+// errorsWriter streams output line by line to the +Errors window of a
+// directory, so long-running commands show progress as they go.
+type errorsWriter struct {
+	src string
+	buf []byte
+}
+
+func (w *errorsWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		acme.Err(w.src, string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any trailing partial line.
+func (w *errorsWriter) Flush() {
+	if len(w.buf) > 0 {
+		acme.Err(w.src, string(w.buf))
+		w.buf = nil
+	}
+}
+
+// syncAll runs config.SyncCommand in the denote directory, if one is
+// configured, and then asks the server to reload every note from disk.
+// It returns a report of how the index changed, so that the effect of a
+// sync done elsewhere can be seen.
+func syncAll() (*results.Report, error) {
+	before, err := readUnfilteredIndex()
+	if err != nil {
+		return nil, err
+	}
+	err = p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+
+		if config.SyncCommand != "" {
+			out := &errorsWriter{src: dir + "/"}
+			fmt.Fprintf(out, "%% %s\n", config.SyncCommand)
+			cmd := exec.Command("sh", "-c", config.SyncCommand)
+			cmd.Dir = dir
+			cmd.Stdout = out
+			cmd.Stderr = out
+			err = cmd.Run()
+			out.Flush()
+			if err != nil {
+				return fmt.Errorf("sync command failed: %w", err)
+			}
+		}
+
+		return reloadIndex(f, dir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	after, err := readUnfilteredIndex()
+	if err != nil {
+		return nil, err
+	}
+	return results.Compare(before, after), nil
+}
+
+// readUnfilteredIndex reads the index of every note, whatever the
+// filter of the windows.
+func readUnfilteredIndex() (metadata.Results, error) {
+	var rs metadata.Results
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	err := p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		if err := setFilter(f, ""); err != nil {
+			return err
+		}
+		rs, err = readFullIndex(f, dir)
+		return err
+	})
+	return rs, err
+}
+
+// openChangesWindow shows r in the +Changes window, replacing the
+// report of the previous sync. Right-click an identifier to open the
+// note.
+func openChangesWindow(r *results.Report) {
+	w := acme.Show(wname + changesWname)
+	if w == nil {
+		var err error
+		if w, err = acme.New(); err != nil {
+			logging.Errorf("failed to open changes window: %v", err)
+			return
+		}
+		w.Name(wname + changesWname)
+	}
+	defer w.CloseFiles()
+	w.Addr(",")
+	w.Write("data", results.MarshalReport(r))
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
+
+// reloadIndex asks the server to reload every note in dir from disk.
+// Changing into the current directory makes the server rescan it.
+func reloadIndex(f *client.Fsys, dir string) error {
+	defer metrics.Since("denote_index_reload_seconds", time.Now())
+	metrics.Add("denote_index_reloads_total", 1)
+	return p9client.WriteFile(f, "ctl", "cd "+dir)
+}
+
+// reloadNote asks the server to reload just the note with identifier id
+// from disk, e.g. after fixing its file by hand. Servers without the ctl
+// command "reload" rescan the whole of dir instead.
+func reloadNote(f *client.Fsys, dir, id string) error {
+	metrics.Add("denote_note_reloads_total", 1)
+	if p9client.WriteFile(f, "ctl", "reload "+id) == nil {
+		return nil
+	}
+	return reloadIndex(f, dir)
+}
+
+// cmdReload reloads the given notes from disk, or every note if none are
+// given.
+func cmdReload(args []string) error {
+	return p9client.With9P(func(f *client.Fsys) error {
+		dir, err := readDenoteDir(f)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return reloadIndex(f, dir)
+		}
+		for _, arg := range args {
+			id := resolveAlias(strings.TrimPrefix(arg, "denote:"))
+			if !metadata.IsIdentifier(id) {
+				return fmt.Errorf("invalid identifier: %s", arg)
+			}
+			if err := reloadNote(f, dir, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"denote/internal/logging"
+	"denote/pkg/config"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"9fans.net/go/acme"
+)
+
+// tagCommand is a user command from config.TagCommands.
+type tagCommand struct {
+	name string
+	path string // executable
+}
+
+// tagCommands parses config.TagCommands. Invalid entries are logged and
+// skipped.
+func tagCommands() []tagCommand {
+	var cmds []tagCommand
+	for _, entry := range config.TagCommands {
+		name, path, ok := strings.Cut(entry, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" || strings.ContainsFunc(name, unicode.IsSpace) {
+			logging.Warnf("invalid tag-commands entry %q: want Name=executable", entry)
+			continue
+		}
+		cmds = append(cmds, tagCommand{name, path})
+	}
+	return cmds
+}
+
+// findTagCommand returns the user command called name.
+func findTagCommand(name string) (tagCommand, bool) {
+	for _, c := range tagCommands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return tagCommand{}, false
+}
+
+// tagText returns the commands written to iw's tag after Look.
+func (iw *indexWindow) tagText() string {
+	tag := config.QueryWindowTag
+	if iw.main {
+		tag = config.WindowTag
+	}
+	for _, c := range tagCommands() {
+		tag += " " + c.name
+	}
+	return strings.TrimSpace(tag)
+}
+
+var identifierRe = regexp.MustCompile(`\b\d{8}T\d{6}\b`)
+
+// selectedIdentifiers returns the identifiers in arg, the argument of a
+// chorded command, or else in the selection of iw, or else on the line
+// holding dot.
+func (iw *indexWindow) selectedIdentifiers(arg string) ([]string, error) {
+	text := arg
+	if text == "" {
+		iw.mu.Lock()
+		iw.win.Ctl("addr=dot")
+		q0, q1, err := iw.win.ReadAddr()
+		var body []byte
+		if err == nil {
+			body, err = iw.win.ReadAll("body")
+		}
+		iw.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if q0 == q1 {
+			text = lineAt(body, q0)
+		} else {
+			runes := []rune(string(body))
+			text = string(runes[min(q0, len(runes)):min(q1, len(runes))])
+		}
+	}
+	return identifierRe.FindAllString(text, -1), nil
+}
+
+// runTagCommand runs c with the selected identifiers of iw on its
+// standard input, in the denote directory. Its output goes to the
+// +Errors window, as for any command run from acme.
+func (iw *indexWindow) runTagCommand(c tagCommand, arg string) {
+	ids, err := iw.selectedIdentifiers(arg)
+	if err != nil {
+		notifyError("%s: %v", c.name, err)
+		return
+	}
+	if len(ids) == 0 {
+		notifyError("%s: no notes selected", c.name)
+		return
+	}
+	dir, err := denoteDir()
+	if err != nil {
+		notifyError("%s: %v", c.name, err)
+		return
+	}
+	cmd := exec.Command(c.path)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "DENOTE_DIR="+dir)
+	cmd.Stdin = strings.NewReader(strings.Join(ids, "\n") + "\n")
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		acme.Err(dir+"/", strings.TrimRight(string(out), "\n"))
+	}
+	if err != nil {
+		notifyError("%s failed: %v", c.name, err)
+		return
+	}
+	iw.refresh()
+	iw.refreshOthers()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"denote/pkg/config"
+	"denote/pkg/metadata"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// vocabulary returns the controlled tag vocabulary from the config.
+func vocabulary() metadata.Vocabulary {
+	return metadata.Vocabulary{
+		Known:  metadata.NormalizeTags(config.KnownTags),
+		Policy: metadata.TagPolicy(config.TagPolicy),
+	}
+}
+
+// completeTags returns the tags of rs and the known tags that start with
+// prefix, sorted.
+func completeTags(rs metadata.Results, prefix string) []string {
+	tags := metadata.CompleteTags(rs, prefix)
+	for _, tag := range vocabulary().Known {
+		if strings.HasPrefix(tag, prefix) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// checkTags applies the tag vocabulary to tags written to a note that
+// had old (nil for a new note), passing each warning to warn.
+func checkTags(tags, old []string, warn func(format string, args ...any)) ([]string, error) {
+	tags, warnings, err := vocabulary().Check(metadata.NormalizeTags(tags), old)
+	for _, w := range warnings {
+		warn("%s", w)
+	}
+	return tags, err
+}
+
+// warnStderr reports a tag warning from a command run in a terminal.
+func warnStderr(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
+}
+
+// checkNewInput applies the tag vocabulary to the tags of a New input,
+// 'title' tag1,tag2, and returns the input with the tags to write. A
+// title without a subdirectory of its own ('dir/:title') is placed in the
+// subdirectory config.Routes gives for its tags. Input without a quoted
+// title is returned as is.
+func checkNewInput(input string, warn func(format string, args ...any)) (string, error) {
+	i := strings.LastIndex(input, "'")
+	if i <= 0 {
+		return input, nil
+	}
+	tags := strings.FieldsFunc(input[i+1:], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(tags) == 0 {
+		return input, nil
+	}
+	tags, err := checkTags(tags, nil, warn)
+	if err != nil {
+		return "", err
+	}
+	title := input[:i+1]
+	if strings.HasPrefix(title, "'") && !strings.Contains(title, "/:") {
+		routes, err := metadata.ParseRoutes(config.Routes)
+		if err != nil {
+			return "", err
+		}
+		if dir := metadata.RouteDir(routes, tags); dir != "" {
+			title = "'" + dir + "/:" + title[1:]
+		}
+	}
+	return title + " " + strings.Join(tags, ","), nil
+}
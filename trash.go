@@ -0,0 +1,113 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/internal/vcs"
+	"denote/pkg/config"
+	"denote/pkg/trash"
+	"flag"
+	"fmt"
+	"time"
+
+	"9fans.net/go/plan9/client"
+)
+
+// trashNote copies the note with identifier id at path into the trash
+// before it is deleted, and purges notes whose retention has run out. It
+// returns a function that takes the note out of the trash again, for when
+// the delete fails.
+func trashNote(id, path string) (undo func(), err error) {
+	dir, err := trash.Dir()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := trash.Save(dir, id, path, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to move %s to trash: %w", id, err)
+	}
+	if config.TrashRetentionDays > 0 {
+		purged, err := trash.Purge(dir, time.Now().AddDate(0, 0, -config.TrashRetentionDays))
+		if err != nil {
+			logging.Warnf("failed to purge trash: %v", err)
+		}
+		for _, e := range purged {
+			logging.Infof("purged %s from trash", e.Identifier)
+		}
+	}
+	return func() { trash.Remove(dir, id) }, nil
+}
+
+// cmdTrash lists, restores, and purges deleted notes.
+func cmdTrash(args []string) error {
+	usage := fmt.Errorf("usage: Denote trash list|restore <identifier>...|purge [-days n]")
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	dir, err := trash.Dir()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return usage
+		}
+		entries, err := trash.List(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			fmt.Println(e)
+		}
+		return nil
+
+	case "restore":
+		if len(args) < 2 {
+			return usage
+		}
+		var restored []*trash.Entry
+		for _, id := range args[1:] {
+			e, err := trash.Restore(dir, id)
+			if err != nil {
+				return err
+			}
+			restored = append(restored, e)
+			fmt.Printf("restored %s\n", e.Path)
+		}
+		// Have a running server index the restored notes.
+		err := p9client.With9P(func(f *client.Fsys) error {
+			denoteDir, err := readDenoteDir(f)
+			if err != nil {
+				return err
+			}
+			if err := reloadIndex(f, denoteDir); err != nil {
+				return err
+			}
+			for _, e := range restored {
+				autoCommit(f, vcs.RestoreMessage(e.Identifier, e.Path), e.Path)
+				runHook(hookNew, e.Identifier, "", e.Path)
+			}
+			return nil
+		})
+		if err != nil {
+			logging.Warnf("restored notes are not indexed yet: %v", err)
+		}
+		return nil
+
+	case "purge":
+		fs := flag.NewFlagSet("trash purge", flag.ContinueOnError)
+		days := fs.Int("days", 0, "purge only notes deleted more than `n` days ago")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 0 {
+			return usage
+		}
+		purged, err := trash.Purge(dir, time.Now().AddDate(0, 0, -*days))
+		for _, e := range purged {
+			fmt.Printf("purged %s\n", e)
+		}
+		return err
+	}
+	return usage
+}
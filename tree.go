@@ -0,0 +1,121 @@
+package main
+
+import (
+	"denote/internal/logging"
+	p9client "denote/internal/p9/client"
+	"denote/pkg/encoding/results"
+	"denote/pkg/extract"
+	"denote/pkg/metadata"
+	"fmt"
+
+	"9fans.net/go/acme"
+	"9fans.net/go/plan9/client"
+)
+
+const treeWname = "+Tree"
+
+// readTree returns the signature hierarchy of every note. Titles come
+// from the index and signatures from the notes in the denote directory
+// (front matter first, then the file name), since the index does not
+// carry signatures.
+func readTree() ([]byte, error) {
+	var rs metadata.Results
+	var dir string
+	indexMu.Lock()
+	err := p9client.With9P(func(f *client.Fsys) error {
+		var err error
+		if dir, err = readDenoteDir(f); err != nil {
+			return err
+		}
+		if err = setFilter(f, ""); err != nil {
+			return err
+		}
+		rs, err = readIndex(f)
+		return err
+	})
+	indexMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sigs := make(map[string]string, len(notes))
+	for _, n := range notes {
+		if md, err := extract.Metadata(n.Path, extract.FallbackNone); err == nil {
+			n = md
+		}
+		sigs[n.Identifier] = n.Signature
+	}
+	for _, r := range rs {
+		r.Signature = sigs[r.Identifier]
+	}
+	return results.MarshalTree(rs), nil
+}
+
+// cmdTree prints the signature hierarchy.
+func cmdTree(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: Denote tree")
+	}
+	tree, err := readTree()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(tree))
+	return nil
+}
+
+// openTreeWindow shows the signature hierarchy in a +Tree window.
+// Right-clicking an identifier opens the note.
+func openTreeWindow() {
+	w := acme.Show(wname + treeWname)
+	if w != nil {
+		refreshTree(w)
+		return
+	}
+	w, err := acme.New()
+	if err != nil {
+		logging.Errorf("failed to open tree window: %v", err)
+		return
+	}
+	w.Name(wname + treeWname)
+	w.Write("tag", []byte("Get"))
+	refreshTree(w)
+
+	go func() {
+		defer w.CloseFiles()
+		for e := range w.EventChan() {
+			switch e.C2 {
+			case 'x', 'X':
+				if string(e.Text) == "Get" {
+					refreshTree(w)
+				} else {
+					w.WriteEvent(e)
+				}
+			case 'l', 'L':
+				if !plumbIdentifier(string(e.Text)) {
+					w.WriteEvent(e)
+				}
+			default:
+				w.WriteEvent(e)
+			}
+		}
+	}()
+}
+
+func refreshTree(w *acme.Win) {
+	tree, err := readTree()
+	if err != nil {
+		logging.Errorf("failed to read tree: %v", err)
+		return
+	}
+	w.Addr(",")
+	w.Write("data", tree)
+	w.Ctl("clean")
+	w.Addr("#0")
+	w.Ctl("dot=addr")
+	w.Ctl("show")
+}
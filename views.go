@@ -0,0 +1,49 @@
+package main
+
+import (
+	"denote/pkg/metadata"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cmdViews builds the browsable views of the collection below out:
+// by-tag/<tag>/ and by-date/<YYYY>/<MM>/ directories of symbolic links
+// to the notes' files. Views from an earlier run are replaced.
+func cmdViews(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: Denote views <dir>")
+	}
+	out := args[0]
+	dir, err := denoteDir()
+	if err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(out); err == nil && (abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator))) {
+		return fmt.Errorf("views must be built outside the denote directory: %s", out)
+	}
+	notes, err := metadata.ScanDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, root := range metadata.ViewRoots {
+		if err := os.RemoveAll(filepath.Join(out, root)); err != nil {
+			return err
+		}
+	}
+	views := metadata.Views(notes)
+	for _, view := range metadata.ViewDirs(views) {
+		vdir := filepath.Join(out, filepath.FromSlash(view))
+		if err := os.MkdirAll(vdir, 0755); err != nil {
+			return err
+		}
+		for _, n := range views[view] {
+			if err := os.Symlink(n.Path, filepath.Join(vdir, filepath.Base(n.Path))); err != nil && !os.IsExist(err) {
+				return err
+			}
+		}
+	}
+	fmt.Printf("%d notes in %d views\n", len(notes), len(views))
+	return nil
+}